@@ -0,0 +1,70 @@
+// Command migrate rebuilds keys derived from the orders and trades hashes for entries written before they existed.
+// Run it once per deploy that adds such keys, with order intake stopped:
+//
+//	go run ./cmd/migrate -steps risk,account-book,order-indexes,order-feed,levels,trades
+package main
+
+import (
+	"context"
+	"flag"
+	"strings"
+	"trade-order-processing-service/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+type migrationStep func(ctx context.Context, orderStorage *storage.OrdersStorage, tradeStorage *storage.TradeStorage) (int, error)
+
+var steps = map[string]migrationStep{
+	"risk": func(ctx context.Context, orderStorage *storage.OrdersStorage, tradeStorage *storage.TradeStorage) (int, error) {
+		return orderStorage.RebuildRiskExposure(ctx)
+	},
+	"account-book": func(ctx context.Context, orderStorage *storage.OrdersStorage, tradeStorage *storage.TradeStorage) (int, error) {
+		return orderStorage.RebuildAccountBookIndex(ctx)
+	},
+	"order-indexes": func(ctx context.Context, orderStorage *storage.OrdersStorage, tradeStorage *storage.TradeStorage) (int, error) {
+		return orderStorage.RebuildOrderIndexes(ctx)
+	},
+	"order-feed": func(ctx context.Context, orderStorage *storage.OrdersStorage, tradeStorage *storage.TradeStorage) (int, error) {
+		return orderStorage.RebuildOrderFeedBook(ctx)
+	},
+	"levels": func(ctx context.Context, orderStorage *storage.OrdersStorage, tradeStorage *storage.TradeStorage) (int, error) {
+		return orderStorage.RebuildStockLevels(ctx)
+	},
+	"trades": func(ctx context.Context, orderStorage *storage.OrdersStorage, tradeStorage *storage.TradeStorage) (int, error) {
+		return tradeStorage.RebuildTradeRetention(ctx)
+	},
+}
+
+var stepsOrder = []string{"risk", "account-book", "order-indexes", "order-feed", "levels", "trades"}
+
+func main() {
+	redisHost := flag.String("redis", "localhost:6379", "redis address")
+	stepNames := flag.String("steps", strings.Join(stepsOrder, ","), "comma separated steps: "+strings.Join(stepsOrder, ", "))
+	flag.Parse()
+
+	client, err := storage.NewRedisClient(*redisHost)
+
+	if err != nil {
+		logrus.Fatalln("Fail connect to redis, reason: ", err.Error())
+	}
+
+	orderStorage := storage.NewOrdersStorage(client)
+	tradeStorage := storage.NewTradeStorage(client)
+
+	for _, name := range strings.Split(*stepNames, ",") {
+		step, ok := steps[strings.TrimSpace(name)]
+
+		if !ok {
+			logrus.Fatalln("Unknown migration step: ", name)
+		}
+
+		count, err := step(context.Background(), orderStorage, tradeStorage)
+
+		if err != nil {
+			logrus.WithField("step", name).Fatalln("Migration failed, reason: ", err.Error())
+		}
+
+		logrus.WithField("step", name).Infoln("Migration is done, rebuilt: ", count)
+	}
+}
@@ -0,0 +1,76 @@
+// Command orderstates lists orders that have stayed in a state longer than the given age,
+// e.g. orders stuck in NEW because the balance lock was never answered:
+//
+//	go run ./cmd/orderstates -state NEW -min-age 5m
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/service"
+	"trade-order-processing-service/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	redisHost := flag.String("redis", "localhost:6379", "redis address")
+	stateName := flag.String("state", "NEW", "order state, e.g. NEW, IN_PROCESS or OPS_ORDER_STATE_NEW")
+	minAge := flag.Duration("min-age", 0, "minimal time spent in the state")
+	limit := flag.Int64("limit", 100, "maximal number of orders")
+	flag.Parse()
+
+	state, ok := parseOrderState(*stateName)
+
+	if !ok {
+		logrus.Fatalln("Unknown order state: ", *stateName)
+	}
+
+	client, err := storage.NewRedisClient(*redisHost)
+
+	if err != nil {
+		logrus.Fatalln("Fail connect to redis, reason: ", err.Error())
+	}
+
+	queryService := service.NewOrderQueryService(storage.NewOrdersStorage(client), nil)
+
+	orders, err := queryService.GetOrdersByState(context.Background(), state, *minAge, *limit)
+
+	if err != nil {
+		logrus.Fatalln("Fail get orders by state, reason: ", err.Error())
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ORDER_ID\tACCOUNT_ID\tPAIR\tDIRECTION\tVOLUME\tFILLED\tAGE")
+
+	for _, entry := range orders {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%f\t%f\t%s\n",
+			entry.Order.OrderId,
+			entry.Order.AccountId,
+			entry.Order.CurrencyPair,
+			ops.OpsOrderDirection(entry.Order.Direction).String(),
+			entry.Order.AskVolume,
+			entry.Order.FilledVolume,
+			(time.Duration(entry.Age) * time.Millisecond).Truncate(time.Second))
+	}
+
+	writer.Flush()
+}
+
+func parseOrderState(name string) (int, bool) {
+	name = strings.ToUpper(name)
+
+	if !strings.HasPrefix(name, "OPS_ORDER_STATE_") {
+		name = "OPS_ORDER_STATE_" + name
+	}
+
+	state, ok := ops.OpsOrderState_value[name]
+
+	return int(state), ok
+}
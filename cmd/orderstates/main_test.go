@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func Test_parseOrderState(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   int
+		wantOk bool
+	}{
+		{name: "NEW", want: 0, wantOk: true},
+		{name: "in_process", want: 2, wantOk: true},
+		{name: "OPS_ORDER_STATE_PART_FILLED", want: 3, wantOk: true},
+		{name: "ops_order_state_rejected", want: 6, wantOk: true},
+		{name: "STUCK", wantOk: false},
+		{name: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseOrderState(tt.name)
+
+			if ok != tt.wantOk || (ok && got != tt.want) {
+				t.Errorf("parseOrderState() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
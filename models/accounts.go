@@ -0,0 +1,16 @@
+package models
+
+type KillSwitchModel struct {
+	AccountId   string `json:"account_id,omitempty"`
+	Enabled     bool   `json:"enabled"`
+	Reason      string `json:"reason,omitempty"`
+	UpdatedBy   string `json:"updated_by,omitempty"`
+	UpdatedDate int64  `json:"updated_date,omitempty"`
+}
+
+type ChangeKillSwitchRequest struct {
+	AccountId string `json:"account_id,omitempty"`
+	Enabled   bool   `json:"enabled"`
+	Reason    string `json:"reason,omitempty"`
+	Operator  string `json:"operator,omitempty"`
+}
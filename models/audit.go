@@ -0,0 +1,36 @@
+package models
+
+import "encoding/json"
+
+const (
+	AuditSourceTicket   = "ticket"
+	AuditSourceOrderLog = "order_log"
+	AuditSourceBps      = "bps"
+	AuditSourceMatcher  = "matcher"
+	AuditSourceTrade    = "trade"
+
+	AuditTypeLockResponse    = "LOCK_RESPONSE"
+	AuditTypeMatchAttempt    = "MATCH_ATTEMPT"
+	AuditTypeFill            = "FILL"
+	AuditTypeExecutionReport = "EXECUTION_REPORT"
+)
+
+type AuditEntryModel struct {
+	Timestamp     int64           `json:"timestamp"`
+	Source        string          `json:"source,omitempty"`
+	Type          string          `json:"type,omitempty"`
+	CorrelationId string          `json:"correlation_id,omitempty"`
+	Details       json.RawMessage `json:"details,omitempty"`
+}
+
+type OrderAuditRequest struct {
+	Id      string `json:"id,omitempty"`
+	OrderId string `json:"order_id,omitempty"`
+}
+
+type OrderAuditResponse struct {
+	Id      string            `json:"id,omitempty"`
+	OrderId string            `json:"order_id,omitempty"`
+	Entries []AuditEntryModel `json:"entries"`
+	Error   string            `json:"error,omitempty"`
+}
@@ -0,0 +1,45 @@
+package models
+
+type AuditChainRecord struct {
+	Id       string
+	Data     []byte
+	PrevHash string
+	Hash     string
+}
+
+type AuditChainVerification struct {
+	CurrencyPair string `json:"currency_pair,omitempty"`
+	Records      int64  `json:"records"`
+	LastRecordId string `json:"last_record_id,omitempty"`
+	HeadHash     string `json:"head_hash,omitempty"`
+	Valid        bool   `json:"valid"`
+	BrokenAt     string `json:"broken_at,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// VerifyAuditChainRequest verifies the chain from the last checkpoint, Full verifies it from the first record.
+type VerifyAuditChainRequest struct {
+	Id           string `json:"id,omitempty"`
+	CurrencyPair string `json:"currency_pair,omitempty"`
+	Operator     string `json:"operator,omitempty"`
+	Full         bool   `json:"full,omitempty"`
+}
+
+type AuditCheckpoint struct {
+	Date        string                `json:"date"`
+	CreatedDate int64                 `json:"created_date"`
+	Pairs       []AuditCheckpointPair `json:"pairs"`
+	Signature   string                `json:"signature,omitempty"`
+}
+
+type AuditCheckpointPair struct {
+	CurrencyPair string `json:"currency_pair"`
+	Records      int64  `json:"records"`
+	LastRecordId string `json:"last_record_id,omitempty"`
+	Hash         string `json:"hash,omitempty"`
+}
+
+type ExportAuditCheckpointRequest struct {
+	Id       string `json:"id,omitempty"`
+	Operator string `json:"operator,omitempty"`
+}
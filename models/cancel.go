@@ -0,0 +1,16 @@
+package models
+
+type MassCancelRequest struct {
+	Id           string `json:"id,omitempty"`
+	AccountId    string `json:"account_id,omitempty"`
+	CurrencyPair string `json:"currency_pair,omitempty"`
+	ExchangeWide bool   `json:"exchange_wide,omitempty"`
+	Operator     string `json:"operator,omitempty"`
+}
+
+type MassCancelResponse struct {
+	Id             string `json:"id,omitempty"`
+	CancelledCount int    `json:"cancelled_count"`
+	FailedCount    int    `json:"failed_count"`
+	Error          string `json:"error,omitempty"`
+}
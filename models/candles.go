@@ -0,0 +1,36 @@
+package models
+
+const (
+	CandleInterval1m = "1m"
+	CandleInterval5m = "5m"
+	CandleInterval1h = "1h"
+	CandleInterval1d = "1d"
+)
+
+type CandleModel struct {
+	CurrencyPair string  `json:"currency_pair,omitempty"`
+	Interval     string  `json:"interval,omitempty"`
+	OpenTime     int64   `json:"open_time"`
+	CloseTime    int64   `json:"close_time"`
+	Open         float64 `json:"open"`
+	High         float64 `json:"high"`
+	Low          float64 `json:"low"`
+	Close        float64 `json:"close"`
+	Volume       float64 `json:"volume"`
+	QuoteVolume  float64 `json:"quote_volume"`
+	TradeCount   int64   `json:"trade_count"`
+}
+
+type CandlesRequest struct {
+	Id           string `json:"id,omitempty"`
+	CurrencyPair string `json:"currency_pair,omitempty"`
+	Interval     string `json:"interval,omitempty"`
+	From         int64  `json:"from"`
+	To           int64  `json:"to"`
+}
+
+type CandlesResponse struct {
+	Id      string        `json:"id,omitempty"`
+	Candles []CandleModel `json:"candles"`
+	Error   string        `json:"error,omitempty"`
+}
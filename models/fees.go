@@ -0,0 +1,12 @@
+package models
+
+type FeeRates struct {
+	MakerFeeBps float64 `json:"maker_fee_bps,omitempty"`
+	TakerFeeBps float64 `json:"taker_fee_bps,omitempty"`
+}
+
+type FeeSchedule struct {
+	FeeRates
+	TierRates     map[string]FeeRates `json:"tier_rates,omitempty"`
+	FeeBalanceIds map[string]string   `json:"fee_balance_ids,omitempty"`
+}
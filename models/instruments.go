@@ -0,0 +1,46 @@
+package models
+
+const (
+	defaultMaxSlippageBps = 500
+	defaultLockBufferBps  = 100
+)
+
+const (
+	MatchingAlgorithmFifo = iota
+	MatchingAlgorithmProRata
+)
+
+// Unconfigured instruments convert the unfilled market remainder to limit, as the matcher always did.
+const (
+	UnfilledMarketPolicyConvertToLimit = iota
+	UnfilledMarketPolicyCancel
+	UnfilledMarketPolicyReject
+)
+
+type InstrumentSettings struct {
+	CurrencyPair         string            `json:"currency_pair,omitempty"`
+	MaxSlippageBps       float64           `json:"max_slippage_bps,omitempty"`
+	LockBufferBps        float64           `json:"lock_buffer_bps,omitempty"`
+	UnfilledMarketPolicy int               `json:"unfilled_market_policy,omitempty"`
+	Fees                 FeeSchedule       `json:"fees,omitempty"`
+	RiskLimits           RiskLimits        `json:"risk_limits,omitempty"`
+	PriceBands           PriceBandSettings `json:"price_bands,omitempty"`
+	Session              TradingSession    `json:"session,omitempty"`
+	MatchingAlgorithm    int               `json:"matching_algorithm,omitempty"`
+	LotSize              float64           `json:"lot_size,omitempty"`
+	RateLimit            RateLimitSettings `json:"rate_limit,omitempty"`
+}
+
+func NewDefaultInstrumentSettings(currencyPair string) InstrumentSettings {
+	return InstrumentSettings{
+		CurrencyPair:         currencyPair,
+		MaxSlippageBps:       defaultMaxSlippageBps,
+		LockBufferBps:        defaultLockBufferBps,
+		UnfilledMarketPolicy: UnfilledMarketPolicyConvertToLimit,
+	}
+}
+
+type PriceLevel struct {
+	Price  float64 `json:"price,omitempty"`
+	Volume float64 `json:"volume,omitempty"`
+}
@@ -0,0 +1,44 @@
+package models
+
+const (
+	PairStatusOpen = iota
+	PairStatusHalted
+	PairStatusCancelOnly
+	PairStatusClosed
+	PairStatusAuction
+)
+
+type PairStatusModel struct {
+	CurrencyPair string `json:"currency_pair,omitempty"`
+	Status       int    `json:"status"`
+	Reason       string `json:"reason,omitempty"`
+	UpdatedBy    string `json:"updated_by,omitempty"`
+	UpdatedDate  int64  `json:"updated_date,omitempty"`
+	ResumeDate   int64  `json:"resume_date,omitempty"`
+}
+
+type ChangePairStatusRequest struct {
+	CurrencyPair string `json:"currency_pair,omitempty"`
+	Status       int    `json:"status"`
+	Reason       string `json:"reason,omitempty"`
+	Operator     string `json:"operator,omitempty"`
+	DurationSec  int64  `json:"duration_sec,omitempty"`
+}
+
+type PriceBandSettings struct {
+	BandBps                 float64 `json:"band_bps,omitempty"`
+	CircuitBreakerBps       float64 `json:"circuit_breaker_bps,omitempty"`
+	CircuitBreakerWindowSec int64   `json:"circuit_breaker_window_sec,omitempty"`
+	HaltDurationSec         int64   `json:"halt_duration_sec,omitempty"`
+	QueueWhenHalted         bool    `json:"queue_when_halted,omitempty"`
+}
+
+type UncrossAuctionRequest struct {
+	CurrencyPair string `json:"currency_pair,omitempty"`
+	Operator     string `json:"operator,omitempty"`
+}
+
+type TradingSession struct {
+	OpenMinute  int `json:"open_minute,omitempty"`
+	CloseMinute int `json:"close_minute,omitempty"`
+}
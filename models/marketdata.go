@@ -0,0 +1,62 @@
+package models
+
+const (
+	OrderEventAdd     = "add"
+	OrderEventModify  = "modify"
+	OrderEventExecute = "execute"
+	OrderEventDelete  = "delete"
+)
+
+type DepthUpdateModel struct {
+	CurrencyPair string  `json:"currency_pair,omitempty"`
+	Direction    int     `json:"direction"`
+	Price        float64 `json:"price"`
+	Volume       float64 `json:"volume"`
+	Sequence     int64   `json:"sequence"`
+	Timestamp    int64   `json:"timestamp,omitempty"`
+}
+
+type DepthSnapshotModel struct {
+	CurrencyPair string       `json:"currency_pair,omitempty"`
+	Bids         []PriceLevel `json:"bids"`
+	Asks         []PriceLevel `json:"asks"`
+	Sequence     int64        `json:"sequence"`
+	Timestamp    int64        `json:"timestamp,omitempty"`
+}
+
+type DepthSnapshotRequest struct {
+	CurrencyPair string `json:"currency_pair,omitempty"`
+}
+
+type OrderEventModel struct {
+	EventType       string  `json:"event_type,omitempty"`
+	OrderId         string  `json:"order_id,omitempty"`
+	CurrencyPair    string  `json:"currency_pair,omitempty"`
+	Direction       int     `json:"direction"`
+	Price           float64 `json:"price"`
+	RemainingVolume float64 `json:"remaining_volume"`
+	ExecutedVolume  float64 `json:"executed_volume,omitempty"`
+	CreationDate    int64   `json:"creation_date,omitempty"`
+	Sequence        int64   `json:"sequence"`
+	Timestamp       int64   `json:"timestamp,omitempty"`
+}
+
+type BookOrderModel struct {
+	OrderId         string  `json:"order_id,omitempty"`
+	Price           float64 `json:"price"`
+	RemainingVolume float64 `json:"remaining_volume"`
+	CreationDate    int64   `json:"creation_date,omitempty"`
+}
+
+// OrderBookSnapshotModel lists the displayed orders of a pair, consumers apply order events above its sequence.
+type OrderBookSnapshotModel struct {
+	CurrencyPair string           `json:"currency_pair,omitempty"`
+	Bids         []BookOrderModel `json:"bids"`
+	Asks         []BookOrderModel `json:"asks"`
+	Sequence     int64            `json:"sequence"`
+	Timestamp    int64            `json:"timestamp,omitempty"`
+}
+
+type OrderBookSnapshotRequest struct {
+	CurrencyPair string `json:"currency_pair,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+const (
+	OrderLogCreated   = "created"
+	OrderLogApproved  = "approved"
+	OrderLogRejected  = "rejected"
+	OrderLogBooked    = "booked"
+	OrderLogUnbooked  = "unbooked"
+	OrderLogFilled    = "filled"
+	OrderLogCancelled = "cancelled"
+	OrderLogExpired   = "expired"
+	OrderLogUpdated   = "updated"
+)
+
+type OrderLogEventModel struct {
+	EventId   string
+	EventType string
+	Timestamp int64
+	Order     OrderModel
+}
+
+type RebuildOrdersRequest struct {
+	Id       string `json:"id,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Force    bool   `json:"force,omitempty"`
+}
@@ -0,0 +1,47 @@
+package models
+
+// OrderIndexEntry Date is the index score: the creation date in account indexes, the state change date in state indexes.
+type OrderIndexEntry struct {
+	OrderId string
+	Date    int64
+}
+
+// AccountOrdersRequest filters are optional, State and Direction are pointers because their zero values are valid states.
+type AccountOrdersRequest struct {
+	Id           string `json:"id,omitempty"`
+	AccountId    string `json:"account_id,omitempty"`
+	CurrencyPair string `json:"currency_pair,omitempty"`
+	State        *int   `json:"state,omitempty"`
+	Direction    *int   `json:"direction,omitempty"`
+	FromDate     int64  `json:"from_date,omitempty"`
+	ToDate       int64  `json:"to_date,omitempty"`
+	Cursor       string `json:"cursor,omitempty"`
+	Limit        int64  `json:"limit,omitempty"`
+}
+
+type AccountOrdersResponse struct {
+	Id         string       `json:"id,omitempty"`
+	AccountId  string       `json:"account_id,omitempty"`
+	Orders     []OrderModel `json:"orders"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+type StateOrdersRequest struct {
+	Id     string `json:"id,omitempty"`
+	State  int    `json:"state"`
+	MinAge int64  `json:"min_age,omitempty"`
+	Limit  int64  `json:"limit,omitempty"`
+}
+
+type StateOrderModel struct {
+	Order OrderModel `json:"order"`
+	Age   int64      `json:"age"`
+}
+
+type StateOrdersResponse struct {
+	Id     string            `json:"id,omitempty"`
+	State  int               `json:"state"`
+	Orders []StateOrderModel `json:"orders"`
+	Error  string            `json:"error,omitempty"`
+}
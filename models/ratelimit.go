@@ -0,0 +1,6 @@
+package models
+
+type RateLimitSettings struct {
+	RatePerSec float64 `json:"rate_per_sec,omitempty"`
+	Burst      float64 `json:"burst,omitempty"`
+}
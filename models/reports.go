@@ -0,0 +1,25 @@
+package models
+
+// LiquidityAuction marks both sides of an auction uncross, neither of them took liquidity.
+const (
+	LiquidityMaker = iota
+	LiquidityTaker
+	LiquidityAuction
+)
+
+type ExecutionReportModel struct {
+	ReportId         string  `json:"report_id,omitempty"`
+	OrderId          string  `json:"order_id,omitempty"`
+	AccountId        string  `json:"account_id,omitempty"`
+	TradeId          string  `json:"trade_id,omitempty"`
+	CurrencyPair     string  `json:"currency_pair,omitempty"`
+	Direction        int     `json:"direction"`
+	LastVolume       float64 `json:"last_volume"`
+	LastPrice        float64 `json:"last_price"`
+	CumulativeVolume float64 `json:"cumulative_volume"`
+	AveragePrice     float64 `json:"average_price"`
+	LeavesVolume     float64 `json:"leaves_volume"`
+	Liquidity        int     `json:"liquidity"`
+	State            int     `json:"state"`
+	ReportDate       int64   `json:"report_date,omitempty"`
+}
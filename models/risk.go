@@ -0,0 +1,12 @@
+package models
+
+type RiskLimits struct {
+	MaxOpenOrders    int64   `json:"max_open_orders,omitempty"`
+	MaxOpenNotional  float64 `json:"max_open_notional,omitempty"`
+	MaxOrderNotional float64 `json:"max_order_notional,omitempty"`
+}
+
+type AccountExposure struct {
+	OpenOrders   int64   `json:"open_orders,omitempty"`
+	OpenNotional float64 `json:"open_notional,omitempty"`
+}
@@ -0,0 +1,28 @@
+package models
+
+const StockBookSnapshotVersion = 1
+
+type StockBookSnapshot struct {
+	Version     int
+	CreatedDate int64
+	Books       []PairBookSnapshot
+}
+
+type PairBookSnapshot struct {
+	CurrencyPair string
+	Orders       []OrderModel
+	Bids         []PriceLevel
+	Asks         []PriceLevel
+}
+
+type SaveStockBookRequest struct {
+	Id       string `json:"id,omitempty"`
+	Operator string `json:"operator,omitempty"`
+}
+
+type RestoreStockBookRequest struct {
+	Id       string `json:"id,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Force    bool   `json:"force,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+type TickerModel struct {
+	CurrencyPair     string  `json:"currency_pair,omitempty"`
+	LastPrice        float64 `json:"last_price"`
+	BestBid          float64 `json:"best_bid"`
+	BestBidVolume    float64 `json:"best_bid_volume"`
+	BestAsk          float64 `json:"best_ask"`
+	BestAskVolume    float64 `json:"best_ask_volume"`
+	High24h          float64 `json:"high_24h"`
+	Low24h           float64 `json:"low_24h"`
+	Volume24h        float64 `json:"volume_24h"`
+	QuoteVolume24h   float64 `json:"quote_volume_24h"`
+	Change24h        float64 `json:"change_24h"`
+	ChangePercent24h float64 `json:"change_percent_24h"`
+	Vwap24h          float64 `json:"vwap_24h"`
+	TradeCount24h    int64   `json:"trade_count_24h"`
+	Timestamp        int64   `json:"timestamp,omitempty"`
+}
+
+type TickerRequest struct {
+	Id           string `json:"id,omitempty"`
+	CurrencyPair string `json:"currency_pair,omitempty"`
+}
+
+type TickerResponse struct {
+	Id     string       `json:"id,omitempty"`
+	Ticker *TickerModel `json:"ticker,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
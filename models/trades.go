@@ -0,0 +1,46 @@
+package models
+
+// TradeModel AggressorSide is the direction of the taker order, auction trades have no aggressor and leave it unset.
+type TradeModel struct {
+	TradeId          string  `json:"trade_id,omitempty"`
+	CurrencyPair     string  `json:"currency_pair,omitempty"`
+	Price            float64 `json:"price,omitempty"`
+	Volume           float64 `json:"volume,omitempty"`
+	MakerOrderId     string  `json:"maker_order_id,omitempty"`
+	TakerOrderId     string  `json:"taker_order_id,omitempty"`
+	MakerAccountId   string  `json:"maker_account_id,omitempty"`
+	TakerAccountId   string  `json:"taker_account_id,omitempty"`
+	AggressorSide    int     `json:"aggressor_side,omitempty"`
+	TradeDate        int64   `json:"trade_date,omitempty"`
+	TransferId       string  `json:"transfer_id,omitempty"`
+	MakerFee         float64 `json:"maker_fee,omitempty"`
+	MakerFeeCurrency string  `json:"maker_fee_currency,omitempty"`
+	TakerFee         float64 `json:"taker_fee,omitempty"`
+	TakerFeeCurrency string  `json:"taker_fee_currency,omitempty"`
+	Auction          bool    `json:"auction,omitempty"`
+}
+
+// TradeIndexEntry Date is the index score, the trade date.
+type TradeIndexEntry struct {
+	TradeId string
+	Date    int64
+}
+
+// TradesRequest asks for the trades of an order or, without OrderId, of an account.
+// Cursor and Limit page the account trades, the trades of an order come at once.
+type TradesRequest struct {
+	Id        string `json:"id,omitempty"`
+	OrderId   string `json:"order_id,omitempty"`
+	AccountId string `json:"account_id,omitempty"`
+	Cursor    string `json:"cursor,omitempty"`
+	Limit     int64  `json:"limit,omitempty"`
+}
+
+type TradesResponse struct {
+	Id         string       `json:"id,omitempty"`
+	OrderId    string       `json:"order_id,omitempty"`
+	AccountId  string       `json:"account_id,omitempty"`
+	Trades     []TradeModel `json:"trades"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
@@ -39,7 +39,7 @@ func (l *Listener[T]) Run(ctx context.Context) {
 			if !ok {
 				continue
 			}
-			go l.processor.processMessage(ctx, msg)
+			l.processor.processMessage(ctx, msg)
 		case <-ctx.Done():
 			l.config.channel.Close()
 			return
@@ -2,6 +2,7 @@ package rabbit
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/rabbitmq/amqp091-go"
 )
@@ -9,15 +10,36 @@ import (
 type ParserFunc[T any] func([]byte) (*T, error)
 type HandlerFunc[T any] func(context.Context, *T)
 
+// GuardFunc decides whether a parsed message is handled, a rejected message is rejected without requeue
+// so the broker dead-letters it.
+type GuardFunc[T any] func(context.Context, *T) bool
+
 type Processor[T any] struct {
 	parser  ParserFunc[T]
+	guard   GuardFunc[T]
 	handler HandlerFunc[T]
 }
 
+func ParseJsonMessage[T any](body []byte) (*T, error) {
+	var message T
+
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
 func NewProcessor[T any](parser ParserFunc[T], handler HandlerFunc[T]) Processor[T] {
 	return Processor[T]{parser: parser, handler: handler}
 }
 
+func NewGuardedProcessor[T any](parser ParserFunc[T], guard GuardFunc[T], handler HandlerFunc[T]) Processor[T] {
+	return Processor[T]{parser: parser, guard: guard, handler: handler}
+}
+
+// processMessage parses and guards the message on the listener loop, so the guard holds back the intake
+// before anything is acked. Accepted messages are acked and handled in their own goroutine.
 func (p *Processor[T]) processMessage(ctx context.Context, msg amqp091.Delivery) {
 	body, err := p.parser(msg.Body)
 
@@ -26,6 +48,12 @@ func (p *Processor[T]) processMessage(ctx context.Context, msg amqp091.Delivery)
 		return
 	}
 
+	if p.guard != nil && !p.guard(ctx, body) {
+		msg.Reject(false)
+		return
+	}
+
 	msg.Ack(false)
-	p.handler(ctx, body)
+
+	go p.handler(ctx, body)
 }
@@ -0,0 +1,78 @@
+package rabbit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+type acknowledgerStub struct {
+	result string
+}
+
+func (a *acknowledgerStub) Ack(tag uint64, multiple bool) error {
+	a.result = "ack"
+	return nil
+}
+
+func (a *acknowledgerStub) Nack(tag uint64, multiple bool, requeue bool) error {
+	a.result = "nack"
+	return nil
+}
+
+func (a *acknowledgerStub) Reject(tag uint64, requeue bool) error {
+	a.result = "reject"
+	return nil
+}
+
+func TestProcessor_processMessage(t *testing.T) {
+	parser := func(body []byte) (*string, error) {
+		if len(body) == 0 {
+			return nil, errors.New("empty body")
+		}
+		message := string(body)
+		return &message, nil
+	}
+	guard := func(ctx context.Context, message *string) bool {
+		return *message != "throttled"
+	}
+	tests := []struct {
+		name        string
+		body        string
+		wantResult  string
+		wantHandled bool
+	}{
+		{name: "accepted message is acked and handled", body: "order", wantResult: "ack", wantHandled: true},
+		{name: "guarded message is rejected before ack", body: "throttled", wantResult: "reject"},
+		{name: "unparsable message is nacked", body: "", wantResult: "nack"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled := make(chan string, 1)
+			processor := NewGuardedProcessor(parser, guard, func(ctx context.Context, message *string) {
+				handled <- *message
+			})
+			acknowledger := &acknowledgerStub{}
+
+			processor.processMessage(context.Background(), amqp091.Delivery{Acknowledger: acknowledger, Body: []byte(tt.body)})
+
+			if acknowledger.result != tt.wantResult {
+				t.Errorf("processMessage() result = %v, want %v", acknowledger.result, tt.wantResult)
+			}
+
+			select {
+			case <-handled:
+				if !tt.wantHandled {
+					t.Errorf("processMessage() handled a %v message", tt.wantResult)
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.wantHandled {
+					t.Errorf("processMessage() did not handle the message")
+				}
+			}
+		})
+	}
+}
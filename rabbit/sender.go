@@ -2,6 +2,7 @@ package rabbit
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/rabbitmq/amqp091-go"
@@ -37,6 +38,24 @@ func (s *Sender) SendMessage(ctx context.Context, message protoreflect.ProtoMess
 	return nil
 }
 
+func (s *Sender) SendJsonMessage(ctx context.Context, message interface{}, exchange, rk string) error {
+	bytes, err := json.Marshal(message)
+
+	if err != nil {
+		return err
+	}
+
+	err = s.channel.PublishWithContext(ctx, exchange, rk, false, false, amqp091.Publishing{
+		ContentType: "application/json",
+		Body:        bytes,
+	})
+
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (s *Sender) handleGraceful(ctx context.Context) {
 	for {
 		select {
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/utils"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	auctionSchedulerInterval = time.Second
+)
+
+type auctionUncross struct {
+	price  float64
+	volume float64
+}
+
+func (m *MatcherService) UncrossAuction(ctx context.Context, request *models.UncrossAuctionRequest) {
+	logrus.WithFields(logrus.Fields{
+		"currencyPair": request.CurrencyPair,
+		"operator":     request.Operator}).Infoln("Received uncross auction request")
+
+	if err := m.uncrossAuction(ctx, request.CurrencyPair); err != nil {
+		logrus.WithField("currencyPair", request.CurrencyPair).Errorln("Fail uncross auction, reason: ", err.Error())
+	}
+}
+
+// RunAuctionScheduler uncrosses every auction whose uncross time has come.
+func (m *MatcherService) RunAuctionScheduler(ctx context.Context) {
+	ticker := time.NewTicker(auctionSchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			statuses, err := m.marketService.GetPairStatuses(ctx)
+
+			if err != nil {
+				logrus.Errorln("Fail get pair statuses, reason: ", err.Error())
+				continue
+			}
+
+			for _, status := range statuses {
+				if status.Status != models.PairStatusAuction || status.ResumeDate == 0 || time.Now().UTC().UnixMilli() < status.ResumeDate {
+					continue
+				}
+
+				if err = m.uncrossAuction(ctx, status.CurrencyPair); err != nil {
+					logrus.WithField("currencyPair", status.CurrencyPair).Errorln("Fail uncross auction, reason: ", err.Error())
+				}
+			}
+		}
+	}
+}
+
+func (m *MatcherService) uncrossAuction(ctx context.Context, currencyPair string) error {
+	status, err := m.marketService.GetPairStatus(ctx, currencyPair)
+
+	if err != nil {
+		return err
+	}
+
+	if status.Status != models.PairStatusAuction {
+		return staticerr.ErrorPairIsNotInAuction
+	}
+
+	lockId := uuid.NewString()
+
+	bids, err := m.lockAuctionOrders(ctx, currencyPair, int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY), lockId)
+	defer m.unlockAuctionOrders(ctx, bids, lockId)
+
+	if err != nil {
+		return err
+	}
+
+	asks, err := m.lockAuctionOrders(ctx, currencyPair, int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL), lockId)
+	defer m.unlockAuctionOrders(ctx, asks, lockId)
+
+	if err != nil {
+		return err
+	}
+
+	referencePrice, err := m.marketService.getReferencePrice(ctx, currencyPair)
+
+	if err != nil {
+		return err
+	}
+
+	uncross := computeClearingPrice(bids, asks, referencePrice)
+
+	logrus.WithFields(logrus.Fields{
+		"currencyPair": currencyPair,
+		"price":        uncross.price,
+		"volume":       uncross.volume}).Infoln("Auction clearing price is calculated")
+
+	if uncross.volume > 0 {
+		if err = m.executeAuction(ctx, bids, asks, uncross.price); err != nil {
+			m.haltFailedAuction(ctx, currencyPair, err)
+			return err
+		}
+	}
+
+	return m.marketService.ResumeTrading(ctx, currencyPair)
+}
+
+// haltFailedAuction halts the pair after a partial uncross. Every fill is stored as it happens, so once
+// the cause is fixed an operator puts the pair back in auction and the remaining crossed orders uncross.
+func (m *MatcherService) haltFailedAuction(ctx context.Context, currencyPair string, cause error) {
+	if errors.Is(cause, staticerr.ErrorTradingHalted) {
+		return
+	}
+
+	logrus.WithField("currencyPair", currencyPair).Errorln("Auction uncross is interrupted, halt trading, reason: ", cause.Error())
+
+	if err := m.marketService.HaltTrading(ctx, currencyPair, haltReasonAuctionFailed, 0); err != nil {
+		logrus.WithField("currencyPair", currencyPair).Errorln("Fail halt trading, reason: ", err.Error())
+	}
+}
+
+func (m *MatcherService) lockAuctionOrders(ctx context.Context, currencyPair string, direction int, lockId string) ([]models.OrderModel, error) {
+	orders, err := m.orderStorage.GetBookOrders(ctx, currencyPair, direction)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().UnixMilli()
+	lockedOrders := make([]models.OrderModel, 0, len(orders))
+
+	for _, oInfo := range orders {
+		if oInfo.ExpirationDate < now || m.isMakerBlocked(ctx, oInfo) {
+			continue
+		}
+
+		if err = m.orderStorage.TryLockOrder(ctx, oInfo.OrderId, lockId); err != nil {
+			logrus.WithField("orderId", oInfo.OrderId).Warningln("Order is locked, skip it in auction...")
+			continue
+		}
+
+		lockedOrders = append(lockedOrders, oInfo)
+	}
+
+	sort.SliceStable(lockedOrders, func(i, j int) bool {
+		if lockedOrders[i].LimitPrice != lockedOrders[j].LimitPrice {
+			if direction == int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY) {
+				return lockedOrders[i].LimitPrice > lockedOrders[j].LimitPrice
+			}
+			return lockedOrders[i].LimitPrice < lockedOrders[j].LimitPrice
+		}
+		if lockedOrders[i].Hidden != lockedOrders[j].Hidden {
+			return !lockedOrders[i].Hidden
+		}
+		return lockedOrders[i].CreationDate < lockedOrders[j].CreationDate
+	})
+
+	return lockedOrders, nil
+}
+
+func (m *MatcherService) unlockAuctionOrders(ctx context.Context, orders []models.OrderModel, lockId string) {
+	for _, oInfo := range orders {
+		m.unlockOrder(ctx, oInfo.OrderId, lockId)
+	}
+}
+
+// executeAuction fills crossing bids and asks (sorted by price-time priority) at the clearing price.
+func (m *MatcherService) executeAuction(ctx context.Context, bids, asks []models.OrderModel, price float64) error {
+	askIndex := 0
+
+	for i := range bids {
+		bid := &bids[i]
+
+		if bid.LimitPrice < price {
+			break
+		}
+
+		bookedBid := *bid
+
+		for askIndex < len(asks) && asks[askIndex].LimitPrice <= price && utils.GetRemainingVolume(*bid) > 0 {
+			ask := &asks[askIndex]
+
+			err := m.performMatchingOrders(ctx, bid, ask, price, math.MaxFloat64, true)
+
+			if err != nil && !errors.Is(err, staticerr.ErrorTradingHalted) {
+				return err
+			}
+
+			if utils.GetRemainingVolume(*ask) == 0 {
+				askIndex++
+			}
+
+			if err != nil {
+				if refreshErr := m.refreshBookedOrder(ctx, bookedBid, *bid); refreshErr != nil {
+					return refreshErr
+				}
+				return err
+			}
+		}
+
+		if err := m.refreshBookedOrder(ctx, bookedBid, *bid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MatcherService) refreshBookedOrder(ctx context.Context, bookedOrder, orderInfo models.OrderModel) error {
+	if bookedOrder.FilledVolume == orderInfo.FilledVolume {
+		return nil
+	}
+
+	if err := m.orderStorage.ExecuteInStockBook(ctx, bookedOrder, orderInfo); err != nil {
+		return err
+	}
+
+	m.marketDataService.PublishBookChange(ctx, models.OrderEventExecute, orderInfo)
+
+	return nil
+}
+
+// computeClearingPrice picks the price with the highest executable volume, then the lowest imbalance,
+// then the one closest to the reference price.
+func computeClearingPrice(bids, asks []models.OrderModel, referencePrice float64) auctionUncross {
+	prices := make([]float64, 0, len(bids)+len(asks))
+
+	for _, oInfo := range append(append([]models.OrderModel{}, bids...), asks...) {
+		prices = append(prices, oInfo.LimitPrice)
+	}
+
+	sort.Float64s(prices)
+
+	best := auctionUncross{}
+	bestImbalance := math.MaxFloat64
+
+	for _, price := range prices {
+		demand, supply := 0.0, 0.0
+
+		for _, bid := range bids {
+			if bid.LimitPrice >= price {
+				demand += utils.GetRemainingVolume(bid)
+			}
+		}
+
+		for _, ask := range asks {
+			if ask.LimitPrice <= price {
+				supply += utils.GetRemainingVolume(ask)
+			}
+		}
+
+		volume := utils.Min(demand, supply)
+		imbalance := math.Abs(demand - supply)
+
+		if volume < utils.VolumeEpsilon {
+			continue
+		}
+
+		switch {
+		case volume > best.volume+utils.VolumeEpsilon:
+		case volume < best.volume-utils.VolumeEpsilon:
+			continue
+		case imbalance < bestImbalance-utils.VolumeEpsilon:
+		case imbalance > bestImbalance+utils.VolumeEpsilon:
+			continue
+		case referencePrice > 0 && math.Abs(price-referencePrice) < math.Abs(best.price-referencePrice):
+		default:
+			continue
+		}
+
+		best = auctionUncross{price: price, volume: volume}
+		bestImbalance = imbalance
+	}
+
+	return best
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+)
+
+func Test_computeClearingPrice(t *testing.T) {
+	order := func(price, volume float64) models.OrderModel {
+		return models.OrderModel{LimitPrice: price, AskVolume: volume}
+	}
+	tests := []struct {
+		name           string
+		bids           []models.OrderModel
+		asks           []models.OrderModel
+		referencePrice float64
+		want           auctionUncross
+	}{
+		{
+			name: "no crossing orders",
+			bids: []models.OrderModel{order(99, 1)},
+			asks: []models.OrderModel{order(100, 1)},
+			want: auctionUncross{},
+		},
+		{
+			name: "maximises executed volume",
+			bids: []models.OrderModel{order(102, 5), order(101, 3), order(100, 2)},
+			asks: []models.OrderModel{order(99, 4), order(100, 3), order(103, 5)},
+			want: auctionUncross{price: 101, volume: 7},
+		},
+		{
+			name: "minimises imbalance on equal volume",
+			bids: []models.OrderModel{order(101, 4), order(100, 1)},
+			asks: []models.OrderModel{order(100, 2), order(101, 2)},
+			want: auctionUncross{price: 101, volume: 4},
+		},
+		{
+			name:           "closest to reference price on equal volume and imbalance",
+			bids:           []models.OrderModel{order(105, 1)},
+			asks:           []models.OrderModel{order(100, 1)},
+			referencePrice: 104,
+			want:           auctionUncross{price: 105, volume: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeClearingPrice(tt.bids, tt.asks, tt.referencePrice); got != tt.want {
+				t.Errorf("computeClearingPrice() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherService_uncrossAuction(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	buy := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY
+	tests := []struct {
+		name       string
+		corrupt    bool
+		wantErr    bool
+		wantStatus int
+		wantReason string
+	}{
+		{name: "uncross opens the pair", wantStatus: models.PairStatusOpen},
+		{name: "failed uncross halts the pair", corrupt: true, wantErr: true, wantStatus: models.PairStatusHalted, wantReason: haltReasonAuctionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			env.bookOrders(t, testOrder("bid", buy, 101, 1), testOrder("ask", sell, 100, 1))
+			env.marketService.ChangePairStatus(ctx, &models.ChangePairStatusRequest{CurrencyPair: "BTC/USDT", Status: models.PairStatusAuction})
+
+			if tt.corrupt {
+				env.server.HSet("instruments", "BTC/USDT", "{")
+			}
+
+			if err := env.matcherService.uncrossAuction(ctx, "BTC/USDT"); (err != nil) != tt.wantErr {
+				t.Fatalf("uncrossAuction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			status, err := env.marketService.GetPairStatus(ctx, "BTC/USDT")
+
+			if err != nil {
+				t.Fatalf("GetPairStatus() error = %v", err)
+			}
+
+			if status.Status != tt.wantStatus || status.Reason != tt.wantReason {
+				t.Errorf("status = %v %q, want %v %q", status.Status, status.Reason, tt.wantStatus, tt.wantReason)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			trades, err := env.tradeStorage.GetTradesByOrder(ctx, "bid")
+
+			if err != nil || len(trades) != 1 || !trades[0].Auction || trades[0].AggressorSide != 0 {
+				t.Errorf("GetTradesByOrder() = %+v, %v, want one auction trade without aggressor", trades, err)
+			}
+
+			reports := env.messageSender.sentTo(executionReportsExchange)
+
+			if len(reports) != 2 {
+				t.Fatalf("sent %d execution reports, want 2", len(reports))
+			}
+
+			for _, sent := range reports {
+				if report := sent.(models.ExecutionReportModel); report.Liquidity != models.LiquidityAuction {
+					t.Errorf("report of %v liquidity = %v, want %v", report.OrderId, report.Liquidity, models.LiquidityAuction)
+				}
+			}
+		})
+	}
+}
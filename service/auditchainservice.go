@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	auditChainReadBatch              = 1000
+	auditChainSealBatch              = 1000
+	auditCheckpointSchedulerInterval = time.Minute
+	auditCheckpointDateLayout        = "2006-01-02"
+	auditChainVerifyRoutingKey       = "verify."
+)
+
+type iAuditChainStorage interface {
+	GetChainPairs(ctx context.Context) ([]string, error)
+	GetChainHead(ctx context.Context, currencyPair string) (string, error)
+	SealChainRecords(ctx context.Context, currencyPair string, count int64, hash func(prevHash string, data []byte) string) (int, error)
+	ReadChainRecords(ctx context.Context, currencyPair string, afterId string, count int64) ([]models.AuditChainRecord, error)
+	GetLastCheckpoint(ctx context.Context) (*models.AuditCheckpoint, error)
+	SaveLastCheckpoint(ctx context.Context, checkpoint models.AuditCheckpoint) error
+	ClaimCheckpoint(ctx context.Context, date string) (bool, error)
+	ReleaseCheckpoint(ctx context.Context, date string) error
+}
+
+type iCheckpointStorage interface {
+	SaveCheckpoint(checkpoint models.AuditCheckpoint) (string, error)
+}
+
+type AuditChainService struct {
+	chainStorage      iAuditChainStorage
+	checkpointStorage iCheckpointStorage
+	messageSender     iMessageSender
+	signingKey        []byte
+}
+
+func NewAuditChainService(chainStorage iAuditChainStorage, checkpointStorage iCheckpointStorage, messageSender iMessageSender, signingKey []byte) (*AuditChainService, error) {
+	if len(signingKey) == 0 {
+		return nil, staticerr.ErrorAuditSigningKeyIsEmpty
+	}
+
+	return &AuditChainService{chainStorage: chainStorage, checkpointStorage: checkpointStorage, messageSender: messageSender, signingKey: signingKey}, nil
+}
+
+func (a *AuditChainService) SendChainVerification(ctx context.Context, request *models.VerifyAuditChainRequest) {
+	logrus.WithFields(logrus.Fields{
+		"currencyPair": request.CurrencyPair,
+		"full":         request.Full,
+		"operator":     request.Operator}).Infoln("Received verify audit chain request")
+
+	result, err := a.verifyChainRequest(ctx, request.CurrencyPair, request.Full)
+
+	if err != nil {
+		logrus.WithField("currencyPair", request.CurrencyPair).Errorln("Fail verify audit chain, reason: ", err.Error())
+		result = &models.AuditChainVerification{CurrencyPair: request.CurrencyPair, Error: err.Error()}
+	}
+
+	if err = a.messageSender.SendJsonMessage(ctx, result, auditExchange, auditChainVerifyRoutingKey+request.CurrencyPair); err != nil {
+		logrus.WithField("currencyPair", request.CurrencyPair).Errorln("Fail send audit chain verification, reason: ", err.Error())
+	}
+}
+
+func (a *AuditChainService) verifyChainRequest(ctx context.Context, currencyPair string, full bool) (*models.AuditChainVerification, error) {
+	if full {
+		return a.VerifyChain(ctx, currencyPair, models.AuditCheckpointPair{})
+	}
+
+	checkpointPairs, err := a.getCheckpointPairs(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.VerifyChain(ctx, currencyPair, checkpointPairs[currencyPair])
+}
+
+// VerifyChain recomputes the hashes of the pair chain after the checkpoint and compares the result with the stored head,
+// an empty checkpoint verifies the chain from the first record.
+func (a *AuditChainService) VerifyChain(ctx context.Context, currencyPair string, from models.AuditCheckpointPair) (*models.AuditChainVerification, error) {
+	result := &models.AuditChainVerification{
+		CurrencyPair: currencyPair,
+		Records:      from.Records,
+		LastRecordId: from.LastRecordId,
+		HeadHash:     from.Hash,
+		Valid:        true,
+	}
+	lastId := "0"
+
+	if from.LastRecordId != "" {
+		lastId = from.LastRecordId
+	}
+
+	for {
+		records, err := a.chainStorage.ReadChainRecords(ctx, currencyPair, lastId, auditChainReadBatch)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(records) == 0 {
+			break
+		}
+
+		hash, brokenAt := verifyChainRecords(a.signingKey, result.HeadHash, records)
+		result.HeadHash = hash
+
+		if brokenAt != "" {
+			result.Valid = false
+			result.BrokenAt = brokenAt
+			return result, nil
+		}
+
+		result.Records += int64(len(records))
+		lastId = records[len(records)-1].Id
+		result.LastRecordId = lastId
+	}
+
+	head, err := a.chainStorage.GetChainHead(ctx, currencyPair)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if head != result.HeadHash {
+		result.Valid = false
+		result.BrokenAt = "head"
+	}
+
+	return result, nil
+}
+
+// RunChainSealer links the queued order events of every pair to their chains.
+func (a *AuditChainService) RunChainSealer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.sealChains(ctx); err != nil {
+				logrus.Errorln("Fail seal audit chains, reason: ", err.Error())
+			}
+		}
+	}
+}
+
+func (a *AuditChainService) sealChains(ctx context.Context) error {
+	pairs, err := a.chainStorage.GetChainPairs(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, currencyPair := range pairs {
+		if err = a.sealChain(ctx, currencyPair); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *AuditChainService) sealChain(ctx context.Context, currencyPair string) error {
+	for {
+		sealed, err := a.chainStorage.SealChainRecords(ctx, currencyPair, auditChainSealBatch, a.hashChainRecord)
+
+		if err != nil || sealed < auditChainSealBatch {
+			return err
+		}
+	}
+}
+
+func (a *AuditChainService) hashChainRecord(prevHash string, data []byte) string {
+	return hashChainRecord(a.signingKey, prevHash, data)
+}
+
+func (a *AuditChainService) ExportCheckpoint(ctx context.Context, request *models.ExportAuditCheckpointRequest) {
+	logrus.WithField("operator", request.Operator).Infoln("Received export audit checkpoint request")
+
+	now := time.Now().UTC()
+
+	if _, err := a.exportCheckpoint(ctx, now.Format(auditCheckpointDateLayout), now); err != nil {
+		logrus.WithField("id", request.Id).Errorln("Fail export audit checkpoint, reason: ", err.Error())
+	}
+}
+
+// RunCheckpointScheduler exports a signed checkpoint once every UTC day is over, a restarted replica
+// catches up on the last day and only the replica that claims the day exports it.
+func (a *AuditChainService) RunCheckpointScheduler(ctx context.Context) {
+	ticker := time.NewTicker(auditCheckpointSchedulerInterval)
+	defer ticker.Stop()
+
+	lastDate := ""
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			date := getClosedCheckpointDate(now)
+
+			if date != lastDate && a.exportDailyCheckpoint(ctx, date, now.UTC()) {
+				lastDate = date
+			}
+		}
+	}
+}
+
+// exportDailyCheckpoint returns false when the day has to be retried.
+func (a *AuditChainService) exportDailyCheckpoint(ctx context.Context, date string, now time.Time) bool {
+	claimed, err := a.chainStorage.ClaimCheckpoint(ctx, date)
+
+	if err != nil {
+		logrus.WithField("date", date).Errorln("Fail claim audit checkpoint, reason: ", err.Error())
+		return false
+	}
+
+	if !claimed {
+		return true
+	}
+
+	if _, err = a.exportCheckpoint(ctx, date, now); err != nil {
+		logrus.WithField("date", date).Errorln("Fail export audit checkpoint, reason: ", err.Error())
+
+		if err = a.chainStorage.ReleaseCheckpoint(ctx, date); err != nil {
+			logrus.WithField("date", date).Errorln("Fail release audit checkpoint, reason: ", err.Error())
+		}
+
+		return false
+	}
+
+	return true
+}
+
+func (a *AuditChainService) exportCheckpoint(ctx context.Context, date string, now time.Time) (string, error) {
+	if err := a.sealChains(ctx); err != nil {
+		return "", err
+	}
+
+	pairs, err := a.chainStorage.GetChainPairs(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	checkpointPairs, err := a.getCheckpointPairs(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	checkpoint := models.AuditCheckpoint{
+		Date:        date,
+		CreatedDate: now.UnixMilli(),
+		Pairs:       make([]models.AuditCheckpointPair, 0, len(pairs)),
+	}
+
+	for _, currencyPair := range pairs {
+		result, err := a.VerifyChain(ctx, currencyPair, checkpointPairs[currencyPair])
+
+		if err != nil {
+			return "", err
+		}
+
+		if !result.Valid {
+			logrus.WithFields(logrus.Fields{
+				"currencyPair": currencyPair,
+				"brokenAt":     result.BrokenAt}).Errorln("Audit chain is broken, checkpoint is not exported")
+			return "", staticerr.ErrorAuditChainIsBroken
+		}
+
+		checkpoint.Pairs = append(checkpoint.Pairs, models.AuditCheckpointPair{
+			CurrencyPair: currencyPair,
+			Records:      result.Records,
+			LastRecordId: result.LastRecordId,
+			Hash:         result.HeadHash,
+		})
+	}
+
+	if checkpoint.Signature, err = signCheckpoint(checkpoint, a.signingKey); err != nil {
+		return "", err
+	}
+
+	path, err := a.checkpointStorage.SaveCheckpoint(checkpoint)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err = a.chainStorage.SaveLastCheckpoint(ctx, checkpoint); err != nil {
+		return "", err
+	}
+
+	logrus.WithField("path", path).Infoln("Audit checkpoint is exported")
+
+	return path, nil
+}
+
+// getCheckpointPairs returns the pairs of the last checkpoint once its signature is checked.
+func (a *AuditChainService) getCheckpointPairs(ctx context.Context) (map[string]models.AuditCheckpointPair, error) {
+	checkpoint, err := a.chainStorage.GetLastCheckpoint(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make(map[string]models.AuditCheckpointPair)
+
+	if checkpoint == nil {
+		return pairs, nil
+	}
+
+	signature, err := signCheckpoint(*checkpoint, a.signingKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(checkpoint.Signature)) {
+		return nil, staticerr.ErrorAuditCheckpointIsInvalid
+	}
+
+	for _, pair := range checkpoint.Pairs {
+		pairs[pair.CurrencyPair] = pair
+	}
+
+	return pairs, nil
+}
+
+// getClosedCheckpointDate returns the last UTC day that is over.
+func getClosedCheckpointDate(now time.Time) string {
+	return now.UTC().AddDate(0, 0, -1).Format(auditCheckpointDateLayout)
+}
+
+// verifyChainRecords returns the last computed hash and the id of the first record that does not match it.
+func verifyChainRecords(key []byte, prevHash string, records []models.AuditChainRecord) (string, string) {
+	for _, record := range records {
+		if record.PrevHash != prevHash || !hmac.Equal([]byte(record.Hash), []byte(hashChainRecord(key, prevHash, record.Data))) {
+			return prevHash, record.Id
+		}
+
+		prevHash = record.Hash
+	}
+
+	return prevHash, ""
+}
+
+// hashChainRecord is HMAC-SHA256 of the previous hash and the record data, so a chain can not be rewritten without the key.
+func hashChainRecord(key []byte, prevHash string, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(prevHash))
+	mac.Write(data)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signCheckpoint signs the checkpoint without its signature with HMAC-SHA256.
+func signCheckpoint(checkpoint models.AuditCheckpoint, key []byte) (string, error) {
+	checkpoint.Signature = ""
+	data, err := json.Marshal(checkpoint)
+
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
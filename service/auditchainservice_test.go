@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/storage"
+)
+
+var testAuditKey = []byte("key")
+
+func buildChain(data ...string) []models.AuditChainRecord {
+	records := make([]models.AuditChainRecord, 0, len(data))
+	prevHash := ""
+
+	for i, value := range data {
+		hash := hashChainRecord(testAuditKey, prevHash, []byte(value))
+		records = append(records, models.AuditChainRecord{Id: string(rune('a' + i)), Data: []byte(value), PrevHash: prevHash, Hash: hash})
+		prevHash = hash
+	}
+
+	return records
+}
+
+func Test_verifyChainRecords(t *testing.T) {
+	tampered := buildChain("first", "second", "third")
+	tampered[1].Data = []byte("changed")
+
+	relinked := buildChain("first", "second", "third")
+	relinked[2].PrevHash = relinked[0].Hash
+
+	tests := []struct {
+		name         string
+		records      []models.AuditChainRecord
+		wantBrokenAt string
+	}{
+		{name: "empty chain", records: nil, wantBrokenAt: ""},
+		{name: "valid chain", records: buildChain("first", "second", "third"), wantBrokenAt: ""},
+		{name: "changed data", records: tampered, wantBrokenAt: "b"},
+		{name: "removed record", records: append(buildChain("first", "second", "third")[:1], buildChain("first", "second", "third")[2]), wantBrokenAt: "c"},
+		{name: "relinked record", records: relinked, wantBrokenAt: "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, brokenAt := verifyChainRecords(testAuditKey, "", tt.records)
+
+			if brokenAt != tt.wantBrokenAt {
+				t.Errorf("verifyChainRecords() brokenAt = %v, want %v", brokenAt, tt.wantBrokenAt)
+			}
+		})
+	}
+}
+
+func Test_signCheckpoint(t *testing.T) {
+	checkpoint := models.AuditCheckpoint{
+		Date:  "2024-01-01",
+		Pairs: []models.AuditCheckpointPair{{CurrencyPair: "BTC/USDT", Records: 3, Hash: "abc"}},
+	}
+
+	signature, err := signCheckpoint(checkpoint, []byte("key"))
+
+	if err != nil {
+		t.Fatalf("signCheckpoint() error = %v", err)
+	}
+
+	checkpoint.Signature = signature
+	resigned, _ := signCheckpoint(checkpoint, []byte("key"))
+
+	if resigned != signature {
+		t.Errorf("signCheckpoint() must ignore the existing signature")
+	}
+
+	checkpoint.Pairs[0].Hash = "abd"
+	changed, _ := signCheckpoint(checkpoint, []byte("key"))
+
+	if changed == signature {
+		t.Errorf("signCheckpoint() must change with the checkpoint")
+	}
+
+	otherKey, _ := signCheckpoint(checkpoint, []byte("other"))
+
+	if otherKey == changed {
+		t.Errorf("signCheckpoint() must depend on the key")
+	}
+}
+
+func TestNewAuditChainService(t *testing.T) {
+	if _, err := NewAuditChainService(nil, nil, &messageSenderStub{}, nil); !errors.Is(err, staticerr.ErrorAuditSigningKeyIsEmpty) {
+		t.Errorf("NewAuditChainService() error = %v, want %v", err, staticerr.ErrorAuditSigningKeyIsEmpty)
+	}
+}
+
+func Test_getClosedCheckpointDate(t *testing.T) {
+	tests := []struct {
+		name string
+		now  time.Time
+		want string
+	}{
+		{name: "right after midnight", now: time.Date(2024, 3, 1, 0, 0, 30, 0, time.UTC), want: "2024-02-29"},
+		{name: "end of day", now: time.Date(2024, 3, 1, 23, 59, 59, 0, time.UTC), want: "2024-02-29"},
+		{name: "local time is converted to UTC", now: time.Date(2024, 3, 2, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*3600)), want: "2024-02-29"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getClosedCheckpointDate(tt.now); got != tt.want {
+				t.Errorf("getClosedCheckpointDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditChainService_exportDailyCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	client, err := storage.NewRedisClient(env.server.Addr())
+
+	if err != nil {
+		t.Fatalf("NewRedisClient() error = %v", err)
+	}
+
+	chainStorage := storage.NewAuditChainStorage(client)
+	dir := t.TempDir()
+	a, err := NewAuditChainService(chainStorage, storage.NewCheckpointStorage(dir), env.messageSender, testAuditKey)
+
+	if err != nil {
+		t.Fatalf("NewAuditChainService() error = %v", err)
+	}
+
+	appendEvents := func(ids ...string) {
+		for _, id := range ids {
+			if err := env.orderStorage.AppendOrderEvent(ctx, models.OrderLogRejected, testOrder(id, ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL, 100, 1)); err != nil {
+				t.Fatalf("AppendOrderEvent() error = %v", err)
+			}
+		}
+	}
+
+	appendEvents("o1", "o2")
+	now := time.Date(2024, 3, 1, 0, 1, 0, 0, time.UTC)
+
+	if !a.exportDailyCheckpoint(ctx, "2024-02-29", now) {
+		t.Fatalf("exportDailyCheckpoint() must export the day")
+	}
+
+	if !a.exportDailyCheckpoint(ctx, "2024-02-29", now) {
+		t.Fatalf("exportDailyCheckpoint() must skip a day claimed before")
+	}
+
+	checkpoint, err := chainStorage.GetLastCheckpoint(ctx)
+
+	if err != nil || checkpoint == nil {
+		t.Fatalf("GetLastCheckpoint() = %v, %v", checkpoint, err)
+	}
+
+	if len(checkpoint.Pairs) != 1 || checkpoint.Pairs[0].Records != 2 || checkpoint.Date != "2024-02-29" {
+		t.Fatalf("GetLastCheckpoint() = %+v, want 2 records of one pair", checkpoint)
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, "audit_checkpoint_2024-02-29.json")); err != nil {
+		t.Fatalf("checkpoint file is not exported: %v", err)
+	}
+
+	appendEvents("o3")
+
+	if err = a.sealChains(ctx); err != nil {
+		t.Fatalf("sealChains() error = %v", err)
+	}
+
+	pair := checkpoint.Pairs[0].CurrencyPair
+	result, err := a.verifyChainRequest(ctx, pair, false)
+
+	if err != nil {
+		t.Fatalf("verifyChainRequest() error = %v", err)
+	}
+
+	if !result.Valid || result.Records != 3 {
+		t.Errorf("verifyChainRequest() = %+v, want 3 valid records", result)
+	}
+
+	checkpoint.Pairs[0].Hash = "forged"
+
+	if err = chainStorage.SaveLastCheckpoint(ctx, *checkpoint); err != nil {
+		t.Fatalf("SaveLastCheckpoint() error = %v", err)
+	}
+
+	if _, err = a.verifyChainRequest(ctx, pair, false); !errors.Is(err, staticerr.ErrorAuditCheckpointIsInvalid) {
+		t.Errorf("verifyChainRequest() error = %v, want %v", err, staticerr.ErrorAuditCheckpointIsInvalid)
+	}
+
+	if result, err = a.verifyChainRequest(ctx, pair, true); err != nil || !result.Valid || result.Records != 3 {
+		t.Errorf("verifyChainRequest() full = %+v, %v, want 3 valid records", result, err)
+	}
+}
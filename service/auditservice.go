@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+	"trade-order-processing-service/models"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const auditExchange = "e.ops.audit"
+
+type AuditService struct {
+	orderStorage  iOrderStorage
+	tradeStorage  iTradeQueryStorage
+	messageSender iMessageSender
+}
+
+func NewAuditService(orderStorage iOrderStorage, tradeStorage iTradeQueryStorage, messageSender iMessageSender) *AuditService {
+	return &AuditService{orderStorage: orderStorage, tradeStorage: tradeStorage, messageSender: messageSender}
+}
+
+// GetOrderAuditTrail merges the order audit entries with its fills and the tickets of their transfers.
+func (a *AuditService) GetOrderAuditTrail(ctx context.Context, orderId string) ([]models.AuditEntryModel, error) {
+	entries, err := a.orderStorage.GetAudit(ctx, orderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	trades, err := a.tradeStorage.GetTradesByOrder(ctx, orderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tradeInfo := range trades {
+		entries = append(entries, newTradeAuditEntry(tradeInfo))
+
+		if tradeInfo.TransferId == "" {
+			continue
+		}
+
+		transferEntries, err := a.orderStorage.GetAudit(ctx, tradeInfo.TransferId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, transferEntries...)
+	}
+
+	sortAuditEntries(entries)
+
+	return entries, nil
+}
+
+func (a *AuditService) SendOrderAuditTrail(ctx context.Context, request *models.OrderAuditRequest) {
+	response := models.OrderAuditResponse{Id: request.Id, OrderId: request.OrderId, Entries: []models.AuditEntryModel{}}
+
+	entries, err := a.GetOrderAuditTrail(ctx, request.OrderId)
+
+	if err != nil {
+		logrus.WithField("orderId", request.OrderId).Errorln("Fail get audit trail, reason: ", err.Error())
+		response.Error = err.Error()
+	} else {
+		response.Entries = entries
+	}
+
+	if err = a.messageSender.SendJsonMessage(ctx, response, auditExchange, request.OrderId); err != nil {
+		logrus.WithField("orderId", request.OrderId).Errorln("Fail send audit trail, reason: ", err.Error())
+	}
+}
+
+func sortAuditEntries(entries []models.AuditEntryModel) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp < entries[j].Timestamp
+	})
+}
+
+func newTradeAuditEntry(tradeInfo models.TradeModel) models.AuditEntryModel {
+	details, _ := json.Marshal(tradeInfo)
+
+	return models.AuditEntryModel{
+		Timestamp:     tradeInfo.TradeDate,
+		Source:        models.AuditSourceTrade,
+		Type:          models.AuditTypeFill,
+		CorrelationId: tradeInfo.TradeId,
+		Details:       details,
+	}
+}
+
+func newAuditEntry(source string, entryType string, correlationId string, message proto.Message) models.AuditEntryModel {
+	details, _ := protojson.Marshal(message)
+
+	return models.AuditEntryModel{
+		Timestamp:     time.Now().UTC().UnixMilli(),
+		Source:        source,
+		Type:          entryType,
+		CorrelationId: correlationId,
+		Details:       details,
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"trade-order-processing-service/models"
+)
+
+func Test_sortAuditEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []models.AuditEntryModel
+		want    []string
+	}{
+		{
+			name: "sorted by timestamp",
+			entries: []models.AuditEntryModel{
+				{Timestamp: 30, Type: models.AuditTypeFill},
+				{Timestamp: 10, Type: models.AuditTypeLockResponse},
+				{Timestamp: 20, Type: models.AuditTypeMatchAttempt},
+			},
+			want: []string{models.AuditTypeLockResponse, models.AuditTypeMatchAttempt, models.AuditTypeFill},
+		},
+		{
+			name: "same timestamp keeps recording order",
+			entries: []models.AuditEntryModel{
+				{Timestamp: 10, Type: models.AuditTypeMatchAttempt},
+				{Timestamp: 10, Type: models.AuditTypeFill},
+				{Timestamp: 5, Type: models.AuditTypeLockResponse},
+			},
+			want: []string{models.AuditTypeLockResponse, models.AuditTypeMatchAttempt, models.AuditTypeFill},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortAuditEntries(tt.entries)
+
+			for i, entry := range tt.entries {
+				if entry.Type != tt.want[i] {
+					t.Errorf("sortAuditEntries()[%d] = %v, want %v", i, entry.Type, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+type auditOrderStorageStub struct {
+	iOrderStorage
+	audits map[string][]models.AuditEntryModel
+}
+
+func (s *auditOrderStorageStub) GetAudit(ctx context.Context, correlationId string) ([]models.AuditEntryModel, error) {
+	return s.audits[correlationId], nil
+}
+
+type auditTradeStorageStub struct {
+	iTradeQueryStorage
+	trades map[string][]models.TradeModel
+	err    error
+}
+
+func (s *auditTradeStorageStub) GetTradesByOrder(ctx context.Context, orderId string) ([]models.TradeModel, error) {
+	return s.trades[orderId], s.err
+}
+
+func TestAuditService_GetOrderAuditTrail(t *testing.T) {
+	orderStorage := &auditOrderStorageStub{audits: map[string][]models.AuditEntryModel{
+		"order":      {{Timestamp: 10, Type: models.AuditTypeLockResponse}, {Timestamp: 40, Type: models.AuditTypeExecutionReport}},
+		"transfer-1": {{Timestamp: 30, Type: "CREATE_TRANSFER"}},
+	}}
+	tests := []struct {
+		name    string
+		trades  map[string][]models.TradeModel
+		err     error
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "fills and their transfer tickets are merged by time",
+			trades: map[string][]models.TradeModel{"order": {
+				{TradeId: "trade-1", TradeDate: 20, TransferId: "transfer-1"},
+				{TradeId: "trade-2", TradeDate: 35},
+			}},
+			want: []string{models.AuditTypeLockResponse, models.AuditTypeFill, "CREATE_TRANSFER", models.AuditTypeFill, models.AuditTypeExecutionReport},
+		},
+		{
+			name: "order without fills has only its own entries",
+			want: []string{models.AuditTypeLockResponse, models.AuditTypeExecutionReport},
+		},
+		{
+			name:    "trade storage error is returned",
+			err:     errors.New("trades are unavailable"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAuditService(orderStorage, &auditTradeStorageStub{trades: tt.trades, err: tt.err}, &messageSenderStub{})
+
+			got, err := a.GetOrderAuditTrail(context.Background(), "order")
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetOrderAuditTrail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			types := make([]string, 0, len(got))
+
+			for _, entry := range got {
+				types = append(types, entry.Type)
+			}
+
+			if !tt.wantErr && !slices.Equal(types, tt.want) {
+				t.Errorf("GetOrderAuditTrail() = %v, want %v", types, tt.want)
+			}
+		})
+	}
+}
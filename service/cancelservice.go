@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"trade-order-processing-service/external/bps"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/utils"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	deactivateOrderExchange = "e.ops.deactivate_order"
+	massCancelExchange      = "e.ops.mass_cancel"
+	massCancelBatchSize     = 100
+)
+
+type CancelService struct {
+	orderStorage      iOrderStorage
+	ticketStorage     iTicketStorage
+	messageSender     iMessageSender
+	marketDataService *MarketDataService
+}
+
+func NewCancelService(orderStorage iOrderStorage, ticketStorage iTicketStorage, messageSender iMessageSender, marketDataService *MarketDataService) *CancelService {
+	return &CancelService{orderStorage: orderStorage, ticketStorage: ticketStorage, messageSender: messageSender, marketDataService: marketDataService}
+}
+
+func (c *CancelService) CancelOrder(ctx context.Context, request *ops.DeactivateOrderRequest) {
+	logrus.WithFields(logrus.Fields{
+		"orderId":   request.OrderId,
+		"accountId": request.AccountId}).Infoln("Received cancel order request")
+
+	response := &ops.DeactivateOrderResponse{Id: request.Id}
+
+	if err := c.cancelOrder(ctx, request.AccountId, request.OrderId); err != nil {
+		logrus.WithField("orderId", request.OrderId).Errorln("Fail cancel order, reason: ", err.Error())
+		response.Error = utils.MapErrorToOpsError(err)
+	}
+
+	if err := c.messageSender.SendMessage(ctx, response, deactivateOrderExchange, request.AccountId); err != nil {
+		logrus.WithField("orderId", request.OrderId).Errorln("Fail send cancel order response, reason: ", err.Error())
+	}
+}
+
+func (c *CancelService) cancelOrder(ctx context.Context, accountId string, orderId string) error {
+	orderInfo, err := c.orderStorage.GetOrderFromStorage(ctx, orderId)
+
+	if err != nil {
+		return err
+	}
+
+	if orderInfo.AccountId != accountId {
+		return staticerr.ErrorOrderNotBelongsToAccount
+	}
+
+	lockId := uuid.NewString()
+
+	if err = c.orderStorage.TryLockOrder(ctx, orderId, lockId); err != nil {
+		return err
+	}
+
+	defer c.unlockOrder(ctx, orderId, lockId)
+
+	cancelled, err := c.cancelLockedOrder(ctx, orderId)
+
+	if err != nil {
+		return err
+	}
+
+	if cancelled == nil {
+		return staticerr.ErrorOrderIsNotCancellable
+	}
+
+	return c.refundOrders(ctx, *cancelled)
+}
+
+// MassCancel cancels resting orders of the account, the pair, both, or the whole exchange in batches.
+func (c *CancelService) MassCancel(ctx context.Context, request *models.MassCancelRequest) {
+	logrus.WithFields(logrus.Fields{
+		"accountId":    request.AccountId,
+		"currencyPair": request.CurrencyPair,
+		"operator":     request.Operator}).Warningln("Received mass cancel request")
+
+	response, err := c.massCancel(ctx, request.AccountId, request.CurrencyPair, request.ExchangeWide)
+
+	if err != nil {
+		logrus.WithField("id", request.Id).Errorln("Fail mass cancel, reason: ", err.Error())
+		response.Error = err.Error()
+	}
+
+	response.Id = request.Id
+
+	logrus.WithFields(logrus.Fields{
+		"id":     request.Id,
+		"failed": response.FailedCount}).Infoln("Mass cancel is done, cancelled orders: ", response.CancelledCount)
+
+	if err = c.messageSender.SendJsonMessage(ctx, response, massCancelExchange, request.AccountId); err != nil {
+		logrus.WithField("id", request.Id).Errorln("Fail send mass cancel response, reason: ", err.Error())
+	}
+}
+
+func (c *CancelService) massCancel(ctx context.Context, accountId string, currencyPair string, exchangeWide bool) (*models.MassCancelResponse, error) {
+	response := &models.MassCancelResponse{}
+
+	if accountId == "" && currencyPair == "" && !exchangeWide {
+		return response, staticerr.ErrorMassCancelScopeIsEmpty
+	}
+
+	ids, err := c.orderStorage.GetBookOrderIds(ctx, accountId, currencyPair)
+
+	if err != nil {
+		return response, err
+	}
+
+	for _, batch := range splitInBatches(ids, massCancelBatchSize) {
+		cancelled, failed := c.cancelBatch(ctx, batch)
+
+		response.CancelledCount += cancelled
+		response.FailedCount += failed
+	}
+
+	return response, nil
+}
+
+func (c *CancelService) cancelBatch(ctx context.Context, ids []string) (int, int) {
+	lockId := uuid.NewString()
+	cancelledOrders := make([]models.OrderModel, 0, len(ids))
+	failed := 0
+
+	for _, id := range ids {
+		if err := c.orderStorage.TryLockOrder(ctx, id, lockId); err != nil {
+			logrus.WithField("orderId", id).Warningln("Skip cancel order, reason: ", err.Error())
+			failed++
+			continue
+		}
+
+		cancelled, err := c.cancelLockedOrder(ctx, id)
+
+		c.unlockOrder(ctx, id, lockId)
+
+		if err != nil {
+			logrus.WithField("orderId", id).Errorln("Fail cancel order, reason: ", err.Error())
+			failed++
+			continue
+		}
+
+		if cancelled != nil {
+			cancelledOrders = append(cancelledOrders, *cancelled)
+		}
+	}
+
+	if err := c.refundOrders(ctx, cancelledOrders...); err != nil {
+		logrus.Errorln("Fail refund cancelled orders, reason: ", err.Error())
+	}
+
+	return len(cancelledOrders), failed
+}
+
+// cancelLockedOrder drops the order from the book and closes it, nil means the order is no longer resting.
+func (c *CancelService) cancelLockedOrder(ctx context.Context, id string) (*models.OrderModel, error) {
+	orderInfo, err := c.orderStorage.GetOrderFromStorage(ctx, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	booked, err := c.orderStorage.IsInStockBook(ctx, *orderInfo)
+
+	if err != nil || !booked {
+		return nil, err
+	}
+
+	bookedOrder := *orderInfo
+	orderInfo.State = int(ops.OpsOrderState_OPS_ORDER_STATE_DONE)
+
+	if err = c.orderStorage.CloseBookedOrder(ctx, models.OrderLogCancelled, *orderInfo); err != nil {
+		return nil, err
+	}
+
+	c.marketDataService.PublishBookChange(ctx, models.OrderEventDelete, bookedOrder)
+
+	if err = c.ticketStorage.AddNewTicket(ctx, ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION, utils.MapOrderInfoToProto(*orderInfo)); err != nil {
+		logrus.WithField("orderId", id).Errorln("Fail send cancel notification, reason: ", err.Error())
+	}
+
+	return orderInfo, nil
+}
+
+func (c *CancelService) refundOrders(ctx context.Context, orders ...models.OrderModel) error {
+	for _, refund := range aggregateRefunds(orders) {
+		logrus.WithField("balanceId", refund.request.BalanceId).Infoln("Refund cancelled orders amount: ", refund.request.Amount)
+
+		if err := c.ticketStorage.AddNewTicket(ctx, ops.OpsTicketOperation_OPS_TICKET_OPERATION_REFUND_BALANCE, refund.request); err != nil {
+			return err
+		}
+
+		// the ticket is audited under the refund id, the other orders of the balance get their own entry
+		for _, orderId := range refund.orderIds[1:] {
+			entry := newAuditEntry(models.AuditSourceTicket, ops.OpsTicketOperation_OPS_TICKET_OPERATION_REFUND_BALANCE.String(), refund.request.Id, refund.request)
+
+			if err := c.orderStorage.AppendOrderAudit(ctx, orderId, entry); err != nil {
+				logrus.WithField("orderId", orderId).Errorln("Fail audit refund, reason: ", err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *CancelService) unlockOrder(ctx context.Context, id string, lockId string) {
+	if err := c.orderStorage.TryUnlockOrder(ctx, id, lockId); err != nil {
+		logrus.WithField("orderId", id).Warningln("Failed unlock order: ", err.Error())
+	}
+}
+
+type balanceRefund struct {
+	request  *bps.BpsRefundBalanceRequest
+	orderIds []string
+}
+
+// aggregateRefunds sums unused locked amounts per balance, the refund id is the first order of the balance.
+func aggregateRefunds(orders []models.OrderModel) []*balanceRefund {
+	refunds := make([]*balanceRefund, 0)
+	byBalance := make(map[string]*balanceRefund)
+
+	for _, orderInfo := range orders {
+		amount := orderInfo.LockedAmount - orderInfo.SpentAmount
+
+		if amount < utils.VolumeEpsilon {
+			continue
+		}
+
+		if refund, ok := byBalance[orderInfo.ExchangeId]; ok {
+			refund.request.Amount += amount
+			refund.orderIds = append(refund.orderIds, orderInfo.OrderId)
+			continue
+		}
+
+		refund := &balanceRefund{
+			request:  &bps.BpsRefundBalanceRequest{Id: orderInfo.OrderId, BalanceId: orderInfo.ExchangeId, Amount: amount},
+			orderIds: []string{orderInfo.OrderId},
+		}
+		byBalance[orderInfo.ExchangeId] = refund
+		refunds = append(refunds, refund)
+	}
+
+	return refunds
+}
+
+func splitInBatches(ids []string, size int) [][]string {
+	batches := make([][]string, 0, len(ids)/size+1)
+
+	for start := 0; start < len(ids); start += size {
+		end := start + size
+
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		batches = append(batches, ids[start:end])
+	}
+
+	return batches
+}
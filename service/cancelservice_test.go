@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/utils"
+)
+
+func Test_aggregateRefunds(t *testing.T) {
+	tests := []struct {
+		name    string
+		orders  []models.OrderModel
+		wantLen int
+		wantSum map[string]float64
+		wantIds map[string][]string
+	}{
+		{
+			name: "same balance is summed",
+			orders: []models.OrderModel{
+				{OrderId: "1", ExchangeId: "b1", LockedAmount: 100, SpentAmount: 40},
+				{OrderId: "2", ExchangeId: "b1", LockedAmount: 50},
+				{OrderId: "3", ExchangeId: "b2", LockedAmount: 10, SpentAmount: 5},
+			},
+			wantLen: 2,
+			wantSum: map[string]float64{"b1": 110, "b2": 5},
+			wantIds: map[string][]string{"b1": {"1", "2"}, "b2": {"3"}},
+		},
+		{
+			name: "fully spent lock is skipped",
+			orders: []models.OrderModel{
+				{OrderId: "1", ExchangeId: "b1", LockedAmount: 100, SpentAmount: 100},
+			},
+			wantLen: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := aggregateRefunds(tt.orders)
+
+			if len(got) != tt.wantLen {
+				t.Fatalf("aggregateRefunds() len = %v, want %v", len(got), tt.wantLen)
+			}
+
+			for _, refund := range got {
+				balanceId := refund.request.BalanceId
+
+				if math.Abs(refund.request.Amount-tt.wantSum[balanceId]) > utils.VolumeEpsilon {
+					t.Errorf("aggregateRefunds() %s amount = %v, want %v", balanceId, refund.request.Amount, tt.wantSum[balanceId])
+				}
+
+				if !slices.Equal(refund.orderIds, tt.wantIds[balanceId]) {
+					t.Errorf("aggregateRefunds() %s orders = %v, want %v", balanceId, refund.orderIds, tt.wantIds[balanceId])
+				}
+			}
+		})
+	}
+}
+
+func Test_splitInBatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []string
+		size    int
+		wantLen []int
+	}{
+		{name: "empty", ids: nil, size: 2, wantLen: []int{}},
+		{name: "exact", ids: []string{"1", "2", "3", "4"}, size: 2, wantLen: []int{2, 2}},
+		{name: "remainder", ids: []string{"1", "2", "3"}, size: 2, wantLen: []int{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitInBatches(tt.ids, tt.size)
+
+			if len(got) != len(tt.wantLen) {
+				t.Fatalf("splitInBatches() len = %v, want %v", len(got), len(tt.wantLen))
+			}
+
+			for i, batch := range got {
+				if len(batch) != tt.wantLen[i] {
+					t.Errorf("splitInBatches() batch %d len = %v, want %v", i, len(batch), tt.wantLen[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCancelService_CancelOrder(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	tests := []struct {
+		name      string
+		booked    bool
+		accountId string
+		wantError ops.OpsErrorCode
+		wantState ops.OpsOrderState
+	}{
+		{name: "owner cancels a resting order", booked: true, accountId: "account-order", wantState: ops.OpsOrderState_OPS_ORDER_STATE_DONE},
+		{name: "other account is refused", booked: true, accountId: "account-other", wantError: staticerr.OpsErrorCodeOrderNotBelongsToAccount, wantState: ops.OpsOrderState_OPS_ORDER_STATE_IN_PROCESS},
+		{name: "order out of the book is not cancellable", accountId: "account-order", wantError: staticerr.OpsErrorCodeOrderIsNotCancellable, wantState: ops.OpsOrderState_OPS_ORDER_STATE_IN_PROCESS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			orderInfo := testOrder("order", sell, 100, 1)
+
+			if tt.booked {
+				env.bookOrders(t, orderInfo)
+			} else if err := env.orderStorage.AddOrderToStorage(ctx, orderInfo); err != nil {
+				t.Fatalf("AddOrderToStorage() error = %v", err)
+			}
+
+			env.cancelService.CancelOrder(ctx, &ops.DeactivateOrderRequest{Id: "request", AccountId: tt.accountId, OrderId: orderInfo.OrderId})
+
+			responses := env.messageSender.sentTo(deactivateOrderExchange)
+
+			if len(responses) != 1 {
+				t.Fatalf("responses = %v, want 1", len(responses))
+			}
+
+			response := responses[0].(*ops.DeactivateOrderResponse)
+
+			if response.Error.GetErrorCode() != tt.wantError {
+				t.Errorf("response error = %v, want %v", response.Error, tt.wantError)
+			}
+
+			if got := env.getOrder(t, orderInfo.OrderId); got.State != int(tt.wantState) {
+				t.Errorf("order state = %v, want %v", got.State, tt.wantState)
+			}
+
+			for _, notification := range env.drainTickets(t, ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION) {
+				if notification.Cause != nil {
+					t.Errorf("cancel notification cause = %v, want none", notification.Cause)
+				}
+			}
+		})
+	}
+}
+
+func TestCancelService_massCancel(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	inPair := func(orderInfo models.OrderModel, accountId, currencyPair string) models.OrderModel {
+		orderInfo.AccountId = accountId
+		orderInfo.CurrencyPair = currencyPair
+		return orderInfo
+	}
+	book := []models.OrderModel{
+		inPair(testOrder("alice-btc", sell, 100, 1), "alice", "BTC/USDT"),
+		inPair(testOrder("alice-eth", sell, 10, 1), "alice", "ETH/USDT"),
+		inPair(testOrder("bob-btc", sell, 100, 1), "bob", "BTC/USDT"),
+	}
+	tests := []struct {
+		name          string
+		accountId     string
+		currencyPair  string
+		exchangeWide  bool
+		wantErr       bool
+		wantCancelled []string
+	}{
+		{name: "by account", accountId: "alice", wantCancelled: []string{"alice-btc", "alice-eth"}},
+		{name: "by account and pair", accountId: "alice", currencyPair: "ETH/USDT", wantCancelled: []string{"alice-eth"}},
+		{name: "by pair", currencyPair: "BTC/USDT", wantCancelled: []string{"alice-btc", "bob-btc"}},
+		{name: "exchange wide", exchangeWide: true, wantCancelled: []string{"alice-btc", "alice-eth", "bob-btc"}},
+		{name: "empty scope is refused", wantErr: true, wantCancelled: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			env.bookOrders(t, book...)
+
+			response, err := env.cancelService.massCancel(ctx, tt.accountId, tt.currencyPair, tt.exchangeWide)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("massCancel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if response.CancelledCount != len(tt.wantCancelled) || response.FailedCount != 0 {
+				t.Errorf("massCancel() = %+v, want %v cancelled", *response, len(tt.wantCancelled))
+			}
+
+			for _, orderInfo := range book {
+				wantState := ops.OpsOrderState_OPS_ORDER_STATE_IN_PROCESS
+
+				if slices.Contains(tt.wantCancelled, orderInfo.OrderId) {
+					wantState = ops.OpsOrderState_OPS_ORDER_STATE_DONE
+				}
+
+				if got := env.getOrder(t, orderInfo.OrderId); got.State != int(wantState) {
+					t.Errorf("%s state = %v, want %v", orderInfo.OrderId, got.State, wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestCancelService_refundOrders(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	withLock := func(orderInfo models.OrderModel, balanceId string, locked float64) models.OrderModel {
+		orderInfo.ExchangeId = balanceId
+		orderInfo.LockedAmount = locked
+		return orderInfo
+	}
+	orders := []models.OrderModel{
+		withLock(testOrder("first", sell, 100, 1), "b1", 1),
+		withLock(testOrder("second", sell, 100, 1), "b1", 2),
+		withLock(testOrder("spent", sell, 100, 1), "b1", 0),
+	}
+	ctx := context.Background()
+	env := newTestEnv(t)
+
+	if err := env.cancelService.refundOrders(ctx, orders...); err != nil {
+		t.Fatalf("refundOrders() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		orderId    string
+		wantRefund bool
+	}{
+		{name: "refund id order is audited by the ticket", orderId: "first", wantRefund: true},
+		{name: "other order of the balance is audited", orderId: "second", wantRefund: true},
+		{name: "order without refund is not audited", orderId: "spent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := env.orderStorage.GetAudit(ctx, tt.orderId)
+
+			if err != nil {
+				t.Fatalf("GetAudit() error = %v", err)
+			}
+
+			refunded := slices.ContainsFunc(entries, func(entry models.AuditEntryModel) bool {
+				return entry.Type == ops.OpsTicketOperation_OPS_TICKET_OPERATION_REFUND_BALANCE.String()
+			})
+
+			if refunded != tt.wantRefund {
+				t.Errorf("refund audited = %v, want %v", refunded, tt.wantRefund)
+			}
+		})
+	}
+}
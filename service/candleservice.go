@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"time"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	candlesExchange         = "e.ops.market_data.candles"
+	candlesClosedRoutingKey = "closed."
+	candlesQueryRoutingKey  = "query."
+	candleSchedulerInterval = time.Second
+	// candleCloseGrace delays publishing a closed candle so trades registered just after the interval end are included.
+	candleCloseGrace = 5 * time.Second
+)
+
+var candleIntervals = map[string]time.Duration{
+	models.CandleInterval1m: time.Minute,
+	models.CandleInterval5m: 5 * time.Minute,
+	models.CandleInterval1h: time.Hour,
+	models.CandleInterval1d: 24 * time.Hour,
+}
+
+// candleRetention keeps the 5m candles longer than the ticker window they are read for.
+var candleRetention = map[string]time.Duration{
+	models.CandleInterval1m: 7 * 24 * time.Hour,
+	models.CandleInterval5m: 30 * 24 * time.Hour,
+	models.CandleInterval1h: 365 * 24 * time.Hour,
+	models.CandleInterval1d: 5 * 365 * 24 * time.Hour,
+}
+
+type iCandleStorage interface {
+	AddTradeToCandle(ctx context.Context, tradeInfo models.TradeModel, interval string, openTime int64, retention time.Duration) error
+	GetCandles(ctx context.Context, currencyPair string, interval string, from int64, to int64) ([]models.CandleModel, error)
+	GetCandle(ctx context.Context, currencyPair string, interval string, openTime int64) (*models.CandleModel, error)
+	ClaimClosedCandle(ctx context.Context, currencyPair string, interval string, openTime int64) (bool, error)
+}
+
+type CandleService struct {
+	candleStorage iCandleStorage
+	messageSender iMessageSender
+}
+
+func NewCandleService(candleStorage iCandleStorage, messageSender iMessageSender) *CandleService {
+	return &CandleService{candleStorage: candleStorage, messageSender: messageSender}
+}
+
+func (c *CandleService) RegisterTrade(ctx context.Context, tradeInfo models.TradeModel) error {
+	for interval, duration := range candleIntervals {
+		if err := c.candleStorage.AddTradeToCandle(ctx, tradeInfo, interval, getCandleOpenTime(tradeInfo.TradeDate, duration), candleRetention[interval]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *CandleService) GetCandles(ctx context.Context, currencyPair string, interval string, from int64, to int64) ([]models.CandleModel, error) {
+	duration, ok := candleIntervals[interval]
+
+	if !ok {
+		return nil, staticerr.ErrorUnknownCandleInterval
+	}
+
+	candles, err := c.candleStorage.GetCandles(ctx, currencyPair, interval, getCandleOpenTime(from, duration), to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range candles {
+		candles[i].CloseTime = candles[i].OpenTime + duration.Milliseconds() - 1
+	}
+
+	return candles, nil
+}
+
+func (c *CandleService) SendCandles(ctx context.Context, request *models.CandlesRequest) {
+	response := models.CandlesResponse{Id: request.Id, Candles: []models.CandleModel{}}
+
+	candles, err := c.GetCandles(ctx, request.CurrencyPair, request.Interval, request.From, request.To)
+
+	if err != nil {
+		logrus.WithField("requestId", request.Id).Errorln("Fail get candles, reason: ", err.Error())
+		response.Error = err.Error()
+	} else {
+		response.Candles = candles
+	}
+
+	if err = c.messageSender.SendJsonMessage(ctx, response, candlesExchange, candlesQueryRoutingKey+request.CurrencyPair); err != nil {
+		logrus.WithField("requestId", request.Id).Errorln("Fail send candles, reason: ", err.Error())
+	}
+}
+
+// RunCandleScheduler publishes every candle of the pairs once its interval and the close grace are over.
+func (c *CandleService) RunCandleScheduler(ctx context.Context, currencyPairs []string) {
+	ticker := time.NewTicker(candleSchedulerInterval)
+	defer ticker.Stop()
+
+	lastClosed := make(map[string]int64)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for interval, duration := range candleIntervals {
+				openTime := getClosedCandleOpenTime(now.UTC().UnixMilli(), duration)
+
+				if lastClosed[interval] == openTime {
+					continue
+				}
+
+				lastClosed[interval] = openTime
+
+				for _, currencyPair := range currencyPairs {
+					c.publishClosedCandle(ctx, currencyPair, interval, openTime, duration)
+				}
+			}
+		}
+	}
+}
+
+func (c *CandleService) publishClosedCandle(ctx context.Context, currencyPair string, interval string, openTime int64, duration time.Duration) {
+	claimed, err := c.candleStorage.ClaimClosedCandle(ctx, currencyPair, interval, openTime)
+
+	if err != nil {
+		logrus.WithField("currencyPair", currencyPair).Errorln("Fail claim closed candle, reason: ", err.Error())
+		return
+	}
+
+	if !claimed {
+		return
+	}
+
+	candle, err := c.candleStorage.GetCandle(ctx, currencyPair, interval, openTime)
+
+	if err != nil {
+		logrus.WithField("currencyPair", currencyPair).Errorln("Fail get closed candle, reason: ", err.Error())
+		return
+	}
+
+	if candle == nil {
+		return
+	}
+
+	candle.CloseTime = openTime + duration.Milliseconds() - 1
+
+	if err = c.messageSender.SendJsonMessage(ctx, candle, candlesExchange, candlesClosedRoutingKey+currencyPair); err != nil {
+		logrus.WithField("currencyPair", currencyPair).Errorln("Fail send closed candle, reason: ", err.Error())
+	}
+}
+
+// getClosedCandleOpenTime returns the open time of the last candle whose interval ended at least the grace ago.
+func getClosedCandleOpenTime(timestamp int64, duration time.Duration) int64 {
+	return getCandleOpenTime(timestamp-candleCloseGrace.Milliseconds(), duration) - duration.Milliseconds()
+}
+
+func getCandleOpenTime(timestamp int64, duration time.Duration) int64 {
+	return timestamp - timestamp%duration.Milliseconds()
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_getCandleOpenTime(t *testing.T) {
+	// 2024-03-05 13:47:31.250 UTC
+	timestamp := time.Date(2024, 3, 5, 13, 47, 31, 250e6, time.UTC).UnixMilli()
+	tests := []struct {
+		name     string
+		duration time.Duration
+		want     time.Time
+	}{
+		{name: "1m", duration: time.Minute, want: time.Date(2024, 3, 5, 13, 47, 0, 0, time.UTC)},
+		{name: "5m", duration: 5 * time.Minute, want: time.Date(2024, 3, 5, 13, 45, 0, 0, time.UTC)},
+		{name: "1h", duration: time.Hour, want: time.Date(2024, 3, 5, 13, 0, 0, 0, time.UTC)},
+		{name: "1d", duration: 24 * time.Hour, want: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getCandleOpenTime(timestamp, tt.duration); got != tt.want.UnixMilli() {
+				t.Errorf("getCandleOpenTime() = %v, want %v", time.UnixMilli(got).UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_getClosedCandleOpenTime(t *testing.T) {
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{name: "inside the grace the previous candle stays open", now: time.Date(2024, 3, 5, 13, 47, 3, 0, time.UTC), want: time.Date(2024, 3, 5, 13, 45, 0, 0, time.UTC)},
+		{name: "after the grace the candle is closed", now: time.Date(2024, 3, 5, 13, 47, 5, 0, time.UTC), want: time.Date(2024, 3, 5, 13, 46, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getClosedCandleOpenTime(tt.now.UnixMilli(), time.Minute); got != tt.want.UnixMilli() {
+				t.Errorf("getClosedCandleOpenTime() = %v, want %v", time.UnixMilli(got).UTC(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"trade-order-processing-service/external/bps"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/utils"
+)
+
+type iAccountStorage interface {
+	GetAccountTier(ctx context.Context, accountId string) (string, error)
+}
+
+type FeeEngine struct {
+	accountStorage iAccountStorage
+}
+
+func NewFeeEngine(accountStorage iAccountStorage) *FeeEngine {
+	return &FeeEngine{accountStorage: accountStorage}
+}
+
+// ApplyTradeFees charges each side in the currency it receives. A side is not
+// charged when the instrument has no fee balance for that currency. An auction
+// trade has no aggressor, so both sides pay their maker rate.
+func (f *FeeEngine) ApplyTradeFees(ctx context.Context, tradeInfo *models.TradeModel, settings models.InstrumentSettings, makerOrder, takerOrder models.OrderModel) error {
+	makerTier, err := f.accountStorage.GetAccountTier(ctx, makerOrder.AccountId)
+
+	if err != nil {
+		return err
+	}
+
+	takerTier, err := f.accountStorage.GetAccountTier(ctx, takerOrder.AccountId)
+
+	if err != nil {
+		return err
+	}
+
+	makerRates := getFeeRates(settings.Fees, makerTier)
+	takerRates := getFeeRates(settings.Fees, takerTier)
+
+	tradeInfo.MakerFeeCurrency = utils.GetAskedCurrencyCode(tradeInfo.CurrencyPair, makerOrder.Direction)
+	tradeInfo.TakerFeeCurrency = utils.GetAskedCurrencyCode(tradeInfo.CurrencyPair, takerOrder.Direction)
+
+	if _, ok := settings.Fees.FeeBalanceIds[tradeInfo.MakerFeeCurrency]; ok {
+		tradeInfo.MakerFee = getReceivedAmount(*tradeInfo, makerOrder.Direction) * makerRates.MakerFeeBps / utils.BpsDenominator
+	}
+
+	takerFeeBps := takerRates.TakerFeeBps
+
+	if tradeInfo.Auction {
+		takerFeeBps = takerRates.MakerFeeBps
+	}
+
+	if _, ok := settings.Fees.FeeBalanceIds[tradeInfo.TakerFeeCurrency]; ok {
+		tradeInfo.TakerFee = getReceivedAmount(*tradeInfo, takerOrder.Direction) * takerFeeBps / utils.BpsDenominator
+	}
+
+	return nil
+}
+
+func (f *FeeEngine) BuildFeeTransferData(tradeInfo models.TradeModel, settings models.InstrumentSettings) []*bps.BpsTransferData {
+	transferData := make([]*bps.BpsTransferData, 0, 2)
+
+	if tradeInfo.MakerFee > 0 {
+		transferData = append(transferData, &bps.BpsTransferData{
+			BalanceId: settings.Fees.FeeBalanceIds[tradeInfo.MakerFeeCurrency],
+			Amount:    tradeInfo.MakerFee,
+		})
+	}
+
+	if tradeInfo.TakerFee > 0 {
+		transferData = append(transferData, &bps.BpsTransferData{
+			BalanceId: settings.Fees.FeeBalanceIds[tradeInfo.TakerFeeCurrency],
+			Amount:    tradeInfo.TakerFee,
+		})
+	}
+
+	return transferData
+}
+
+func getFeeRates(schedule models.FeeSchedule, tier string) models.FeeRates {
+	if rates, ok := schedule.TierRates[tier]; ok {
+		return rates
+	}
+
+	return schedule.FeeRates
+}
+
+func getReceivedAmount(tradeInfo models.TradeModel, direction int) float64 {
+	if direction == int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY) {
+		return tradeInfo.Volume
+	}
+
+	return tradeInfo.Volume * tradeInfo.Price
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+)
+
+type accountStorageStub map[string]string
+
+func (a accountStorageStub) GetAccountTier(ctx context.Context, accountId string) (string, error) {
+	return a[accountId], nil
+}
+
+func TestFeeEngine_ApplyTradeFees(t *testing.T) {
+	settings := models.InstrumentSettings{
+		CurrencyPair: "BTC/USD",
+		Fees: models.FeeSchedule{
+			FeeRates:      models.FeeRates{MakerFeeBps: 10, TakerFeeBps: 20},
+			TierRates:     map[string]models.FeeRates{"vip": {MakerFeeBps: 0, TakerFeeBps: 5}},
+			FeeBalanceIds: map[string]string{"BTC": "fee-btc", "USD": "fee-usd"},
+		},
+	}
+	buyOrder := models.OrderModel{AccountId: "buyer", Direction: int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY)}
+	sellOrder := models.OrderModel{AccountId: "seller", Direction: int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL)}
+
+	tests := []struct {
+		name         string
+		tiers        accountStorageStub
+		settings     models.InstrumentSettings
+		maker, taker models.OrderModel
+		auction      bool
+		wantMakerFee float64
+		wantTakerFee float64
+		wantLegs     int
+	}{
+		{
+			name:         "default rates, taker buys",
+			tiers:        accountStorageStub{},
+			settings:     settings,
+			maker:        sellOrder,
+			taker:        buyOrder,
+			wantMakerFee: 2000 * 10 / 10000.0,
+			wantTakerFee: 2 * 20 / 10000.0,
+			wantLegs:     2,
+		},
+		{
+			name:         "vip maker pays nothing",
+			tiers:        accountStorageStub{"buyer": "vip"},
+			settings:     settings,
+			maker:        buyOrder,
+			taker:        sellOrder,
+			wantMakerFee: 0,
+			wantTakerFee: 2000 * 20 / 10000.0,
+			wantLegs:     1,
+		},
+		{
+			name:         "auction charges both sides the maker rate",
+			tiers:        accountStorageStub{},
+			settings:     settings,
+			maker:        sellOrder,
+			taker:        buyOrder,
+			auction:      true,
+			wantMakerFee: 2000 * 10 / 10000.0,
+			wantTakerFee: 2 * 10 / 10000.0,
+			wantLegs:     2,
+		},
+		{
+			name:         "no fee balance configured",
+			tiers:        accountStorageStub{},
+			settings:     models.InstrumentSettings{CurrencyPair: "BTC/USD", Fees: models.FeeSchedule{FeeRates: models.FeeRates{MakerFeeBps: 10, TakerFeeBps: 20}}},
+			maker:        sellOrder,
+			taker:        buyOrder,
+			wantMakerFee: 0,
+			wantTakerFee: 0,
+			wantLegs:     0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFeeEngine(tt.tiers)
+			tradeInfo := models.TradeModel{CurrencyPair: "BTC/USD", Price: 1000, Volume: 2, Auction: tt.auction}
+
+			if err := f.ApplyTradeFees(context.Background(), &tradeInfo, tt.settings, tt.maker, tt.taker); err != nil {
+				t.Fatalf("ApplyTradeFees() error = %v", err)
+			}
+			if math.Abs(tradeInfo.MakerFee-tt.wantMakerFee) > 1e-9 || math.Abs(tradeInfo.TakerFee-tt.wantTakerFee) > 1e-9 {
+				t.Errorf("ApplyTradeFees() fees = %v/%v, want %v/%v", tradeInfo.MakerFee, tradeInfo.TakerFee, tt.wantMakerFee, tt.wantTakerFee)
+			}
+			if legs := f.BuildFeeTransferData(tradeInfo, tt.settings); len(legs) != tt.wantLegs {
+				t.Errorf("BuildFeeTransferData() = %v legs, want %v", len(legs), tt.wantLegs)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"trade-order-processing-service/staticerr"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	haltSchedulerInterval = time.Second
+)
+
+// RunHaltScheduler resumes pairs whose halt time is over and releases what was queued while a pair was not trading.
+func (o *OrderService) RunHaltScheduler(ctx context.Context) {
+	ticker := time.NewTicker(haltSchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.resumeHaltedPairs(ctx, time.Now())
+		}
+	}
+}
+
+func (o *OrderService) resumeHaltedPairs(ctx context.Context, now time.Time) {
+	statuses, err := o.marketService.GetPairStatuses(ctx)
+
+	if err != nil {
+		logrus.Errorln("Fail get pair statuses, reason: ", err.Error())
+		return
+	}
+
+	for _, status := range statuses {
+		if !isHaltOver(status, now) {
+			continue
+		}
+
+		logrus.WithField("currencyPair", status.CurrencyPair).Infoln("Halt time is over, resume trading")
+
+		if err = o.marketService.ResumeTrading(ctx, status.CurrencyPair); err != nil {
+			logrus.WithField("currencyPair", status.CurrencyPair).Errorln("Fail resume trading, reason: ", err.Error())
+		}
+	}
+
+	currencyPairs, err := o.marketService.GetQueuedPairs(ctx)
+
+	if err != nil {
+		logrus.Errorln("Fail get queued pairs, reason: ", err.Error())
+		return
+	}
+
+	for _, currencyPair := range currencyPairs {
+		if err = o.releaseQueued(ctx, currencyPair, now); err != nil {
+			logrus.WithField("currencyPair", currencyPair).Errorln("Fail release queued orders, reason: ", err.Error())
+		}
+	}
+}
+
+// releaseQueued creates the queued orders in arrival order, then re-emits the queued matches.
+// It stops as soon as the pair is no longer open or its trading session is over.
+func (o *OrderService) releaseQueued(ctx context.Context, currencyPair string, now time.Time) error {
+	settings, err := o.instrumentStorage.GetInstrumentSettings(ctx, currencyPair)
+
+	if err != nil {
+		return err
+	}
+
+	for {
+		status, err := o.marketService.GetPairStatus(ctx, currencyPair)
+
+		if err != nil {
+			return err
+		}
+
+		if !isPairTrading(*status, settings.Session, now) {
+			return nil
+		}
+
+		request, err := o.marketService.PopQueuedOrderRequest(ctx, currencyPair)
+
+		if errors.Is(err, staticerr.ErrorQueueIsEmpty) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		logrus.WithField("requestId", request.Id).Infoln("Trading is resumed, create queued order")
+
+		o.CreateOrder(ctx, request)
+	}
+
+	return o.marketService.ReleaseQueuedMatches(ctx, currencyPair)
+}
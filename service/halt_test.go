@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/utils"
+)
+
+func TestOrderService_resumeHaltedPairs(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	settings := models.NewDefaultInstrumentSettings("BTC/USDT")
+	settings.PriceBands.QueueWhenHalted = true
+	env.setSettings(t, settings)
+
+	if err := env.marketService.HaltTrading(ctx, "BTC/USDT", haltReasonCircuitBreaker, time.Hour); err != nil {
+		t.Fatalf("HaltTrading() error = %v", err)
+	}
+
+	env.orderService.CreateOrder(ctx, &ops.OpsCreateOrderRequest{
+		Id:           "request-1",
+		AccountId:    "account-1",
+		CurrencyPair: "BTC/USDT",
+		Direction:    ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY,
+		LimitPrice:   100,
+		AskVolume:    1,
+		Type:         ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT,
+	})
+
+	if got := env.drainTickets(t, ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION); len(got) != 0 {
+		t.Fatalf("order is created while halted, notifications = %v", got)
+	}
+
+	tests := []struct {
+		name              string
+		now               time.Time
+		wantStatus        int
+		wantNotifications int
+	}{
+		{name: "halt is not over", now: time.Now(), wantStatus: models.PairStatusHalted, wantNotifications: 0},
+		{name: "halt is over", now: time.Now().Add(2 * time.Hour), wantStatus: models.PairStatusOpen, wantNotifications: 1},
+		{name: "queue is drained", now: time.Now().Add(3 * time.Hour), wantStatus: models.PairStatusOpen, wantNotifications: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env.orderService.resumeHaltedPairs(ctx, tt.now)
+
+			status, err := env.marketService.GetPairStatus(ctx, "BTC/USDT")
+
+			if err != nil {
+				t.Fatalf("GetPairStatus() error = %v", err)
+			}
+
+			if status.Status != tt.wantStatus {
+				t.Errorf("status = %v, want %v", status.Status, tt.wantStatus)
+			}
+
+			notifications := env.drainTickets(t, ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION)
+
+			if len(notifications) != tt.wantNotifications {
+				t.Fatalf("notifications = %v, want %v", len(notifications), tt.wantNotifications)
+			}
+
+			for _, notification := range notifications {
+				if notification.AccountId != "account-1" || notification.Cause != nil {
+					t.Errorf("notification = %v, want created order of account-1", notification)
+				}
+			}
+		})
+	}
+}
+
+func TestMatcherService_MatchOrder_outsideSession(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	now := time.Now().UTC()
+	openMinute := (now.Hour()*60 + now.Minute() + 60) % (24 * 60)
+	settings := models.NewDefaultInstrumentSettings("BTC/USDT")
+	settings.Session = models.TradingSession{OpenMinute: openMinute, CloseMinute: (openMinute + 60) % (24 * 60)}
+	env.setSettings(t, settings)
+
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	buy := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY
+	maker := testOrder("maker", sell, 100, 1)
+	taker := testOrder("taker", buy, 100, 1)
+	env.bookOrders(t, maker)
+
+	if err := env.orderStorage.AddOrderToStorage(ctx, taker); err != nil {
+		t.Fatalf("AddOrderToStorage() error = %v", err)
+	}
+
+	env.matcherService.MatchOrder(ctx, utils.MapOrderInfoToProto(taker))
+
+	if got := env.getOrder(t, maker.OrderId); got.FilledVolume != 0 {
+		t.Fatalf("maker FilledVolume = %v, want no match outside session", got.FilledVolume)
+	}
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		wantMatches []string
+	}{
+		{name: "session is closed", now: now, wantMatches: []string{}},
+		{name: "session is open", now: now.Add(time.Hour), wantMatches: []string{taker.OrderId}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env.orderService.resumeHaltedPairs(ctx, tt.now)
+
+			matches := make([]string, 0)
+
+			for _, orderInfo := range env.drainTickets(t, ops.OpsTicketOperation_OPS_TICKET_OPERATION_MATCH_ORDER) {
+				matches = append(matches, orderInfo.OrderId)
+			}
+
+			if !reflect.DeepEqual(matches, tt.wantMatches) {
+				t.Errorf("released matches = %v, want %v", matches, tt.wantMatches)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"time"
+	"trade-order-processing-service/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+const killSwitchExchange = "e.ops.kill_switch"
+
+type iKillSwitchStorage interface {
+	GetKillSwitch(ctx context.Context, accountId string) (*models.KillSwitchModel, error)
+	SetKillSwitch(ctx context.Context, state models.KillSwitchModel) error
+}
+
+type KillSwitchService struct {
+	killSwitchStorage iKillSwitchStorage
+	cancelService     *CancelService
+	messageSender     iMessageSender
+}
+
+func NewKillSwitchService(killSwitchStorage iKillSwitchStorage, cancelService *CancelService, messageSender iMessageSender) *KillSwitchService {
+	return &KillSwitchService{killSwitchStorage: killSwitchStorage, cancelService: cancelService, messageSender: messageSender}
+}
+
+// ChangeKillSwitch flips the account switch, enabling it cancels every resting order of the account.
+func (k *KillSwitchService) ChangeKillSwitch(ctx context.Context, request *models.ChangeKillSwitchRequest) {
+	logrus.WithFields(logrus.Fields{
+		"accountId": request.AccountId,
+		"operator":  request.Operator}).Warningln("Received kill switch request, enabled: ", request.Enabled)
+
+	if request.AccountId == "" {
+		logrus.Errorln("Kill switch request without account id")
+		return
+	}
+
+	state := models.KillSwitchModel{
+		AccountId:   request.AccountId,
+		Enabled:     request.Enabled,
+		Reason:      request.Reason,
+		UpdatedBy:   request.Operator,
+		UpdatedDate: time.Now().UTC().UnixMilli(),
+	}
+
+	if err := k.killSwitchStorage.SetKillSwitch(ctx, state); err != nil {
+		logrus.WithField("accountId", request.AccountId).Errorln("Fail save kill switch, reason: ", err.Error())
+		return
+	}
+
+	if err := k.messageSender.SendJsonMessage(ctx, state, killSwitchExchange, state.AccountId); err != nil {
+		logrus.WithField("accountId", request.AccountId).Errorln("Fail send kill switch notification, reason: ", err.Error())
+	}
+
+	if !state.Enabled {
+		return
+	}
+
+	response, err := k.cancelService.massCancel(ctx, state.AccountId, "", false)
+
+	if err != nil {
+		logrus.WithField("accountId", request.AccountId).Errorln("Fail cancel account orders, reason: ", err.Error())
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"accountId": request.AccountId,
+		"failed":    response.FailedCount}).Infoln("Account orders are cancelled by kill switch: ", response.CancelledCount)
+}
+
+func (k *KillSwitchService) IsAccountBlocked(ctx context.Context, accountId string) (bool, error) {
+	state, err := k.killSwitchStorage.GetKillSwitch(ctx, accountId)
+
+	if err != nil {
+		return false, err
+	}
+
+	return state.Enabled, nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/utils"
+)
+
+type killSwitchStorageStub map[string]models.KillSwitchModel
+
+func (k killSwitchStorageStub) GetKillSwitch(ctx context.Context, accountId string) (*models.KillSwitchModel, error) {
+	state, ok := k[accountId]
+
+	if !ok {
+		return &models.KillSwitchModel{AccountId: accountId}, nil
+	}
+
+	return &state, nil
+}
+
+func (k killSwitchStorageStub) SetKillSwitch(ctx context.Context, state models.KillSwitchModel) error {
+	k[state.AccountId] = state
+	return nil
+}
+
+func TestKillSwitchService_IsAccountBlocked(t *testing.T) {
+	storage := killSwitchStorageStub{
+		"blocked":  {AccountId: "blocked", Enabled: true, UpdatedBy: "risk"},
+		"released": {AccountId: "released", Enabled: false, UpdatedBy: "risk"},
+	}
+	tests := []struct {
+		name      string
+		accountId string
+		want      bool
+	}{
+		{name: "switch enabled", accountId: "blocked", want: true},
+		{name: "switch released", accountId: "released", want: false},
+		{name: "switch never set", accountId: "unknown", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := NewKillSwitchService(storage, nil, nil)
+
+			got, err := k.IsAccountBlocked(context.Background(), tt.accountId)
+
+			if err != nil {
+				t.Fatalf("IsAccountBlocked() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("IsAccountBlocked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func blockAccount(t *testing.T, env *testEnv, accountId string) {
+	t.Helper()
+
+	if err := env.accountStorage.SetKillSwitch(context.Background(), models.KillSwitchModel{AccountId: accountId, Enabled: true}); err != nil {
+		t.Fatalf("SetKillSwitch() error = %v", err)
+	}
+}
+
+func TestMatcherService_MatchOrder_killSwitch(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	buy := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY
+	tests := []struct {
+		name          string
+		algorithm     int
+		blocked       string
+		wantFilled    map[string]float64
+		wantTakerDone bool
+	}{
+		{name: "fifo skips a blocked maker", algorithm: models.MatchingAlgorithmFifo, blocked: "account-blocked", wantFilled: map[string]float64{"blocked": 0, "free": 1, "taker": 1}},
+		{name: "pro-rata skips a blocked maker", algorithm: models.MatchingAlgorithmProRata, blocked: "account-blocked", wantFilled: map[string]float64{"blocked": 0, "free": 1, "taker": 1}},
+		{name: "blocked taker is closed unmatched", algorithm: models.MatchingAlgorithmFifo, blocked: "account-taker", wantFilled: map[string]float64{"blocked": 0, "free": 0, "taker": 0}, wantTakerDone: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			settings := models.NewDefaultInstrumentSettings("BTC/USDT")
+			settings.MatchingAlgorithm = tt.algorithm
+			env.setSettings(t, settings)
+
+			blocked := testOrder("blocked", sell, 100, 1)
+			blocked.CreationDate -= 1000
+			env.bookOrders(t, blocked, testOrder("free", sell, 100, 1))
+			blockAccount(t, env, tt.blocked)
+
+			taker := testOrder("taker", buy, 100, 1)
+
+			if err := env.orderStorage.AddOrderToStorage(ctx, taker); err != nil {
+				t.Fatalf("AddOrderToStorage() error = %v", err)
+			}
+
+			env.matcherService.MatchOrder(ctx, utils.MapOrderInfoToProto(taker))
+
+			for id, want := range tt.wantFilled {
+				if got := env.getOrder(t, id); got.FilledVolume != want {
+					t.Errorf("%s FilledVolume = %v, want %v", id, got.FilledVolume, want)
+				}
+			}
+
+			if got := env.getOrder(t, taker.OrderId); (got.State == int(ops.OpsOrderState_OPS_ORDER_STATE_DONE)) != tt.wantTakerDone {
+				t.Errorf("taker state = %v, want done %v", got.State, tt.wantTakerDone)
+			}
+		})
+	}
+}
+
+func TestMatcherService_uncrossAuction_killSwitch(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	buy := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY
+	env.bookOrders(t, testOrder("bid", buy, 101, 1), testOrder("blocked", sell, 99, 1), testOrder("free", sell, 100, 1))
+	blockAccount(t, env, "account-blocked")
+	env.marketService.ChangePairStatus(ctx, &models.ChangePairStatusRequest{CurrencyPair: "BTC/USDT", Status: models.PairStatusAuction})
+
+	if err := env.matcherService.uncrossAuction(ctx, "BTC/USDT"); err != nil {
+		t.Fatalf("uncrossAuction() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		wantFilled float64
+	}{
+		{name: "blocked ask stays out of the auction", id: "blocked", wantFilled: 0},
+		{name: "free ask is crossed", id: "free", wantFilled: 1},
+		{name: "bid is filled by the free ask", id: "bid", wantFilled: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := env.getOrder(t, tt.id); got.FilledVolume != tt.wantFilled {
+				t.Errorf("FilledVolume = %v, want %v", got.FilledVolume, tt.wantFilled)
+			}
+		})
+	}
+}
+
+func TestKillSwitchService_ChangeKillSwitch(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	tests := []struct {
+		name      string
+		enabled   bool
+		wantState ops.OpsOrderState
+	}{
+		{name: "enabling cancels resting orders", enabled: true, wantState: ops.OpsOrderState_OPS_ORDER_STATE_DONE},
+		{name: "releasing leaves orders resting", enabled: false, wantState: ops.OpsOrderState_OPS_ORDER_STATE_IN_PROCESS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			env.bookOrders(t, testOrder("order", sell, 100, 1), testOrder("other", sell, 100, 1))
+
+			env.killSwitchService.ChangeKillSwitch(ctx, &models.ChangeKillSwitchRequest{AccountId: "account-order", Enabled: tt.enabled, Operator: "risk"})
+
+			if got := env.getOrder(t, "order"); got.State != int(tt.wantState) {
+				t.Errorf("order state = %v, want %v", got.State, tt.wantState)
+			}
+
+			if got := env.getOrder(t, "other"); got.State != int(ops.OpsOrderState_OPS_ORDER_STATE_IN_PROCESS) {
+				t.Errorf("other account order state = %v, want untouched", got.State)
+			}
+
+			if sent := env.messageSender.sentTo(killSwitchExchange); len(sent) != 1 {
+				t.Errorf("kill switch notifications = %v, want 1", len(sent))
+			}
+		})
+	}
+}
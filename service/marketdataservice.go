@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	depthExchange           = "e.ops.market_data.depth"
+	depthUpdateRoutingKey   = "update."
+	depthSnapshotRoutingKey = "snapshot."
+	orderFeedExchange       = "e.ops.market_data.orders"
+	orderFeedRelayBatch     = 500
+)
+
+type iMarketDataStorage interface {
+	NextDepthLevel(ctx context.Context, currencyPair string, direction int, price float64) (*models.PriceLevel, int64, error)
+	GetDepthSequence(ctx context.Context, currencyPair string) (int64, error)
+	MarkTickerChanged(ctx context.Context, currencyPair string) error
+	PopChangedTickers(ctx context.Context) ([]string, error)
+}
+
+type MarketDataService struct {
+	orderStorage      iOrderStorage
+	marketDataStorage iMarketDataStorage
+	messageSender     iMessageSender
+}
+
+func NewMarketDataService(orderStorage iOrderStorage, marketDataStorage iMarketDataStorage, messageSender iMessageSender) *MarketDataService {
+	return &MarketDataService{orderStorage: orderStorage, marketDataStorage: marketDataStorage, messageSender: messageSender}
+}
+
+// PublishBookChange feeds L2 and the ticker from one book change, hidden orders are not displayed in the feeds.
+// L3 events are journaled by the book transactions and published by RunOrderFeedRelay.
+func (m *MarketDataService) PublishBookChange(ctx context.Context, eventType string, orderInfo models.OrderModel) {
+	displayed := !orderInfo.Hidden && orderInfo.Type == int(ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT)
+
+	if displayed || eventType == models.OrderEventExecute {
+		m.MarkTickerChanged(ctx, orderInfo.CurrencyPair)
+	}
+
+	if displayed {
+		m.publishDepthUpdate(ctx, orderInfo)
+	}
+}
+
+// RunOrderFeedRelay publishes the journaled order events in their order, it must run in one instance.
+// Events are sent at least once, consumers drop sequences they have already applied.
+func (m *MarketDataService) RunOrderFeedRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.relayOrderFeed(ctx); err != nil {
+				logrus.Errorln("Fail relay order feed, reason: ", err.Error())
+			}
+		}
+	}
+}
+
+func (m *MarketDataService) relayOrderFeed(ctx context.Context) error {
+	for {
+		events, lastId, err := m.orderStorage.ReadOrderFeed(ctx, orderFeedRelayBatch)
+
+		if err != nil {
+			return err
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		for _, event := range events {
+			if err = m.messageSender.SendJsonMessage(ctx, event, orderFeedExchange, event.CurrencyPair); err != nil {
+				return err
+			}
+		}
+
+		if err = m.orderStorage.AckOrderFeed(ctx, lastId); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *MarketDataService) SendOrderBookSnapshot(ctx context.Context, request *models.OrderBookSnapshotRequest) {
+	if err := m.PublishOrderBookSnapshot(ctx, request.CurrencyPair); err != nil {
+		logrus.WithField("currencyPair", request.CurrencyPair).Errorln("Fail send order book snapshot, reason: ", err.Error())
+	}
+}
+
+// PublishOrderBookSnapshot sends the displayed orders read together with the order feed sequence they are current at.
+func (m *MarketDataService) PublishOrderBookSnapshot(ctx context.Context, currencyPair string) error {
+	orders, sequence, err := m.orderStorage.GetOrderFeedBook(ctx, currencyPair)
+
+	if err != nil {
+		return err
+	}
+
+	snapshot := models.OrderBookSnapshotModel{
+		CurrencyPair: currencyPair,
+		Bids:         []models.BookOrderModel{},
+		Asks:         []models.BookOrderModel{},
+		Sequence:     sequence,
+		Timestamp:    time.Now().UTC().UnixMilli(),
+	}
+
+	sortBookOrders(orders)
+
+	for _, order := range orders {
+		bookOrder := models.BookOrderModel{OrderId: order.OrderId, Price: order.Price, RemainingVolume: order.RemainingVolume, CreationDate: order.CreationDate}
+
+		if order.Direction == int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY) {
+			snapshot.Bids = append(snapshot.Bids, bookOrder)
+		} else {
+			snapshot.Asks = append(snapshot.Asks, bookOrder)
+		}
+	}
+
+	return m.messageSender.SendJsonMessage(ctx, snapshot, orderFeedExchange, depthSnapshotRoutingKey+currencyPair)
+}
+
+// MarkTickerChanged queues the pair for the next ticker push.
+func (m *MarketDataService) MarkTickerChanged(ctx context.Context, currencyPair string) {
+	if err := m.marketDataStorage.MarkTickerChanged(ctx, currencyPair); err != nil {
+		logrus.WithField("currencyPair", currencyPair).Errorln("Fail mark ticker changed, reason: ", err.Error())
+	}
+}
+
+// publishDepthUpdate sends the current volume of the order price level.
+func (m *MarketDataService) publishDepthUpdate(ctx context.Context, orderInfo models.OrderModel) {
+	level, sequence, err := m.marketDataStorage.NextDepthLevel(ctx, orderInfo.CurrencyPair, orderInfo.Direction, orderInfo.LimitPrice)
+
+	if err != nil {
+		logrus.WithField("currencyPair", orderInfo.CurrencyPair).Errorln("Fail get depth level, reason: ", err.Error())
+		return
+	}
+
+	if level.Volume < utils.VolumeEpsilon {
+		level.Volume = 0
+	}
+
+	update := models.DepthUpdateModel{
+		CurrencyPair: orderInfo.CurrencyPair,
+		Direction:    orderInfo.Direction,
+		Price:        level.Price,
+		Volume:       level.Volume,
+		Sequence:     sequence,
+		Timestamp:    time.Now().UTC().UnixMilli(),
+	}
+
+	if err = m.messageSender.SendJsonMessage(ctx, update, depthExchange, depthUpdateRoutingKey+orderInfo.CurrencyPair); err != nil {
+		logrus.WithField("currencyPair", orderInfo.CurrencyPair).Errorln("Fail send depth update, reason: ", err.Error())
+	}
+}
+
+func (m *MarketDataService) SendDepthSnapshot(ctx context.Context, request *models.DepthSnapshotRequest) {
+	if err := m.PublishDepthSnapshot(ctx, request.CurrencyPair); err != nil {
+		logrus.WithField("currencyPair", request.CurrencyPair).Errorln("Fail send depth snapshot, reason: ", err.Error())
+	}
+}
+
+// PublishDepthSnapshot sends the full book, the sequence is read first so that consumers
+// can replay every update above it on top of the snapshot.
+func (m *MarketDataService) PublishDepthSnapshot(ctx context.Context, currencyPair string) error {
+	sequence, err := m.marketDataStorage.GetDepthSequence(ctx, currencyPair)
+
+	if err != nil {
+		return err
+	}
+
+	bids, err := m.getDepthLevels(ctx, currencyPair, int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY))
+
+	if err != nil {
+		return err
+	}
+
+	asks, err := m.getDepthLevels(ctx, currencyPair, int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL))
+
+	if err != nil {
+		return err
+	}
+
+	snapshot := models.DepthSnapshotModel{
+		CurrencyPair: currencyPair,
+		Bids:         bids,
+		Asks:         asks,
+		Sequence:     sequence,
+		Timestamp:    time.Now().UTC().UnixMilli(),
+	}
+
+	return m.messageSender.SendJsonMessage(ctx, snapshot, depthExchange, depthSnapshotRoutingKey+currencyPair)
+}
+
+// sortBookOrders puts the best prices first on both sides and keeps time priority within a level.
+func sortBookOrders(orders []models.OrderEventModel) {
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].Direction != orders[j].Direction {
+			return orders[i].Direction < orders[j].Direction
+		}
+
+		if orders[i].Price != orders[j].Price {
+			if orders[i].Direction == int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY) {
+				return orders[i].Price > orders[j].Price
+			}
+			return orders[i].Price < orders[j].Price
+		}
+
+		return orders[i].CreationDate < orders[j].CreationDate
+	})
+}
+
+func (m *MarketDataService) getDepthLevels(ctx context.Context, currencyPair string, direction int) ([]models.PriceLevel, error) {
+	levels, err := m.orderStorage.GetStockBookLevels(ctx, currencyPair, direction, 0)
+
+	if errors.Is(err, staticerr.ErrorStockBookIsEmpty) {
+		return []models.PriceLevel{}, nil
+	}
+
+	return levels, err
+}
+
+// RunDepthSnapshotScheduler publishes depth and order book snapshots of the pairs periodically so late consumers can sync without asking.
+func (m *MarketDataService) RunDepthSnapshotScheduler(ctx context.Context, currencyPairs []string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, currencyPair := range currencyPairs {
+				if err := m.PublishDepthSnapshot(ctx, currencyPair); err != nil {
+					logrus.WithField("currencyPair", currencyPair).Errorln("Fail send depth snapshot, reason: ", err.Error())
+				}
+
+				if err := m.PublishOrderBookSnapshot(ctx, currencyPair); err != nil {
+					logrus.WithField("currencyPair", currencyPair).Errorln("Fail send order book snapshot, reason: ", err.Error())
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+)
+
+func TestMarketDataService_PublishBookChange(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	hidden := testOrder("hidden", sell, 100, 1)
+	hidden.Hidden = true
+	filled := testOrder("filled", sell, 100, 1)
+	filled.FilledVolume = 1
+	tests := []struct {
+		name      string
+		eventType string
+		orderInfo models.OrderModel
+		wantDepth []models.DepthUpdateModel
+	}{
+		{
+			name:      "new order publishes its level",
+			eventType: models.OrderEventAdd,
+			orderInfo: testOrder("new", sell, 100, 1),
+			wantDepth: []models.DepthUpdateModel{{Price: 100, Volume: 2, Sequence: 1}},
+		},
+		{
+			name:      "hidden order stays out of the feeds",
+			eventType: models.OrderEventAdd,
+			orderInfo: hidden,
+		},
+		{
+			name:      "filled order publishes its level",
+			eventType: models.OrderEventExecute,
+			orderInfo: filled,
+			wantDepth: []models.DepthUpdateModel{{Price: 100, Volume: 2, Sequence: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.bookOrders(t, testOrder("resting", sell, 100, 2))
+
+			env.marketDataService.PublishBookChange(context.Background(), tt.eventType, tt.orderInfo)
+
+			depth := env.messageSender.sentTo(depthExchange)
+
+			if len(depth) != len(tt.wantDepth) {
+				t.Fatalf("depth updates = %v, want %v", len(depth), len(tt.wantDepth))
+			}
+
+			for i, sent := range depth {
+				update := sent.(models.DepthUpdateModel)
+
+				if update.Price != tt.wantDepth[i].Price || update.Volume != tt.wantDepth[i].Volume || update.Sequence != tt.wantDepth[i].Sequence {
+					t.Errorf("depth update = %+v, want %+v", update, tt.wantDepth[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMarketDataService_relayOrderFeed(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	ctx := context.Background()
+	env := newTestEnv(t)
+	resting := testOrder("resting", sell, 100, 2)
+	env.bookOrders(t, resting)
+
+	if err := env.orderStorage.DropFromStockBook(ctx, resting); err != nil {
+		t.Fatalf("DropFromStockBook() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		wantEvents []string
+	}{
+		{name: "journaled events are published in order", wantEvents: []string{models.OrderEventAdd, models.OrderEventDelete}},
+		{name: "published events are not sent again", wantEvents: []string{models.OrderEventAdd, models.OrderEventDelete}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := env.marketDataService.relayOrderFeed(ctx); err != nil {
+				t.Fatalf("relayOrderFeed() error = %v", err)
+			}
+
+			events := env.messageSender.sentTo(orderFeedExchange)
+
+			if len(events) != len(tt.wantEvents) {
+				t.Fatalf("order events = %v, want %v", len(events), len(tt.wantEvents))
+			}
+
+			for i, sent := range events {
+				if event := sent.(models.OrderEventModel); event.EventType != tt.wantEvents[i] || event.Sequence != int64(i+1) {
+					t.Errorf("order event = %v %v, want %v %v", event.EventType, event.Sequence, tt.wantEvents[i], i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestMarketDataService_PublishOrderBookSnapshot(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	buy := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY
+	hidden := testOrder("hidden", sell, 101, 1)
+	hidden.Hidden = true
+	late := testOrder("late", buy, 99, 1)
+	late.CreationDate += 10
+	env := newTestEnv(t)
+	env.bookOrders(t, late, testOrder("ask-far", sell, 102, 1), testOrder("ask", sell, 101, 1), hidden,
+		testOrder("bid-far", buy, 98, 1), testOrder("early", buy, 99, 1))
+
+	if err := env.marketDataService.PublishOrderBookSnapshot(context.Background(), "BTC/USDT"); err != nil {
+		t.Fatalf("PublishOrderBookSnapshot() error = %v", err)
+	}
+
+	sent := env.messageSender.sentTo(orderFeedExchange)
+
+	if len(sent) != 1 {
+		t.Fatalf("snapshots = %v, want 1", len(sent))
+	}
+
+	snapshot := sent[0].(models.OrderBookSnapshotModel)
+	tests := []struct {
+		name   string
+		orders []models.BookOrderModel
+		want   []string
+	}{
+		{name: "bids best price first then time priority", orders: snapshot.Bids, want: []string{"early", "late", "bid-far"}},
+		{name: "asks best price first without hidden", orders: snapshot.Asks, want: []string{"ask", "ask-far"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0, len(tt.orders))
+
+			for _, orderInfo := range tt.orders {
+				got = append(got, orderInfo.OrderId)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("orders = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if snapshot.Sequence != 5 {
+		t.Errorf("sequence = %v, want 5", snapshot.Sequence)
+	}
+}
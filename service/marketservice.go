@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/utils"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	haltReasonCircuitBreaker = "CircuitBreaker"
+	haltReasonAuctionFailed  = "AuctionUncrossFailed"
+	marketStatusExchange     = "e.ops.market_status"
+)
+
+type iMarketStorage interface {
+	GetPairStatus(ctx context.Context, currencyPair string) (*models.PairStatusModel, error)
+	GetPairStatuses(ctx context.Context) ([]models.PairStatusModel, error)
+	SetPairStatus(ctx context.Context, status models.PairStatusModel) error
+	GetLastPrice(ctx context.Context, currencyPair string) (float64, error)
+	AddTradePrice(ctx context.Context, tradeInfo models.TradeModel, window time.Duration) ([]float64, error)
+	QueueOrderRequest(ctx context.Context, currencyPair string, request []byte) error
+	PopQueuedOrderRequest(ctx context.Context, currencyPair string) ([]byte, error)
+	QueueMatch(ctx context.Context, currencyPair string, orderId string) error
+	PopQueuedMatch(ctx context.Context, currencyPair string) (string, error)
+	GetQueuedPairs(ctx context.Context) ([]string, error)
+}
+
+type MarketService struct {
+	marketStorage iMarketStorage
+	orderStorage  iOrderStorage
+	ticketStorage iTicketStorage
+	messageSender iMessageSender
+}
+
+func NewMarketService(marketStorage iMarketStorage, orderStorage iOrderStorage, ticketStorage iTicketStorage, messageSender iMessageSender) *MarketService {
+	return &MarketService{marketStorage: marketStorage, orderStorage: orderStorage, ticketStorage: ticketStorage, messageSender: messageSender}
+}
+
+func (m *MarketService) GetPairStatus(ctx context.Context, currencyPair string) (*models.PairStatusModel, error) {
+	return m.marketStorage.GetPairStatus(ctx, currencyPair)
+}
+
+func (m *MarketService) ChangePairStatus(ctx context.Context, request *models.ChangePairStatusRequest) {
+	logrus.WithFields(logrus.Fields{
+		"currencyPair": request.CurrencyPair,
+		"operator":     request.Operator}).Infoln("Received change pair status request: ", request.Status)
+
+	if request.Status < models.PairStatusOpen || request.Status > models.PairStatusAuction {
+		logrus.WithField("currencyPair", request.CurrencyPair).Errorln("Unknown pair status: ", request.Status)
+		return
+	}
+
+	status := models.PairStatusModel{
+		CurrencyPair: request.CurrencyPair,
+		Status:       request.Status,
+		Reason:       request.Reason,
+		UpdatedBy:    request.Operator,
+		UpdatedDate:  time.Now().UTC().UnixMilli(),
+	}
+
+	if (request.Status == models.PairStatusHalted || request.Status == models.PairStatusAuction) && request.DurationSec > 0 {
+		status.ResumeDate = time.Now().UTC().Add(time.Duration(request.DurationSec) * time.Second).UnixMilli()
+	}
+
+	if err := m.applyPairStatus(ctx, status); err != nil {
+		logrus.WithField("currencyPair", request.CurrencyPair).Errorln("Fail change pair status, reason: ", err.Error())
+	}
+}
+
+func (m *MarketService) GetPairStatuses(ctx context.Context) ([]models.PairStatusModel, error) {
+	return m.marketStorage.GetPairStatuses(ctx)
+}
+
+func (m *MarketService) GetQueuedPairs(ctx context.Context) ([]string, error) {
+	return m.marketStorage.GetQueuedPairs(ctx)
+}
+
+func (m *MarketService) HaltTrading(ctx context.Context, currencyPair string, reason string, duration time.Duration) error {
+	status := models.PairStatusModel{
+		CurrencyPair: currencyPair,
+		Status:       models.PairStatusHalted,
+		Reason:       reason,
+		UpdatedDate:  time.Now().UTC().UnixMilli(),
+	}
+
+	if duration > 0 {
+		status.ResumeDate = time.Now().UTC().Add(duration).UnixMilli()
+	}
+
+	return m.applyPairStatus(ctx, status)
+}
+
+func (m *MarketService) ResumeTrading(ctx context.Context, currencyPair string) error {
+	return m.applyPairStatus(ctx, models.PairStatusModel{
+		CurrencyPair: currencyPair,
+		Status:       models.PairStatusOpen,
+		UpdatedDate:  time.Now().UTC().UnixMilli(),
+	})
+}
+
+// applyPairStatus saves and publishes the status, whatever was queued meanwhile is released by the order service halt scheduler.
+func (m *MarketService) applyPairStatus(ctx context.Context, status models.PairStatusModel) error {
+	if err := m.marketStorage.SetPairStatus(ctx, status); err != nil {
+		return err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"currencyPair": status.CurrencyPair,
+		"reason":       status.Reason}).Warningln("Pair status is changed: ", status.Status)
+
+	if err := m.messageSender.SendJsonMessage(ctx, status, marketStatusExchange, status.CurrencyPair); err != nil {
+		logrus.WithField("currencyPair", status.CurrencyPair).Errorln("Fail send pair status notification, reason: ", err.Error())
+	}
+
+	return nil
+}
+
+// ReleaseQueuedMatches re-emits match tickets for the orders whose matching was queued while the pair was not trading.
+func (m *MarketService) ReleaseQueuedMatches(ctx context.Context, currencyPair string) error {
+	for {
+		orderId, err := m.marketStorage.PopQueuedMatch(ctx, currencyPair)
+
+		if errors.Is(err, staticerr.ErrorQueueIsEmpty) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		orderInfo, err := m.orderStorage.GetOrderFromStorage(ctx, orderId)
+
+		if err != nil {
+			logrus.WithField("orderId", orderId).Errorln("Fail get queued order, reason: ", err.Error())
+			continue
+		}
+
+		if err = m.ticketStorage.AddNewTicket(ctx, ops.OpsTicketOperation_OPS_TICKET_OPERATION_MATCH_ORDER, utils.MapOrderInfoToProto(*orderInfo)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MarketService) QueueOrderRequest(ctx context.Context, request *ops.OpsCreateOrderRequest) error {
+	data, err := proto.Marshal(request)
+
+	if err != nil {
+		return err
+	}
+
+	return m.marketStorage.QueueOrderRequest(ctx, request.CurrencyPair, data)
+}
+
+// PopQueuedOrderRequest returns the oldest order request queued while the pair was halted.
+func (m *MarketService) PopQueuedOrderRequest(ctx context.Context, currencyPair string) (*ops.OpsCreateOrderRequest, error) {
+	data, err := m.marketStorage.PopQueuedOrderRequest(ctx, currencyPair)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var request ops.OpsCreateOrderRequest
+
+	if err = proto.Unmarshal(data, &request); err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+func (m *MarketService) QueueMatch(ctx context.Context, orderInfo models.OrderModel) error {
+	return m.marketStorage.QueueMatch(ctx, orderInfo.CurrencyPair, orderInfo.OrderId)
+}
+
+func (m *MarketService) ValidatePriceBand(ctx context.Context, orderInfo models.OrderModel, bands models.PriceBandSettings) error {
+	if bands.BandBps == 0 || orderInfo.Type != int(ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT) {
+		return nil
+	}
+
+	referencePrice, err := m.getReferencePrice(ctx, orderInfo.CurrencyPair)
+
+	if err != nil {
+		return err
+	}
+
+	if referencePrice == 0 {
+		return nil
+	}
+
+	if math.Abs(orderInfo.LimitPrice-referencePrice)/referencePrice*utils.BpsDenominator > bands.BandBps {
+		return staticerr.ErrorPriceOutOfBand
+	}
+
+	return nil
+}
+
+// RegisterTrade records the trade price and halts the pair when the circuit breaker is tripped.
+func (m *MarketService) RegisterTrade(ctx context.Context, tradeInfo models.TradeModel, bands models.PriceBandSettings) (bool, error) {
+	window := time.Duration(bands.CircuitBreakerWindowSec) * time.Second
+
+	prices, err := m.marketStorage.AddTradePrice(ctx, tradeInfo, window)
+
+	if err != nil {
+		return false, err
+	}
+
+	if bands.CircuitBreakerBps == 0 || !isCircuitBreakerTripped(prices, bands.CircuitBreakerBps) {
+		return false, nil
+	}
+
+	err = m.HaltTrading(ctx, tradeInfo.CurrencyPair, haltReasonCircuitBreaker, time.Duration(bands.HaltDurationSec)*time.Second)
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (m *MarketService) getReferencePrice(ctx context.Context, currencyPair string) (float64, error) {
+	lastPrice, err := m.marketStorage.GetLastPrice(ctx, currencyPair)
+
+	if err != nil || lastPrice > 0 {
+		return lastPrice, err
+	}
+
+	bestBid, err := m.orderStorage.GetBestStockLevel(ctx, currencyPair, int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY))
+
+	if errors.Is(err, staticerr.ErrorStockBookIsEmpty) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	bestAsk, err := m.orderStorage.GetBestStockLevel(ctx, currencyPair, int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL))
+
+	if errors.Is(err, staticerr.ErrorStockBookIsEmpty) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return (bestBid.Price + bestAsk.Price) / 2, nil
+}
+
+func isCircuitBreakerTripped(prices []float64, thresholdBps float64) bool {
+	if len(prices) < 2 {
+		return false
+	}
+
+	minPrice, maxPrice := prices[0], prices[0]
+
+	for _, price := range prices[1:] {
+		minPrice = math.Min(minPrice, price)
+		maxPrice = math.Max(maxPrice, price)
+	}
+
+	return minPrice > 0 && (maxPrice-minPrice)/minPrice*utils.BpsDenominator > thresholdBps
+}
+
+// isHaltOver reports whether a halt with a resume time has run its course.
+func isHaltOver(status models.PairStatusModel, now time.Time) bool {
+	return status.Status == models.PairStatusHalted && status.ResumeDate != 0 && now.UTC().UnixMilli() >= status.ResumeDate
+}
+
+// isPairTrading reports whether the pair is open and inside its trading session.
+func isPairTrading(status models.PairStatusModel, session models.TradingSession, now time.Time) bool {
+	return status.Status == models.PairStatusOpen && isWithinTradingSession(session, now)
+}
+
+// isOrderAcceptedInStatus allows new orders on open pairs and only limit orders while an auction collects orders.
+func isOrderAcceptedInStatus(status models.PairStatusModel, orderInfo models.OrderModel) bool {
+	if status.Status == models.PairStatusAuction {
+		return orderInfo.Type == int(ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT)
+	}
+
+	return status.Status == models.PairStatusOpen
+}
+
+// isWithinTradingSession checks the UTC minute of day against the session, an empty session means round the clock.
+func isWithinTradingSession(session models.TradingSession, now time.Time) bool {
+	if session.OpenMinute == session.CloseMinute {
+		return true
+	}
+
+	minute := now.UTC().Hour()*60 + now.UTC().Minute()
+
+	if session.OpenMinute < session.CloseMinute {
+		return minute >= session.OpenMinute && minute < session.CloseMinute
+	}
+
+	return minute >= session.OpenMinute || minute < session.CloseMinute
+}
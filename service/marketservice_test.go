@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"trade-order-processing-service/models"
+)
+
+func Test_isCircuitBreakerTripped(t *testing.T) {
+	tests := []struct {
+		name         string
+		prices       []float64
+		thresholdBps float64
+		want         bool
+	}{
+		{name: "single trade", prices: []float64{100}, thresholdBps: 100, want: false},
+		{name: "move inside threshold", prices: []float64{100, 100.5, 99.8}, thresholdBps: 100, want: false},
+		{name: "move above threshold", prices: []float64{100, 101.5}, thresholdBps: 100, want: true},
+		{name: "drop above threshold", prices: []float64{100, 95, 97}, thresholdBps: 300, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCircuitBreakerTripped(tt.prices, tt.thresholdBps); got != tt.want {
+				t.Errorf("isCircuitBreakerTripped() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isWithinTradingSession(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name    string
+		session models.TradingSession
+		now     time.Time
+		want    bool
+	}{
+		{name: "round the clock", session: models.TradingSession{}, now: at(3, 0), want: true},
+		{name: "inside day session", session: models.TradingSession{OpenMinute: 9 * 60, CloseMinute: 17 * 60}, now: at(12, 30), want: true},
+		{name: "after day session", session: models.TradingSession{OpenMinute: 9 * 60, CloseMinute: 17 * 60}, now: at(17, 0), want: false},
+		{name: "inside overnight session", session: models.TradingSession{OpenMinute: 22 * 60, CloseMinute: 6 * 60}, now: at(1, 0), want: true},
+		{name: "outside overnight session", session: models.TradingSession{OpenMinute: 22 * 60, CloseMinute: 6 * 60}, now: at(12, 0), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isWithinTradingSession(tt.session, tt.now); got != tt.want {
+				t.Errorf("isWithinTradingSession() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
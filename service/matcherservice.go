@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"math"
 	"time"
 	"trade-order-processing-service/external/bps"
 	"trade-order-processing-service/external/ops"
@@ -13,89 +16,312 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	causeMarketRemainderCancelled = "MarketOrderRemainderCancelled"
+	causeMarketOrderRejected      = "MarketOrderRejected"
+	causeMarketConvertedToLimit   = "MarketOrderConvertedToLimit"
+)
+
+const (
+	executionReportsExchange = "e.ops.execution_reports"
+)
+
 type MatcherService struct {
-	orderStorage  iOrderStorage
-	ticketStorage iTicketStorage
+	orderStorage      iOrderStorage
+	ticketStorage     iTicketStorage
+	instrumentStorage iInstrumentStorage
+	tradeStorage      iTradeStorage
+	messageSender     iMessageSender
+	feeEngine         *FeeEngine
+	marketService     *MarketService
+	killSwitchService *KillSwitchService
+	marketDataService *MarketDataService
+	candleService     *CandleService
 }
 
-func NewMatcherService(orderStorage iOrderStorage, ticketStorage iTicketStorage) *MatcherService {
-	return &MatcherService{orderStorage: orderStorage, ticketStorage: ticketStorage}
+func NewMatcherService(orderStorage iOrderStorage, ticketStorage iTicketStorage, instrumentStorage iInstrumentStorage, tradeStorage iTradeStorage, messageSender iMessageSender, feeEngine *FeeEngine, marketService *MarketService, killSwitchService *KillSwitchService, marketDataService *MarketDataService, candleService *CandleService) *MatcherService {
+	return &MatcherService{
+		orderStorage:      orderStorage,
+		ticketStorage:     ticketStorage,
+		instrumentStorage: instrumentStorage,
+		tradeStorage:      tradeStorage,
+		messageSender:     messageSender,
+		feeEngine:         feeEngine,
+		marketService:     marketService,
+		killSwitchService: killSwitchService,
+		marketDataService: marketDataService,
+		candleService:     candleService,
+	}
 }
 
 func (m *MatcherService) MatchOrder(ctx context.Context, matchData *ops.OpsOrderInfo) {
 
-	lockId := uuid.NewString()
+	if err := m.orderStorage.AppendOrderAudit(ctx, matchData.OrderId, newAuditEntry(models.AuditSourceMatcher, models.AuditTypeMatchAttempt, matchData.OrderId, matchData)); err != nil {
+		logrus.WithField("orderId", matchData.OrderId).Errorln("Fail audit matching attempt, reason: ", err.Error())
+	}
+
+	orderModel, err := m.orderStorage.GetOrderFromStorage(ctx, matchData.OrderId)
+
+	if err != nil {
+		logrus.WithField("orderId", matchData.OrderId).Errorln("Internal error: ", err.Error())
+		return
+	}
+
+	blocked, err := m.killSwitchService.IsAccountBlocked(ctx, orderModel.AccountId)
+
+	if err != nil {
+		logrus.WithField("orderId", matchData.OrderId).Errorln("Internal error: ", err.Error())
+		return
+	}
+
+	if blocked {
+		logrus.WithField("orderId", matchData.OrderId).Warningln("Account is blocked by kill switch, cancel order...")
+		if err = m.closeOrder(ctx, orderModel, ops.OpsOrderState_OPS_ORDER_STATE_DONE, utils.MapErrorToOpsError(staticerr.ErrorAccountIsBlocked)); err != nil {
+			logrus.WithField("orderId", matchData.OrderId).Errorln("Failed cancel order: ", err.Error())
+		}
+		return
+	}
+
+	status, err := m.marketService.GetPairStatus(ctx, orderModel.CurrencyPair)
+
+	if err != nil {
+		logrus.WithField("orderId", matchData.OrderId).Errorln("Internal error: ", err.Error())
+		return
+	}
+
+	if status.Status == models.PairStatusAuction {
+		logrus.WithField("orderId", matchData.OrderId).Infoln("Pair is in auction, collect order without matching...")
+		if err = m.rejectOrderMatching(ctx, orderModel); err != nil {
+			logrus.WithField("orderId", matchData.OrderId).Errorln("Failed collect order for auction: ", err.Error())
+		}
+		return
+	}
+
+	settings, err := m.instrumentStorage.GetInstrumentSettings(ctx, orderModel.CurrencyPair)
+
+	if err != nil {
+		logrus.WithField("orderId", matchData.OrderId).Errorln("Internal error: ", err.Error())
+		return
+	}
+
+	if !isPairTrading(*status, settings.Session, time.Now()) {
+		logrus.WithField("orderId", matchData.OrderId).Infoln("Pair is not open, queue matching...")
+		if err = m.marketService.QueueMatch(ctx, *orderModel); err != nil {
+			logrus.WithField("orderId", matchData.OrderId).Errorln("Failed queue matching: ", err.Error())
+		}
+		return
+	}
 
 	orders, err := m.orderStorage.GetOrdersForMatch(ctx, matchData.OrderId)
 
 	if err != nil {
 		logrus.WithField("orderId", matchData.OrderId).Infoln("Orders stock book is empty, reject matching...")
-		if err = m.rejectOrderMatching(ctx, matchData); err != nil {
+		if err = m.rejectOrderMatching(ctx, orderModel); err != nil {
 			logrus.WithField("orderId", matchData.OrderId).Errorln("Failed reject matching, exit...")
 		}
 		logrus.WithField("orderId", matchData.OrderId).Infoln("Matching was rejected, exit...")
 		return
 	}
 
-	orderModel := utils.MapProtoOrderInfoToModel(matchData)
+	if settings.MatchingAlgorithm == models.MatchingAlgorithmProRata {
+		err = m.matchProRata(ctx, orderModel, orders, settings.LotSize)
+	} else {
+		err = m.matchFifo(ctx, orderModel, orders)
+	}
+
+	if m.handleMatchingError(ctx, orderModel, err) {
+		return
+	}
+
+	if utils.GetRemainingVolume(*orderModel) > 0 {
+		logrus.WithField("orderId", matchData.OrderId).Infoln("Order is not filled completely, reject matching for remainder...")
+		if err = m.rejectOrderMatching(ctx, orderModel); err != nil {
+			logrus.WithField("orderId", matchData.OrderId).Errorln("Failed reject matching, exit...")
+		}
+	}
+}
+
+func (m *MatcherService) matchFifo(ctx context.Context, orderModel *models.OrderModel, orders []string) error {
+	lockId := uuid.NewString()
 
 	for _, oId := range orders {
 
+		if utils.GetRemainingVolume(*orderModel) == 0 {
+			break
+		}
+
 		logrus.WithFields(logrus.Fields{
-			"orderId":        matchData.OrderId,
+			"orderId":        orderModel.OrderId,
 			"matchedOrderId": oId}).Infoln("Matching 1 stage: lock matchedOrderData:")
 
-		if err = m.orderStorage.TryLockOrder(ctx, oId, lockId); err != nil {
+		if err := m.orderStorage.TryLockOrder(ctx, oId, lockId); err != nil {
 			logrus.WithFields(logrus.Fields{
-				"orderId":        matchData.OrderId,
+				"orderId":        orderModel.OrderId,
 				"matchedOrderId": oId}).Warningln("MatchedOrderId is locked, skipping...")
 			continue
 		}
 
 		logrus.WithFields(logrus.Fields{
-			"orderId":        matchData.OrderId,
+			"orderId":        orderModel.OrderId,
 			"matchedOrderId": oId}).Infoln("Matching 2 stage: change OrdersData:")
 
 		matchingOrderInfo, err := m.orderStorage.GetOrderFromStorage(ctx, oId)
 
 		if err != nil {
 			logrus.WithFields(logrus.Fields{
-				"orderId":        matchData.OrderId,
+				"orderId":        orderModel.OrderId,
 				"matchedOrderId": oId}).Warningln("Internal error, skip this order...")
+			m.unlockOrder(ctx, oId, lockId)
 			continue
 		}
 
-		if err = m.performMatchingOrders(ctx, &orderModel, matchingOrderInfo); err != nil {
-			return
+		if m.isMakerBlocked(ctx, *matchingOrderInfo) {
+			m.unlockOrder(ctx, oId, lockId)
+			continue
+		}
+
+		err = m.performMatchingOrders(ctx, orderModel, matchingOrderInfo, matchingOrderInfo.LimitPrice, math.MaxFloat64, false)
+		m.unlockOrder(ctx, oId, lockId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// handleMatchingError reports whether matching of the order has to stop.
+func (m *MatcherService) handleMatchingError(ctx context.Context, orderModel *models.OrderModel, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, staticerr.ErrorLockAmountExhausted):
+		logrus.WithField("orderId", orderModel.OrderId).Infoln("Locked amount is exhausted, close order")
+		if err = m.closeOrder(ctx, orderModel, ops.OpsOrderState_OPS_ORDER_STATE_DONE, nil); err != nil {
+			logrus.WithField("orderId", orderModel.OrderId).Errorln("Failed close order: ", err.Error())
+		}
+	case errors.Is(err, staticerr.ErrorTradingHalted):
+		if utils.GetRemainingVolume(*orderModel) == 0 {
+			break
+		}
+		logrus.WithField("orderId", orderModel.OrderId).Infoln("Trading is halted by circuit breaker, queue matching for remainder...")
+		if err = m.marketService.QueueMatch(ctx, *orderModel); err != nil {
+			logrus.WithField("orderId", orderModel.OrderId).Errorln("Failed queue matching: ", err.Error())
 		}
+	default:
+		logrus.WithField("orderId", orderModel.OrderId).Errorln("Failed matching: ", err.Error())
+	}
+
+	return true
+}
 
+// isMakerBlocked reports whether the resting order belongs to an account stopped by the kill switch.
+func (m *MatcherService) isMakerBlocked(ctx context.Context, orderInfo models.OrderModel) bool {
+	blocked, err := m.killSwitchService.IsAccountBlocked(ctx, orderInfo.AccountId)
+
+	if err != nil {
+		logrus.WithField("matchedOrderId", orderInfo.OrderId).Warningln("Fail get kill switch, skip this order: ", err.Error())
+		return true
 	}
+
+	if blocked {
+		logrus.WithField("matchedOrderId", orderInfo.OrderId).Warningln("Account is blocked by kill switch, skip this order...")
+	}
+
+	return blocked
 }
 
-func (m *MatcherService) rejectOrderMatching(ctx context.Context, orderData *ops.OpsOrderInfo) error {
+func (m *MatcherService) unlockOrder(ctx context.Context, id string, lockId string) {
+	if err := m.orderStorage.TryUnlockOrder(ctx, id, lockId); err != nil {
+		logrus.WithField("orderId", id).Warningln("Failed unlock order: ", err.Error())
+	}
+}
+
+func (m *MatcherService) rejectOrderMatching(ctx context.Context, orderModel *models.OrderModel) error {
+
+	if orderModel.Type == int(ops.OpsOrderType_OPS_ORDER_TYPE_MARKET) {
+		return m.applyUnfilledMarketPolicy(ctx, orderModel)
+	}
 
-	if orderData.Type == ops.OpsOrderType_OPS_ORDER_TYPE_MARKET {
-		logrus.WithField("orderId", orderData.OrderId).Infoln("Order is market, convert to limit")
+	logrus.WithField("orderId", orderModel.OrderId).Infoln("Add order in stock book")
 
-		orderData.Type = ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT
+	if err := m.orderStorage.AddInStockBook(ctx, *orderModel); err != nil {
+		return err
+	}
+
+	m.marketDataService.PublishBookChange(ctx, models.OrderEventAdd, *orderModel)
 
-		if err := m.orderStorage.UpdateOrderInfo(ctx, utils.MapProtoOrderInfoToModel(orderData)); err != nil {
+	return nil
+}
+
+func (m *MatcherService) applyUnfilledMarketPolicy(ctx context.Context, orderModel *models.OrderModel) error {
+	settings, err := m.instrumentStorage.GetInstrumentSettings(ctx, orderModel.CurrencyPair)
+
+	if err != nil {
+		return err
+	}
+
+	switch settings.UnfilledMarketPolicy {
+	case models.UnfilledMarketPolicyCancel:
+		logrus.WithField("orderId", orderModel.OrderId).Infoln("Order is market, cancel remainder")
+
+		return m.closeOrder(ctx, orderModel, ops.OpsOrderState_OPS_ORDER_STATE_DONE, &ops.OpsError{
+			Message:   causeMarketRemainderCancelled,
+			ErrorCode: ops.OpsErrorCode_OPS_ERROR_CODE_STOCK_BOOK_IS_EMPTY,
+		})
+	case models.UnfilledMarketPolicyReject:
+		logrus.WithField("orderId", orderModel.OrderId).Infoln("Order is market, reject remainder")
+
+		return m.closeOrder(ctx, orderModel, ops.OpsOrderState_OPS_ORDER_STATE_REJECTED, &ops.OpsError{
+			Message:   causeMarketOrderRejected,
+			ErrorCode: ops.OpsErrorCode_OPS_ERROR_CODE_STOCK_BOOK_IS_EMPTY,
+		})
+	default:
+		logrus.WithField("orderId", orderModel.OrderId).Infoln("Order is market, convert to limit")
+
+		orderModel.Type = int(ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT)
+
+		if err = m.orderStorage.UpdateOrderInfo(ctx, *orderModel); err != nil {
 			return err
 		}
+
+		logrus.WithField("orderId", orderModel.OrderId).Infoln("Add order in stock book")
+
+		if err = m.orderStorage.AddInStockBook(ctx, *orderModel); err != nil {
+			return err
+		}
+
+		m.marketDataService.PublishBookChange(ctx, models.OrderEventAdd, *orderModel)
+
+		protoModel := utils.MapOrderInfoToProto(*orderModel)
+		protoModel.Cause = &ops.OpsError{
+			Message:   causeMarketConvertedToLimit,
+			ErrorCode: staticerr.OpsErrorCodeMarketConvertedToLimit,
+		}
+
+		return m.ticketStorage.AddNewTicket(ctx, ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION, protoModel)
 	}
+}
 
-	orderModel := utils.MapProtoOrderInfoToModel(orderData)
+func (m *MatcherService) closeOrder(ctx context.Context, orderModel *models.OrderModel, state ops.OpsOrderState, cause *ops.OpsError) error {
+	orderModel.State = int(state)
 
-	logrus.WithField("orderId", orderData.OrderId).Infoln("Add order in stock book")
+	if err := m.orderStorage.UpdateOrderInfo(ctx, *orderModel); err != nil {
+		return err
+	}
 
-	if err := m.orderStorage.AddInStockBook(ctx, orderModel); err != nil {
+	if err := m.refundUnusedLock(ctx, *orderModel); err != nil {
 		return err
 	}
 
-	return nil
+	protoModel := utils.MapOrderInfoToProto(*orderModel)
+	protoModel.Cause = cause
+
+	return m.ticketStorage.AddNewTicket(ctx, ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION, protoModel)
 }
 
-func (m *MatcherService) performMatchingOrders(ctx context.Context, firstOrder *models.OrderModel, secondOrder *models.OrderModel) error {
+func (m *MatcherService) performMatchingOrders(ctx context.Context, firstOrder *models.OrderModel, secondOrder *models.OrderModel, fillPrice float64, maxVolume float64, auction bool) error {
 
 	matchingDate := time.Now().UTC().UnixMilli()
 
@@ -107,72 +333,200 @@ func (m *MatcherService) performMatchingOrders(ctx context.Context, firstOrder *
 		return staticerr.ErrorOrderExpired
 	}
 
-	filledVolume := utils.Min(firstOrder.AskVolume, secondOrder.AskVolume)
+	filledVolume := utils.Min(utils.GetRemainingVolume(*firstOrder), utils.GetRemainingVolume(*secondOrder))
+	filledVolume = utils.Min(filledVolume, getAffordableVolume(*firstOrder, fillPrice))
+	filledVolume = utils.Min(filledVolume, maxVolume)
+
+	if filledVolume < utils.VolumeEpsilon {
+		return staticerr.ErrorLockAmountExhausted
+	}
 
-	transferId := uuid.NewString()
+	tradeInfo := models.TradeModel{
+		TradeId:        uuid.NewString(),
+		CurrencyPair:   firstOrder.CurrencyPair,
+		Price:          fillPrice,
+		Volume:         filledVolume,
+		MakerOrderId:   secondOrder.OrderId,
+		TakerOrderId:   firstOrder.OrderId,
+		MakerAccountId: secondOrder.AccountId,
+		TakerAccountId: firstOrder.AccountId,
+		TradeDate:      matchingDate,
+		TransferId:     uuid.NewString(),
+		Auction:        auction,
+	}
 
-	firstOrder.FilledVolume = filledVolume
-	firstOrder.MatchingDate = matchingDate
-	firstOrder.FilledPrice = secondOrder.LimitPrice
-	firstOrder.TransferId = transferId
-	changeStateForMatchedOrder(firstOrder)
+	if !auction {
+		tradeInfo.AggressorSide = firstOrder.Direction
+	}
 
-	secondOrder.FilledVolume = filledVolume
-	secondOrder.MatchingDate = matchingDate
-	secondOrder.FilledPrice = secondOrder.FilledVolume
-	secondOrder.TransferId = transferId
-	changeStateForMatchedOrder(secondOrder)
+	bookedOrder := *secondOrder
 
-	if err := m.orderStorage.DropFromStockBook(ctx, *secondOrder); err != nil {
+	settings, err := m.instrumentStorage.GetInstrumentSettings(ctx, firstOrder.CurrencyPair)
+
+	if err != nil {
 		return err
 	}
 
-	if err := m.orderStorage.UpdateOrderInfo(ctx, *firstOrder); err != nil {
+	if err = m.feeEngine.ApplyTradeFees(ctx, &tradeInfo, *settings, *secondOrder, *firstOrder); err != nil {
 		return err
 	}
 
-	if err := m.orderStorage.UpdateOrderInfo(ctx, *secondOrder); err != nil {
+	applyOrderFill(firstOrder, tradeInfo)
+	applyOrderFill(secondOrder, tradeInfo)
+
+	if err := m.tradeStorage.AddTradeToStorage(ctx, tradeInfo); err != nil {
 		return err
 	}
 
+	if err := m.orderStorage.ExecuteInStockBook(ctx, bookedOrder, *secondOrder); err != nil {
+		return err
+	}
+
+	m.marketDataService.PublishBookChange(ctx, models.OrderEventExecute, *secondOrder)
+
+	if err := m.orderStorage.UpdateOrdersInfo(ctx, *firstOrder, *secondOrder); err != nil {
+		return err
+	}
+
+	if err := m.performTransfer(ctx, tradeInfo, *firstOrder, *secondOrder, *settings); err != nil {
+		return err
+	}
+
+	m.sendExecutionReports(ctx, tradeInfo, *firstOrder, *secondOrder)
+
+	if err := m.candleService.RegisterTrade(ctx, tradeInfo); err != nil {
+		logrus.WithField("tradeId", tradeInfo.TradeId).Errorln("Fail add trade to candles, reason: ", err.Error())
+	}
+
+	for _, oInfo := range []models.OrderModel{*firstOrder, *secondOrder} {
+		if oInfo.State != int(ops.OpsOrderState_OPS_ORDER_STATE_FILLED) {
+			continue
+		}
+
+		if err := m.refundUnusedLock(ctx, oInfo); err != nil {
+			return err
+		}
+	}
+
+	halted, err := m.marketService.RegisterTrade(ctx, tradeInfo, settings.PriceBands)
+
+	if err != nil {
+		return err
+	}
+
+	if halted {
+		return staticerr.ErrorTradingHalted
+	}
+
 	return nil
 }
 
+func (m *MatcherService) sendExecutionReports(ctx context.Context, tradeInfo models.TradeModel, orders ...models.OrderModel) {
+	for _, oInfo := range orders {
+		report := utils.MapOrderFillToExecutionReport(oInfo, tradeInfo)
+
+		if err := m.messageSender.SendJsonMessage(ctx, report, executionReportsExchange, oInfo.AccountId); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"orderId": oInfo.OrderId,
+				"tradeId": tradeInfo.TradeId}).Errorln("Fail send execution report, reason: ", err.Error())
+			continue
+		}
+
+		details, _ := json.Marshal(report)
+		entry := models.AuditEntryModel{
+			Timestamp:     time.Now().UTC().UnixMilli(),
+			Source:        models.AuditSourceMatcher,
+			Type:          models.AuditTypeExecutionReport,
+			CorrelationId: tradeInfo.TradeId,
+			Details:       details,
+		}
+
+		if err := m.orderStorage.AppendOrderAudit(ctx, oInfo.OrderId, entry); err != nil {
+			logrus.WithField("orderId", oInfo.OrderId).Errorln("Fail audit execution report, reason: ", err.Error())
+		}
+	}
+}
+
+func applyOrderFill(orderInfo *models.OrderModel, tradeInfo models.TradeModel) {
+	orderInfo.FilledVolume += tradeInfo.Volume
+	orderInfo.FilledNotional += tradeInfo.Volume * tradeInfo.Price
+	orderInfo.FilledPrice = orderInfo.FilledNotional / orderInfo.FilledVolume
+	orderInfo.MatchingDate = tradeInfo.TradeDate
+	orderInfo.TransferId = tradeInfo.TransferId
+	orderInfo.TradeIds = append(orderInfo.TradeIds, tradeInfo.TradeId)
+
+	if orderInfo.Direction == int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY) {
+		orderInfo.SpentAmount = orderInfo.FilledNotional
+	} else {
+		orderInfo.SpentAmount += tradeInfo.Volume
+	}
+
+	changeStateForMatchedOrder(orderInfo)
+}
+
+// getAffordableVolume bounds a buy order by what is left of its locked amount,
+// so a market order walking the book never spends more than it has locked.
+func getAffordableVolume(orderInfo models.OrderModel, price float64) float64 {
+	if orderInfo.Direction != int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY) || orderInfo.LockedAmount == 0 || price == 0 {
+		return math.MaxFloat64
+	}
+
+	return math.Max(orderInfo.LockedAmount-orderInfo.SpentAmount, 0) / price
+}
+
 func changeStateForMatchedOrder(orderInfo *models.OrderModel) {
 
 	orderInfo.State = int(ops.OpsOrderState_OPS_ORDER_STATE_PART_FILLED)
 
-	if orderInfo.AskVolume == orderInfo.FilledVolume {
+	if utils.GetRemainingVolume(*orderInfo) == 0 {
 		orderInfo.State = int(ops.OpsOrderState_OPS_ORDER_STATE_FILLED)
 	}
 }
 
-func (m *MatcherService) performTransfer(ctx context.Context, transferId string, firstOrder, secondOrder models.OrderModel) error {
+func (m *MatcherService) refundUnusedLock(ctx context.Context, orderInfo models.OrderModel) error {
+	amount := orderInfo.LockedAmount - orderInfo.SpentAmount
+
+	if amount < utils.VolumeEpsilon {
+		return nil
+	}
+
+	logrus.WithField("orderId", orderInfo.OrderId).Infoln("Refund unused locked amount: ", amount)
+
+	return m.ticketStorage.AddNewTicket(ctx, ops.OpsTicketOperation_OPS_TICKET_OPERATION_REFUND_BALANCE, &bps.BpsRefundBalanceRequest{
+		Id:        orderInfo.OrderId,
+		BalanceId: orderInfo.ExchangeId,
+		Amount:    amount,
+	})
+}
+
+func (m *MatcherService) performTransfer(ctx context.Context, tradeInfo models.TradeModel, takerOrder, makerOrder models.OrderModel, settings models.InstrumentSettings) error {
 
 	amounts := make(map[string]float64)
 
-	for _, oInfo := range []models.OrderModel{firstOrder, secondOrder} {
+	for _, oInfo := range []models.OrderModel{takerOrder, makerOrder} {
 
-		amounts[oInfo.ExchangeId] = oInfo.FilledVolume
+		amounts[oInfo.ExchangeId] = tradeInfo.Volume
 
 		if oInfo.Direction == int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY) {
-			amounts[oInfo.ExchangeId] = oInfo.FilledPrice * oInfo.FilledVolume
+			amounts[oInfo.ExchangeId] = tradeInfo.Price * tradeInfo.Volume
 		}
 	}
 
 	transferRequest := &bps.BpsCreateTransferRequest{
-		Id: uuid.NewString(),
+		Id: tradeInfo.TransferId,
 		TransferData: []*bps.BpsTransferData{
 			&bps.BpsTransferData{
-				BalanceId: firstOrder.ExchangeId,
-				Amount:    amounts[secondOrder.ExchangeId],
+				BalanceId: takerOrder.ExchangeId,
+				Amount:    amounts[makerOrder.ExchangeId] - tradeInfo.TakerFee,
 			},
 			&bps.BpsTransferData{
-				BalanceId: secondOrder.ExchangeId,
-				Amount:    amounts[firstOrder.ExchangeId],
+				BalanceId: makerOrder.ExchangeId,
+				Amount:    amounts[takerOrder.ExchangeId] - tradeInfo.MakerFee,
 			},
 		}}
 
+	transferRequest.TransferData = append(transferRequest.TransferData, m.feeEngine.BuildFeeTransferData(tradeInfo, settings)...)
+
 	if err := m.ticketStorage.AddNewTicket(ctx, ops.OpsTicketOperation_OPS_TICKET_OPERATION_APPROVE_CREATION, transferRequest); err != nil {
 		return err
 	}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/utils"
+)
+
+func Test_applyOrderFill(t *testing.T) {
+	type want struct {
+		filledVolume   float64
+		filledNotional float64
+		filledPrice    float64
+		spentAmount    float64
+		state          int
+	}
+	tests := []struct {
+		name   string
+		order  models.OrderModel
+		trades []models.TradeModel
+		want   want
+	}{
+		{
+			name:   "buy filled by three makers",
+			order:  models.OrderModel{OrderId: "taker", AskVolume: 6, Direction: int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY)},
+			trades: []models.TradeModel{{Price: 100, Volume: 1}, {Price: 101, Volume: 2}, {Price: 104, Volume: 3}},
+			want: want{
+				filledVolume:   6,
+				filledNotional: 100 + 202 + 312,
+				filledPrice:    614.0 / 6,
+				spentAmount:    614,
+				state:          int(ops.OpsOrderState_OPS_ORDER_STATE_FILLED),
+			},
+		},
+		{
+			name:   "sell partially filled",
+			order:  models.OrderModel{OrderId: "taker", AskVolume: 5, Direction: int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL)},
+			trades: []models.TradeModel{{Price: 10, Volume: 1}, {Price: 8, Volume: 1}},
+			want: want{
+				filledVolume:   2,
+				filledNotional: 18,
+				filledPrice:    9,
+				spentAmount:    2,
+				state:          int(ops.OpsOrderState_OPS_ORDER_STATE_PART_FILLED),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := tt.order
+			for _, trade := range tt.trades {
+				applyOrderFill(&order, trade)
+			}
+			got := want{
+				filledVolume:   order.FilledVolume,
+				filledNotional: order.FilledNotional,
+				filledPrice:    order.FilledPrice,
+				spentAmount:    order.SpentAmount,
+				state:          order.State,
+			}
+			if math.Abs(got.filledPrice-tt.want.filledPrice) > 1e-9 {
+				t.Errorf("applyOrderFill() filledPrice = %v, want %v", got.filledPrice, tt.want.filledPrice)
+			}
+			got.filledPrice = tt.want.filledPrice
+			if got != tt.want {
+				t.Errorf("applyOrderFill() = %+v, want %+v", got, tt.want)
+			}
+			if len(order.TradeIds) != len(tt.trades) {
+				t.Errorf("applyOrderFill() tradeIds = %v, want %d entries", order.TradeIds, len(tt.trades))
+			}
+		})
+	}
+}
+
+func testOrder(id string, direction ops.OpsOrderDirection, price, volume float64) models.OrderModel {
+	return models.OrderModel{
+		OrderId:        id,
+		AccountId:      "account-" + id,
+		CurrencyPair:   "BTC/USDT",
+		Direction:      int(direction),
+		LimitPrice:     price,
+		AskVolume:      volume,
+		Type:           int(ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT),
+		CreationDate:   time.Now().UnixMilli(),
+		ExpirationDate: time.Now().Add(time.Hour).UnixMilli(),
+		State:          int(ops.OpsOrderState_OPS_ORDER_STATE_IN_PROCESS),
+	}
+}
+
+func TestMatcherService_MatchOrder_partiallyFilledMaker(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	buy := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY
+	tests := []struct {
+		name         string
+		takers       []models.OrderModel
+		filledVolume float64
+		booked       bool
+		levelVolume  float64
+	}{
+		{
+			name:         "maker stays booked with its remainder",
+			takers:       []models.OrderModel{testOrder("taker-1", buy, 100, 3)},
+			filledVolume: 3,
+			booked:       true,
+			levelVolume:  2,
+		},
+		{
+			name:         "fills accumulate across takers",
+			takers:       []models.OrderModel{testOrder("taker-1", buy, 100, 3), testOrder("taker-2", buy, 100, 1)},
+			filledVolume: 4,
+			booked:       true,
+			levelVolume:  1,
+		},
+		{
+			name:         "maker leaves the book once filled",
+			takers:       []models.OrderModel{testOrder("taker-1", buy, 100, 3), testOrder("taker-2", buy, 100, 2)},
+			filledVolume: 5,
+			booked:       false,
+			levelVolume:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			maker := testOrder("maker", sell, 100, 5)
+			env.bookOrders(t, maker)
+
+			for _, taker := range tt.takers {
+				if err := env.orderStorage.AddOrderToStorage(ctx, taker); err != nil {
+					t.Fatalf("AddOrderToStorage() error = %v", err)
+				}
+				env.matcherService.MatchOrder(ctx, utils.MapOrderInfoToProto(taker))
+			}
+
+			got := env.getOrder(t, maker.OrderId)
+			if got.FilledVolume != tt.filledVolume {
+				t.Errorf("maker FilledVolume = %v, want %v", got.FilledVolume, tt.filledVolume)
+			}
+
+			booked, err := env.orderStorage.IsInStockBook(ctx, got)
+			if err != nil || booked != tt.booked {
+				t.Errorf("IsInStockBook() = %v, %v, want %v", booked, err, tt.booked)
+			}
+
+			volume, err := env.orderStorage.GetStockBookLevel(ctx, maker.CurrencyPair, maker.Direction, maker.LimitPrice)
+			if err != nil || math.Abs(volume-tt.levelVolume) > 1e-9 {
+				t.Errorf("GetStockBookLevel() = %v, %v, want %v", volume, err, tt.levelVolume)
+			}
+		})
+	}
+}
+
+func TestMatcherService_applyUnfilledMarketPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    int
+		configure bool
+		wantState ops.OpsOrderState
+		wantType  ops.OpsOrderType
+		booked    bool
+		wantCause ops.OpsErrorCode
+	}{
+		{
+			name:      "unconfigured instrument converts to limit",
+			wantState: ops.OpsOrderState_OPS_ORDER_STATE_IN_PROCESS,
+			wantType:  ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT,
+			booked:    true,
+			wantCause: staticerr.OpsErrorCodeMarketConvertedToLimit,
+		},
+		{
+			name:      "convert to limit",
+			policy:    models.UnfilledMarketPolicyConvertToLimit,
+			configure: true,
+			wantState: ops.OpsOrderState_OPS_ORDER_STATE_IN_PROCESS,
+			wantType:  ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT,
+			booked:    true,
+			wantCause: staticerr.OpsErrorCodeMarketConvertedToLimit,
+		},
+		{
+			name:      "cancel remainder",
+			policy:    models.UnfilledMarketPolicyCancel,
+			configure: true,
+			wantState: ops.OpsOrderState_OPS_ORDER_STATE_DONE,
+			wantType:  ops.OpsOrderType_OPS_ORDER_TYPE_MARKET,
+			wantCause: ops.OpsErrorCode_OPS_ERROR_CODE_STOCK_BOOK_IS_EMPTY,
+		},
+		{
+			name:      "reject remainder",
+			policy:    models.UnfilledMarketPolicyReject,
+			configure: true,
+			wantState: ops.OpsOrderState_OPS_ORDER_STATE_REJECTED,
+			wantType:  ops.OpsOrderType_OPS_ORDER_TYPE_MARKET,
+			wantCause: ops.OpsErrorCode_OPS_ERROR_CODE_STOCK_BOOK_IS_EMPTY,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+
+			if tt.configure {
+				settings := models.NewDefaultInstrumentSettings("BTC/USDT")
+				settings.UnfilledMarketPolicy = tt.policy
+				env.setSettings(t, settings)
+			}
+
+			order := testOrder("taker", ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY, 100, 1)
+			order.Type = int(ops.OpsOrderType_OPS_ORDER_TYPE_MARKET)
+			if err := env.orderStorage.AddOrderToStorage(ctx, order); err != nil {
+				t.Fatalf("AddOrderToStorage() error = %v", err)
+			}
+
+			if err := env.matcherService.applyUnfilledMarketPolicy(ctx, &order); err != nil {
+				t.Fatalf("applyUnfilledMarketPolicy() error = %v", err)
+			}
+
+			got := env.getOrder(t, order.OrderId)
+			if got.State != int(tt.wantState) || got.Type != int(tt.wantType) {
+				t.Errorf("applyUnfilledMarketPolicy() state = %v, type = %v, want %v, %v", got.State, got.Type, tt.wantState, tt.wantType)
+			}
+
+			booked, err := env.orderStorage.IsInStockBook(ctx, got)
+			if err != nil || booked != tt.booked {
+				t.Errorf("IsInStockBook() = %v, %v, want %v", booked, err, tt.booked)
+			}
+
+			notifications := env.drainTickets(t, ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION)
+			if len(notifications) != 1 || notifications[0].Cause.GetErrorCode() != tt.wantCause {
+				t.Errorf("notifications = %v, want one with cause %v", notifications, tt.wantCause)
+			}
+		})
+	}
+}
+
+func TestMatcherService_sendExecutionReports(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	buy := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY
+	type report struct {
+		orderId          string
+		lastPrice        float64
+		cumulativeVolume float64
+		averagePrice     float64
+		leavesVolume     float64
+		liquidity        int
+	}
+	tests := []struct {
+		name   string
+		makers []models.OrderModel
+		taker  models.OrderModel
+		want   []report
+	}{
+		{
+			name:   "single fill reports both sides",
+			makers: []models.OrderModel{testOrder("maker-1", sell, 100, 5)},
+			taker:  testOrder("taker", buy, 100, 2),
+			want: []report{
+				{orderId: "taker", lastPrice: 100, cumulativeVolume: 2, averagePrice: 100, leavesVolume: 0, liquidity: models.LiquidityTaker},
+				{orderId: "maker-1", lastPrice: 100, cumulativeVolume: 2, averagePrice: 100, leavesVolume: 3, liquidity: models.LiquidityMaker},
+			},
+		},
+		{
+			name:   "taker walking two levels averages its fills",
+			makers: []models.OrderModel{testOrder("maker-1", sell, 100, 1), testOrder("maker-2", sell, 101, 2)},
+			taker:  testOrder("taker", buy, 101, 3),
+			want: []report{
+				{orderId: "taker", lastPrice: 100, cumulativeVolume: 1, averagePrice: 100, leavesVolume: 2, liquidity: models.LiquidityTaker},
+				{orderId: "maker-1", lastPrice: 100, cumulativeVolume: 1, averagePrice: 100, leavesVolume: 0, liquidity: models.LiquidityMaker},
+				{orderId: "taker", lastPrice: 101, cumulativeVolume: 3, averagePrice: 302.0 / 3, leavesVolume: 0, liquidity: models.LiquidityTaker},
+				{orderId: "maker-2", lastPrice: 101, cumulativeVolume: 2, averagePrice: 101, leavesVolume: 0, liquidity: models.LiquidityMaker},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			env.bookOrders(t, tt.makers...)
+
+			if err := env.orderStorage.AddOrderToStorage(ctx, tt.taker); err != nil {
+				t.Fatalf("AddOrderToStorage() error = %v", err)
+			}
+
+			env.matcherService.MatchOrder(ctx, utils.MapOrderInfoToProto(tt.taker))
+
+			sent := env.messageSender.sentTo(executionReportsExchange)
+			if len(sent) != len(tt.want) {
+				t.Fatalf("sendExecutionReports() sent %d reports, want %d", len(sent), len(tt.want))
+			}
+
+			for i, message := range sent {
+				r := message.(models.ExecutionReportModel)
+				got := report{r.OrderId, r.LastPrice, r.CumulativeVolume, r.AveragePrice, r.LeavesVolume, r.Liquidity}
+
+				if math.Abs(got.averagePrice-tt.want[i].averagePrice) < 1e-9 {
+					got.averagePrice = tt.want[i].averagePrice
+				}
+
+				if got != tt.want[i] {
+					t.Errorf("report %d = %+v, want %+v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
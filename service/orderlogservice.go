@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+
+	"github.com/sirupsen/logrus"
+)
+
+const orderEventsPageSize = 1000
+
+type OrderLogService struct {
+	orderStorage iOrderStorage
+}
+
+func NewOrderLogService(orderStorage iOrderStorage) *OrderLogService {
+	return &OrderLogService{orderStorage: orderStorage}
+}
+
+// RebuildOrders re-derives the orders hash and book indexes by replaying the order event stream.
+// A non-empty book is only rebuilt with force, orders still booked keep their indexes.
+func (s *OrderLogService) RebuildOrders(ctx context.Context, request *models.RebuildOrdersRequest) {
+	logrus.WithField("operator", request.Operator).Warningln("Received rebuild orders request")
+
+	rebuilt, err := s.rebuildOrders(ctx, request.Force)
+
+	if err != nil {
+		logrus.WithField("id", request.Id).Errorln("Fail rebuild orders, reason: ", err.Error())
+		return
+	}
+
+	logrus.WithField("id", request.Id).Infoln("Orders are rebuilt from event log: ", rebuilt)
+}
+
+func (s *OrderLogService) rebuildOrders(ctx context.Context, force bool) (int, error) {
+	ids, err := s.orderStorage.GetBookOrderIds(ctx, "", "")
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ids) > 0 && !force {
+		return 0, staticerr.ErrorStockBookIsNotEmpty
+	}
+
+	replay := newOrderReplay()
+	lastId := "0"
+
+	for {
+		events, err := s.orderStorage.ReadOrderEvents(ctx, lastId, orderEventsPageSize)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if len(events) == 0 {
+			break
+		}
+
+		replay.apply(events...)
+		lastId = events[len(events)-1].EventId
+	}
+
+	for _, orderInfo := range replay.orders {
+		booked := replay.booked[orderInfo.OrderId]
+
+		if booked {
+			alreadyBooked, err := s.orderStorage.IsInStockBook(ctx, orderInfo)
+
+			if err != nil {
+				return 0, err
+			}
+
+			booked = !alreadyBooked
+		}
+
+		if err = s.orderStorage.RebuildOrder(ctx, orderInfo, booked); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(replay.orders), nil
+}
+
+type orderReplay struct {
+	orders map[string]models.OrderModel
+	booked map[string]bool
+}
+
+func newOrderReplay() *orderReplay {
+	return &orderReplay{orders: make(map[string]models.OrderModel), booked: make(map[string]bool)}
+}
+
+// apply folds events into the last known order state. Unbooking is logged with the state the order was
+// booked with, so it does not overwrite a newer one, and rejected orders are dropped as the service deletes them.
+func (r *orderReplay) apply(events ...models.OrderLogEventModel) {
+	for _, event := range events {
+		orderId := event.Order.OrderId
+
+		switch event.EventType {
+		case models.OrderLogRejected:
+			delete(r.orders, orderId)
+			delete(r.booked, orderId)
+			continue
+		case models.OrderLogBooked:
+			r.booked[orderId] = true
+		case models.OrderLogUnbooked:
+			delete(r.booked, orderId)
+
+			if _, ok := r.orders[orderId]; ok {
+				continue
+			}
+		}
+
+		r.orders[orderId] = event.Order
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+	"trade-order-processing-service/models"
+)
+
+func Test_orderReplay_apply(t *testing.T) {
+	booked := models.OrderModel{OrderId: "1", AskVolume: 10, State: 1}
+	filled := models.OrderModel{OrderId: "1", AskVolume: 10, FilledVolume: 10, State: 4}
+
+	tests := []struct {
+		name       string
+		events     []models.OrderLogEventModel
+		wantOrders map[string]float64
+		wantBooked map[string]bool
+	}{
+		{
+			name: "created and booked",
+			events: []models.OrderLogEventModel{
+				{EventType: models.OrderLogCreated, Order: models.OrderModel{OrderId: "1"}},
+				{EventType: models.OrderLogApproved, Order: booked},
+				{EventType: models.OrderLogBooked, Order: booked},
+			},
+			wantOrders: map[string]float64{"1": 0},
+			wantBooked: map[string]bool{"1": true},
+		},
+		{
+			name: "filled before unbooked keeps the newer state",
+			events: []models.OrderLogEventModel{
+				{EventType: models.OrderLogBooked, Order: booked},
+				{EventType: models.OrderLogFilled, Order: filled},
+				{EventType: models.OrderLogUnbooked, Order: booked},
+			},
+			wantOrders: map[string]float64{"1": 10},
+			wantBooked: map[string]bool{},
+		},
+		{
+			name: "rejected order is dropped",
+			events: []models.OrderLogEventModel{
+				{EventType: models.OrderLogCreated, Order: models.OrderModel{OrderId: "2"}},
+				{EventType: models.OrderLogRejected, Order: models.OrderModel{OrderId: "2"}},
+			},
+			wantOrders: map[string]float64{},
+			wantBooked: map[string]bool{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay := newOrderReplay()
+			replay.apply(tt.events...)
+
+			if len(replay.orders) != len(tt.wantOrders) {
+				t.Fatalf("apply() orders = %v, want %v", replay.orders, tt.wantOrders)
+			}
+
+			for id, filledVolume := range tt.wantOrders {
+				if replay.orders[id].FilledVolume != filledVolume {
+					t.Errorf("apply() order %s filled = %v, want %v", id, replay.orders[id].FilledVolume, filledVolume)
+				}
+			}
+
+			if len(replay.booked) != len(tt.wantBooked) {
+				t.Errorf("apply() booked = %v, want %v", replay.booked, tt.wantBooked)
+			}
+		})
+	}
+}
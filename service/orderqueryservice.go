@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ordersQueryExchange          = "e.ops.orders"
+	ordersQueryAccountRoutingKey = "account."
+	ordersQueryStateRoutingKey   = "state."
+	ordersQueryDefaultLimit      = 50
+	ordersQueryMaxLimit          = 500
+	ordersQueryMaxScanPages      = 20
+)
+
+type OrderQueryService struct {
+	orderStorage  iOrderStorage
+	messageSender iMessageSender
+}
+
+func NewOrderQueryService(orderStorage iOrderStorage, messageSender iMessageSender) *OrderQueryService {
+	return &OrderQueryService{orderStorage: orderStorage, messageSender: messageSender}
+}
+
+func (q *OrderQueryService) SendAccountOrders(ctx context.Context, request *models.AccountOrdersRequest) {
+	response := models.AccountOrdersResponse{Id: request.Id, AccountId: request.AccountId, Orders: []models.OrderModel{}}
+
+	orders, nextCursor, err := q.GetAccountOrders(ctx, request)
+
+	if err != nil {
+		logrus.WithField("accountId", request.AccountId).Errorln("Fail get account orders, reason: ", err.Error())
+		response.Error = err.Error()
+	} else {
+		response.Orders = orders
+		response.NextCursor = nextCursor
+	}
+
+	if err = q.messageSender.SendJsonMessage(ctx, response, ordersQueryExchange, ordersQueryAccountRoutingKey+request.AccountId); err != nil {
+		logrus.WithField("accountId", request.AccountId).Errorln("Fail send account orders, reason: ", err.Error())
+	}
+}
+
+// GetAccountOrders returns a page of account orders from the newest to the oldest and the cursor of the next page,
+// the cursor is empty when there are no more orders. At most ordersQueryMaxScanPages pages of the index are scanned
+// for the filters, so a page can come back short with a cursor to continue from.
+func (q *OrderQueryService) GetAccountOrders(ctx context.Context, request *models.AccountOrdersRequest) ([]models.OrderModel, string, error) {
+	limit := request.Limit
+
+	if limit <= 0 {
+		limit = ordersQueryDefaultLimit
+	}
+
+	if limit > ordersQueryMaxLimit {
+		limit = ordersQueryMaxLimit
+	}
+
+	toDate := request.ToDate
+	var after *models.OrderIndexEntry
+
+	if request.Cursor != "" {
+		cursor, err := decodeOrderCursor(request.Cursor)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		if toDate == 0 || cursor.Date < toDate {
+			toDate = cursor.Date
+		}
+
+		after = cursor
+	}
+
+	orders := make([]models.OrderModel, 0, limit)
+	offset := int64(0)
+
+	for {
+		entries, err := q.orderStorage.GetAccountOrderIndex(ctx, request.AccountId, request.State, request.FromDate, toDate, offset, limit)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		if len(entries) == 0 {
+			return orders, "", nil
+		}
+
+		offset += int64(len(entries))
+		last := entries[len(entries)-1]
+
+		candidates, err := q.orderStorage.GetOrdersFromStorage(ctx, skipOrdersBeforeCursor(entries, after))
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, orderInfo := range candidates {
+			if !isAccountOrderMatched(orderInfo, request) {
+				continue
+			}
+
+			orders = append(orders, orderInfo)
+
+			if int64(len(orders)) == limit {
+				return orders, encodeOrderCursor(models.OrderIndexEntry{OrderId: orderInfo.OrderId, Date: orderInfo.CreationDate}), nil
+			}
+		}
+
+		if offset >= limit*ordersQueryMaxScanPages {
+			return orders, encodeOrderCursor(last), nil
+		}
+	}
+}
+
+func (q *OrderQueryService) SendOrdersByState(ctx context.Context, request *models.StateOrdersRequest) {
+	response := models.StateOrdersResponse{Id: request.Id, State: request.State, Orders: []models.StateOrderModel{}}
+
+	orders, err := q.GetOrdersByState(ctx, request.State, time.Duration(request.MinAge)*time.Millisecond, request.Limit)
+
+	if err != nil {
+		logrus.WithField("state", request.State).Errorln("Fail get orders by state, reason: ", err.Error())
+		response.Error = err.Error()
+	} else {
+		response.Orders = orders
+	}
+
+	if err = q.messageSender.SendJsonMessage(ctx, response, ordersQueryExchange, ordersQueryStateRoutingKey+strconv.Itoa(request.State)); err != nil {
+		logrus.WithField("state", request.State).Errorln("Fail send orders by state, reason: ", err.Error())
+	}
+}
+
+// GetOrdersByState returns the orders that have stayed in the state at least minAge, the oldest first.
+func (q *OrderQueryService) GetOrdersByState(ctx context.Context, state int, minAge time.Duration, limit int64) ([]models.StateOrderModel, error) {
+	if limit <= 0 {
+		limit = ordersQueryDefaultLimit
+	}
+
+	if limit > ordersQueryMaxLimit {
+		limit = ordersQueryMaxLimit
+	}
+
+	now := time.Now().UTC().UnixMilli()
+	orders := make([]models.StateOrderModel, 0, limit)
+	offset := int64(0)
+
+	for {
+		entries, err := q.orderStorage.GetStateOrderIndex(ctx, state, now-minAge.Milliseconds(), offset, limit)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(entries) == 0 {
+			return orders, nil
+		}
+
+		offset += int64(len(entries))
+		ids := make([]string, 0, len(entries))
+		since := make(map[string]int64, len(entries))
+
+		for _, entry := range entries {
+			ids = append(ids, entry.OrderId)
+			since[entry.OrderId] = entry.Date
+		}
+
+		candidates, err := q.orderStorage.GetOrdersFromStorage(ctx, ids)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, orderInfo := range candidates {
+			if orderInfo.State != state {
+				continue
+			}
+
+			orders = append(orders, models.StateOrderModel{Order: orderInfo, Age: now - since[orderInfo.OrderId]})
+
+			if int64(len(orders)) == limit {
+				return orders, nil
+			}
+		}
+	}
+}
+
+// skipOrdersBeforeCursor drops the entries already returned, orders created at the cursor date come in
+// reverse order of their ids so only the ids below the cursor one are left.
+func skipOrdersBeforeCursor(entries []models.OrderIndexEntry, after *models.OrderIndexEntry) []string {
+	ids := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if after != nil && entry.Date == after.Date && entry.OrderId >= after.OrderId {
+			continue
+		}
+
+		ids = append(ids, entry.OrderId)
+	}
+
+	return ids
+}
+
+func isAccountOrderMatched(orderInfo models.OrderModel, request *models.AccountOrdersRequest) bool {
+	if request.CurrencyPair != "" && orderInfo.CurrencyPair != request.CurrencyPair {
+		return false
+	}
+
+	if request.Direction != nil && orderInfo.Direction != *request.Direction {
+		return false
+	}
+
+	if request.State != nil && orderInfo.State != *request.State {
+		return false
+	}
+
+	return true
+}
+
+func encodeOrderCursor(entry models.OrderIndexEntry) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", entry.Date, entry.OrderId)))
+}
+
+func decodeOrderCursor(cursor string) (*models.OrderIndexEntry, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil {
+		return nil, staticerr.ErrorInvalidCursor
+	}
+
+	creationDate, orderId, found := strings.Cut(string(data), ":")
+
+	if !found || orderId == "" {
+		return nil, staticerr.ErrorInvalidCursor
+	}
+
+	date, err := strconv.ParseInt(creationDate, 10, 64)
+
+	if err != nil {
+		return nil, staticerr.ErrorInvalidCursor
+	}
+
+	return &models.OrderIndexEntry{OrderId: orderId, Date: date}, nil
+}
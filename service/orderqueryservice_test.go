@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+)
+
+func Test_orderCursor(t *testing.T) {
+	entry := models.OrderIndexEntry{OrderId: "order:1", Date: 1700000000000}
+
+	cursor, err := decodeOrderCursor(encodeOrderCursor(entry))
+
+	if err != nil {
+		t.Fatalf("decodeOrderCursor() error = %v", err)
+	}
+
+	if *cursor != entry {
+		t.Errorf("decodeOrderCursor() = %+v, want %+v", cursor, entry)
+	}
+
+	for _, invalid := range []string{"%%%", "MTcwMA", "YWJjOmlk"} {
+		if _, err = decodeOrderCursor(invalid); !errors.Is(err, staticerr.ErrorInvalidCursor) {
+			t.Errorf("decodeOrderCursor(%v) error = %v, want %v", invalid, err, staticerr.ErrorInvalidCursor)
+		}
+	}
+}
+
+func Test_skipOrdersBeforeCursor(t *testing.T) {
+	entries := []models.OrderIndexEntry{
+		{OrderId: "c", Date: 20},
+		{OrderId: "b", Date: 20},
+		{OrderId: "a", Date: 20},
+		{OrderId: "d", Date: 10},
+	}
+
+	tests := []struct {
+		name  string
+		after *models.OrderIndexEntry
+		want  []string
+	}{
+		{name: "first page", after: nil, want: []string{"c", "b", "a", "d"}},
+		{name: "cursor inside same date", after: &models.OrderIndexEntry{OrderId: "b", Date: 20}, want: []string{"a", "d"}},
+		{name: "cursor on older date", after: &models.OrderIndexEntry{OrderId: "z", Date: 30}, want: []string{"c", "b", "a", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := skipOrdersBeforeCursor(entries, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("skipOrdersBeforeCursor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isAccountOrderMatched(t *testing.T) {
+	buy, sell, newState := 1, 0, 0
+	orderInfo := models.OrderModel{CurrencyPair: "BTC/USDT", Direction: 1, State: 2}
+
+	tests := []struct {
+		name    string
+		request models.AccountOrdersRequest
+		want    bool
+	}{
+		{name: "no filters", request: models.AccountOrdersRequest{}, want: true},
+		{name: "pair and side", request: models.AccountOrdersRequest{CurrencyPair: "BTC/USDT", Direction: &buy}, want: true},
+		{name: "other pair", request: models.AccountOrdersRequest{CurrencyPair: "ETH/USDT"}, want: false},
+		{name: "other side", request: models.AccountOrdersRequest{Direction: &sell}, want: false},
+		{name: "zero state filter", request: models.AccountOrdersRequest{State: &newState}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAccountOrderMatched(orderInfo, &tt.request); got != tt.want {
+				t.Errorf("isAccountOrderMatched() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderQueryService_GetAccountOrders_scanCap(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	q := NewOrderQueryService(env.orderStorage, env.messageSender)
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+
+	match := testOrder("match", sell, 100, 1)
+	match.AccountId = "alice"
+	match.CurrencyPair = "ETH/USDT"
+	match.CreationDate = 1
+	orders := []models.OrderModel{match}
+
+	for i := 0; i < 25; i++ {
+		orderInfo := testOrder(fmt.Sprintf("other-%02d", i), sell, 100, 1)
+		orderInfo.AccountId = "alice"
+		orderInfo.CreationDate = int64(i + 2)
+		orders = append(orders, orderInfo)
+	}
+
+	for _, orderInfo := range orders {
+		if err := env.orderStorage.AddOrderToStorage(ctx, orderInfo); err != nil {
+			t.Fatalf("AddOrderToStorage() error = %v", err)
+		}
+	}
+
+	request := &models.AccountOrdersRequest{AccountId: "alice", CurrencyPair: "ETH/USDT", Limit: 1}
+	got, cursor, err := q.GetAccountOrders(ctx, request)
+
+	if err != nil || len(got) != 0 || cursor == "" {
+		t.Fatalf("GetAccountOrders() = %v, %q, %v, want an empty page with a cursor", got, cursor, err)
+	}
+
+	request.Cursor = cursor
+	got, cursor, err = q.GetAccountOrders(ctx, request)
+
+	if err != nil || len(got) != 1 || got[0].OrderId != "match" {
+		t.Fatalf("GetAccountOrders() = %v, %v, want the matching order", got, err)
+	}
+
+	request.Cursor = cursor
+
+	if got, cursor, err = q.GetAccountOrders(ctx, request); err != nil || len(got) != 0 || cursor != "" {
+		t.Errorf("GetAccountOrders() = %v, %q, %v, want the end of the listing", got, cursor, err)
+	}
+}
+
+func TestOrderQueryService_GetOrdersByState(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	q := NewOrderQueryService(env.orderStorage, env.messageSender)
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	now := time.Now().UTC()
+	withState := func(id string, state ops.OpsOrderState, since time.Duration) models.OrderModel {
+		orderInfo := testOrder(id, sell, 100, 1)
+		orderInfo.State = int(state)
+		orderInfo.UpdatedDate = now.Add(-since).UnixMilli()
+		return orderInfo
+	}
+
+	for _, orderInfo := range []models.OrderModel{
+		withState("stuck", ops.OpsOrderState_OPS_ORDER_STATE_NEW, 10*time.Minute),
+		withState("fresh", ops.OpsOrderState_OPS_ORDER_STATE_NEW, time.Minute),
+		withState("approved", ops.OpsOrderState_OPS_ORDER_STATE_APPROVED, time.Hour),
+	} {
+		if err := env.orderStorage.AddOrderToStorage(ctx, orderInfo); err != nil {
+			t.Fatalf("AddOrderToStorage() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		minAge time.Duration
+		limit  int64
+		want   []string
+	}{
+		{name: "orders older than the age", minAge: 5 * time.Minute, want: []string{"stuck"}},
+		{name: "every order of the state, the oldest first", minAge: 0, want: []string{"stuck", "fresh"}},
+		{name: "limit keeps the oldest", minAge: 0, limit: 1, want: []string{"stuck"}},
+		{name: "no order is old enough", minAge: time.Hour, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders, err := q.GetOrdersByState(ctx, int(ops.OpsOrderState_OPS_ORDER_STATE_NEW), tt.minAge, tt.limit)
+
+			if err != nil {
+				t.Fatalf("GetOrdersByState() error = %v", err)
+			}
+
+			got := make([]string, 0, len(orders))
+
+			for _, entry := range orders {
+				got = append(got, entry.Order.OrderId)
+
+				if entry.Age < tt.minAge.Milliseconds() {
+					t.Errorf("GetOrdersByState() age of %v = %v, want at least %v", entry.Order.OrderId, entry.Age, tt.minAge)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetOrdersByState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"trade-order-processing-service/external/bps"
 	"trade-order-processing-service/external/ops"
 	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
 	"trade-order-processing-service/utils"
 
 	"github.com/google/uuid"
@@ -14,74 +15,227 @@ import (
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
+// stockBookWalkDepth bounds the levels read to estimate a market buy lock, the volume left after them
+// is priced at the protection price, so a deeper book only makes the lock larger than needed.
+const stockBookWalkDepth = 100
+
 type iOrderStorage interface {
 	AddOrderToStorage(ctx context.Context, orderInfo models.OrderModel) error
 	GetOrderFromStorage(ctx context.Context, id string) (*models.OrderModel, error)
+	GetOrdersFromStorage(ctx context.Context, ids []string) ([]models.OrderModel, error)
 	UpdateOrderInfo(ctx context.Context, orderInfo models.OrderModel) error
+	UpdateOrdersInfo(ctx context.Context, ordersInfo ...models.OrderModel) error
+	CloseOrder(ctx context.Context, eventType string, orderInfo models.OrderModel) error
 	DeleteOrderFromStorage(ctx context.Context, id string) error
+	AppendOrderEvent(ctx context.Context, eventType string, orderInfo models.OrderModel) error
+	ReadOrderEvents(ctx context.Context, afterId string, count int64) ([]models.OrderLogEventModel, error)
+	RebuildOrder(ctx context.Context, orderInfo models.OrderModel, booked bool) error
+	AppendOrderAudit(ctx context.Context, orderId string, entry models.AuditEntryModel) error
+	GetAudit(ctx context.Context, correlationId string) ([]models.AuditEntryModel, error)
+	GetAccountOrderIndex(ctx context.Context, accountId string, state *int, fromDate, toDate int64, offset, count int64) ([]models.OrderIndexEntry, error)
+	GetStateOrderIndex(ctx context.Context, state int, toDate int64, offset, count int64) ([]models.OrderIndexEntry, error)
 	AddInStockBook(ctx context.Context, orderInfo models.OrderModel) error
-	DropFromStockBook(ctx context.Context, orderInfo models.OrderModel) error
+	CloseBookedOrder(ctx context.Context, eventType string, orderInfo models.OrderModel) error
+	ExecuteInStockBook(ctx context.Context, bookedOrder, orderInfo models.OrderModel) error
+	ReadOrderFeed(ctx context.Context, count int64) ([]models.OrderEventModel, string, error)
+	AckOrderFeed(ctx context.Context, lastId string) error
+	GetOrderFeedBook(ctx context.Context, currencyPair string) ([]models.OrderEventModel, int64, error)
 	TryLockOrder(ctx context.Context, id string, guid string) error
 	TryUnlockOrder(ctx context.Context, id string, guid string) error
-	GetStockPriceByCurrencyPairAndDirection(ctx context.Context, currencyPair string, direction int) (float64, error)
+	GetBestStockLevel(ctx context.Context, currencyPair string, direction int) (*models.PriceLevel, error)
 	GetOrdersForMatch(ctx context.Context, id string) ([]string, error)
+	GetStockBookLevels(ctx context.Context, currencyPair string, direction int, count int64) ([]models.PriceLevel, error)
+	GetStockBookLevel(ctx context.Context, currencyPair string, direction int, price float64) (float64, error)
+	GetBookOrders(ctx context.Context, currencyPair string, direction int) ([]models.OrderModel, error)
+	GetBookOrderIds(ctx context.Context, accountId string, currencyPair string) ([]string, error)
+	IsInStockBook(ctx context.Context, orderInfo models.OrderModel) (bool, error)
+}
+
+type iMessageSender interface {
+	SendMessage(ctx context.Context, message protoreflect.ProtoMessage, exchange, rk string) error
+	SendJsonMessage(ctx context.Context, message interface{}, exchange, rk string) error
+}
+
+type iTradeStorage interface {
+	AddTradeToStorage(ctx context.Context, tradeInfo models.TradeModel) error
+}
+
+type iInstrumentStorage interface {
+	GetInstrumentSettings(ctx context.Context, currencyPair string) (*models.InstrumentSettings, error)
 }
 
 type iTicketStorage interface {
 	AddNewTicket(ctx context.Context, operationType ops.OpsTicketOperation, ticketData protoreflect.ProtoMessage) error
 }
 
+type iAccountOrdersStorage interface {
+	IsHiddenOrdersAccount(ctx context.Context, accountId string) (bool, error)
+}
+
 type OrderService struct {
-	orderStorage  iOrderStorage
-	ticketStorage iTicketStorage
+	orderStorage      iOrderStorage
+	ticketStorage     iTicketStorage
+	instrumentStorage iInstrumentStorage
+	accountStorage    iAccountOrdersStorage
+	riskService       *RiskService
+	marketService     *MarketService
+	killSwitchService *KillSwitchService
+	rateLimiter       *RateLimiter
 }
 
-func NewOrderService(orderStorage iOrderStorage, ticketStorage iTicketStorage) *OrderService {
-	return &OrderService{orderStorage: orderStorage, ticketStorage: ticketStorage}
+type stockBookWalk struct {
+	volume   float64
+	notional float64
 }
 
-func (o *OrderService) CreateOrder(ctx context.Context, request *ops.OpsCreateOrderRequest) {
-	orderId := uuid.NewString()
+func NewOrderService(orderStorage iOrderStorage, ticketStorage iTicketStorage, instrumentStorage iInstrumentStorage, accountStorage iAccountOrdersStorage, riskService *RiskService, marketService *MarketService, killSwitchService *KillSwitchService, rateLimiter *RateLimiter) *OrderService {
+	return &OrderService{
+		orderStorage:      orderStorage,
+		ticketStorage:     ticketStorage,
+		instrumentStorage: instrumentStorage,
+		accountStorage:    accountStorage,
+		riskService:       riskService,
+		marketService:     marketService,
+		killSwitchService: killSwitchService,
+		rateLimiter:       rateLimiter,
+	}
+}
 
-	orderInfo := models.OrderModel{
-		OrderId:      orderId,
-		AccountId:    request.AccountId,
-		AssetId:      request.AssetId,
-		CurrencyPair: request.CurrencyPair,
-		Direction:    int(request.Direction),
-		LimitPrice:   request.LimitPrice,
-		AskVolume:    request.AskVolume,
-		Type:         int(request.Type),
-		CreationDate: time.Now().UTC().UnixMilli(),
-		UpdatedDate:  time.Now().UTC().UnixMilli(),
-		State:        int(ops.OpsOrderState_OPS_ORDER_STATE_NEW),
+// AllowCreateOrder guards the intake listener, requests over the rate limit are rejected with RateLimitExceeded
+// and dead-lettered before the order is stored. The limiter fails open when redis is unavailable, those requests
+// are counted in rate_limit_fail_open.
+func (o *OrderService) AllowCreateOrder(ctx context.Context, request *ops.OpsCreateOrderRequest) bool {
+	allowed, err := o.rateLimiter.Allow(ctx, request.AccountId, request.CurrencyPair)
+
+	if err != nil {
+		rateLimitFailOpen.Add(1)
+		logrus.WithField("requestId", request.Id).Errorln("Fail check rate limit, reason: ", err.Error())
+		return true
+	}
+
+	if !allowed {
+		logrus.WithFields(logrus.Fields{
+			"requestId": request.Id,
+			"accountId": request.AccountId}).Warningln("Rate limit exceeded, reject order request")
+		o.rejectOrderCreation(ctx, buildOrderModel(request), utils.MapErrorToOpsError(staticerr.ErrorRateLimitExceeded))
 	}
 
+	return allowed
+}
+
+func (o *OrderService) CreateOrder(ctx context.Context, request *ops.OpsCreateOrderRequest) {
+	orderInfo := buildOrderModel(request)
+	orderId := orderInfo.OrderId
+
 	logrus.WithField("requestId", request.Id).Infoln("Order id for this request: ", orderId)
 
-	if err := o.enrichMarketOrderStockPrice(ctx, &orderInfo); err != nil {
-		logrus.WithField("orderId", orderId).Errorln("Fail enrich market order stockPrice, reason: ", err.Error())
+	blocked, err := o.killSwitchService.IsAccountBlocked(ctx, request.AccountId)
+
+	if err != nil {
+		logrus.WithField("orderId", orderId).Errorln("Fail get kill switch, reason: ", err.Error())
+		return
 	}
 
-	if err := o.orderStorage.AddOrderToStorage(ctx, orderInfo); err != nil {
-		logrus.WithField("orderId", orderId).Errorln("Creation order failed, reason: ", err.Error())
+	if blocked {
+		logrus.WithField("orderId", orderId).Warningln("Account is blocked by kill switch, reject order")
+		o.rejectOrderCreation(ctx, orderInfo, utils.MapErrorToOpsError(staticerr.ErrorAccountIsBlocked))
 		return
 	}
 
-	logrus.WithField("orderId", orderId).Errorln("Creation order successfully")
+	if orderInfo.Type == int(ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT) {
+		if orderInfo.Hidden, err = o.accountStorage.IsHiddenOrdersAccount(ctx, request.AccountId); err != nil {
+			logrus.WithField("orderId", orderId).Errorln("Fail get account hidden orders, reason: ", err.Error())
+			return
+		}
+	}
 
-	if err := o.ticketStorage.AddNewTicket(ctx, ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION, utils.MapOrderInfoToProto(orderInfo)); err != nil {
-		logrus.WithField("orderId", orderId).Errorln("Fail save ticket for lock, reason: ", err.Error())
+	settings, err := o.instrumentStorage.GetInstrumentSettings(ctx, request.CurrencyPair)
+
+	if err != nil {
+		logrus.WithField("orderId", orderId).Errorln("Fail get instrument settings, reason: ", err.Error())
+		return
+	}
+
+	status, err := o.marketService.GetPairStatus(ctx, request.CurrencyPair)
+
+	if err != nil {
+		logrus.WithField("orderId", orderId).Errorln("Fail get pair status, reason: ", err.Error())
+		return
+	}
+
+	if status.Status == models.PairStatusHalted {
+		if settings.PriceBands.QueueWhenHalted {
+			logrus.WithField("requestId", request.Id).Infoln("Trading is halted, queue order request")
+			if err = o.marketService.QueueOrderRequest(ctx, request); err != nil {
+				logrus.WithField("requestId", request.Id).Errorln("Fail queue order request, reason: ", err.Error())
+			}
+			return
+		}
 
+		logrus.WithField("orderId", orderId).Infoln("Trading is halted, reject order")
+		o.rejectOrderCreation(ctx, orderInfo, utils.MapErrorToOpsError(staticerr.ErrorTradingHalted))
+		return
+	}
+
+	if !isOrderAcceptedInStatus(*status, orderInfo) || !isWithinTradingSession(settings.Session, time.Now()) {
+		logrus.WithField("orderId", orderId).Infoln("Pair is not open, reject order")
+		o.rejectOrderCreation(ctx, orderInfo, utils.MapErrorToOpsError(staticerr.ErrorPairIsNotOpen))
+		return
 	}
 
-	lockAmount, err := o.calculateLockAmount(ctx, orderInfo)
+	// OpsCreateOrderRequest carries no slippage field, the bound is per instrument until trade-protos gains one.
+	orderInfo.MaxSlippageBps = settings.MaxSlippageBps
+
+	if err = o.enrichMarketOrderStockPrice(ctx, &orderInfo); err != nil {
+		logrus.WithField("orderId", orderId).Errorln("Fail enrich market order stockPrice, reason: ", err.Error())
+		o.rejectOrderCreation(ctx, orderInfo, utils.MapErrorToOpsError(err))
+		return
+	}
+
+	if err = o.marketService.ValidatePriceBand(ctx, orderInfo, settings.PriceBands); err != nil {
+		logrus.WithField("orderId", orderId).Infoln("Order failed price band check, reason: ", err.Error())
+		o.rejectOrderCreation(ctx, orderInfo, utils.MapErrorToOpsError(err))
+		return
+	}
+
+	if err = o.riskService.CheckOrder(orderInfo, settings.RiskLimits); err != nil {
+		logrus.WithField("orderId", orderId).Infoln("Order failed risk checks, reason: ", err.Error())
+		o.rejectOrderCreation(ctx, orderInfo, utils.MapErrorToOpsError(err))
+		return
+	}
+
+	lockAmount, err := o.calculateLockAmount(ctx, orderInfo, *settings)
 
 	if err != nil {
 		logrus.WithField("orderId", orderId).Errorln("Lock balance order failed, reason: ", err.Error())
 		return
 	}
 
+	orderInfo.LockedAmount = lockAmount
+
+	if err := o.orderStorage.AddOrderToStorage(ctx, orderInfo); err != nil {
+		logrus.WithField("orderId", orderId).Errorln("Creation order failed, reason: ", err.Error())
+		return
+	}
+
+	if err = o.riskService.CheckExposure(ctx, orderInfo, settings.RiskLimits); err != nil {
+		logrus.WithField("orderId", orderId).Infoln("Order failed exposure checks, reason: ", err.Error())
+
+		if err := o.orderStorage.DeleteOrderFromStorage(ctx, orderId); err != nil {
+			logrus.WithField("orderId", orderId).Errorln("Fail release order exposure, reason: ", err.Error())
+		}
+
+		o.rejectOrderCreation(ctx, orderInfo, utils.MapErrorToOpsError(err))
+		return
+	}
+
+	logrus.WithField("orderId", orderId).Infoln("Creation order successfully")
+
+	if err := o.ticketStorage.AddNewTicket(ctx, ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION, utils.MapOrderInfoToProto(orderInfo)); err != nil {
+		logrus.WithField("orderId", orderId).Errorln("Fail save ticket for lock, reason: ", err.Error())
+
+	}
+
 	err = o.ticketStorage.AddNewTicket(ctx, ops.OpsTicketOperation_OPS_TICKET_OPERATION_LOCK_BALANCE, &bps.BpsLockBalanceRequest{
 		Id:           orderId,
 		AssetId:      request.AssetId,
@@ -96,10 +250,33 @@ func (o *OrderService) CreateOrder(ctx context.Context, request *ops.OpsCreateOr
 	}
 }
 
+func (o *OrderService) rejectOrderCreation(ctx context.Context, orderInfo models.OrderModel, cause *ops.OpsError) {
+	orderInfo.State = int(ops.OpsOrderState_OPS_ORDER_STATE_REJECTED)
+	orderInfo.UpdatedDate = time.Now().UTC().UnixMilli()
+
+	if err := o.orderStorage.AppendOrderEvent(ctx, models.OrderLogRejected, orderInfo); err != nil {
+		logrus.WithField("orderId", orderInfo.OrderId).Errorln("Fail log order event, reason: ", err.Error())
+	}
+
+	protoModel := utils.MapOrderInfoToProto(orderInfo)
+	protoModel.Cause = cause
+
+	if err := o.ticketStorage.AddNewTicket(ctx, ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION, protoModel); err != nil {
+		logrus.WithField("orderId", orderInfo.OrderId).Errorln("Internal error: ", err.Error())
+		return
+	}
+
+	logrus.WithField("orderId", orderInfo.OrderId).Infoln("Order is rejected, send notification: ")
+}
+
 func (s *OrderService) ApproveOrderCreation(ctx context.Context, request *bps.BpsLockBalanceResponse) {
 
 	logrus.WithField("orderId", request.Id).Infoln("Received response from bps, lockBalance: ", request.String())
 
+	if err := s.orderStorage.AppendOrderAudit(ctx, request.Id, newAuditEntry(models.AuditSourceBps, models.AuditTypeLockResponse, request.Id, request)); err != nil {
+		logrus.WithField("orderId", request.Id).Errorln("Fail audit lock response, reason: ", err.Error())
+	}
+
 	orderInfo, err := s.orderStorage.GetOrderFromStorage(ctx, request.Id)
 
 	if err != nil {
@@ -112,6 +289,10 @@ func (s *OrderService) ApproveOrderCreation(ctx context.Context, request *bps.Bp
 		orderInfo.State = int(ops.OpsOrderState_OPS_ORDER_STATE_REJECTED)
 		orderInfo.UpdatedDate = time.Now().UTC().UnixMilli()
 
+		if err = s.orderStorage.AppendOrderEvent(ctx, models.OrderLogRejected, *orderInfo); err != nil {
+			logrus.WithField("orderId", request.Id).Errorln("Fail log order event, reason: ", err.Error())
+		}
+
 		if err = s.orderStorage.DeleteOrderFromStorage(ctx, orderInfo.OrderId); err != nil {
 			logrus.WithField("orderId", request.Id).Errorln("Internal error: ", err.Error())
 			return
@@ -152,24 +333,85 @@ func (s *OrderService) ApproveOrderCreation(ctx context.Context, request *bps.Bp
 
 }
 
-func (s *OrderService) calculateLockAmount(ctx context.Context, model models.OrderModel) (float64, error) {
+func (s *OrderService) calculateLockAmount(ctx context.Context, model models.OrderModel, settings models.InstrumentSettings) (float64, error) {
 
 	if model.Direction == int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL) {
 		return model.AskVolume, nil
 	}
 
-	return model.LimitPrice * model.AskVolume, nil
+	if model.Type == int(ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT) {
+		return model.LimitPrice * model.AskVolume, nil
+	}
+
+	levels, err := s.orderStorage.GetStockBookLevels(ctx, model.CurrencyPair, utils.GetDirectionForBuildMatchingIndex(model.Direction), stockBookWalkDepth)
+
+	if err != nil {
+		return 0, err
+	}
+
+	walk := walkStockBook(levels, model.AskVolume, model.LimitPrice, model.Direction)
+	estimatedAmount := walk.notional + (model.AskVolume-walk.volume)*model.LimitPrice
 
+	return utils.Min(estimatedAmount*(1+settings.LockBufferBps/utils.BpsDenominator), model.LimitPrice*model.AskVolume), nil
 }
 
 func (s *OrderService) enrichMarketOrderStockPrice(ctx context.Context, model *models.OrderModel) error {
-	var err error
-	if model.Type == int(ops.OpsOrderType_OPS_ORDER_TYPE_MARKET) {
-		model.LimitPrice, err = s.orderStorage.GetStockPriceByCurrencyPairAndDirection(ctx, model.CurrencyPair, model.Direction)
+	if model.Type != int(ops.OpsOrderType_OPS_ORDER_TYPE_MARKET) {
+		return nil
+	}
 
-		if err != nil {
-			return err
-		}
+	levels, err := s.orderStorage.GetStockBookLevels(ctx, model.CurrencyPair, utils.GetDirectionForBuildMatchingIndex(model.Direction), 1)
+
+	if err != nil {
+		return err
 	}
+
+	model.LimitPrice = utils.GetProtectionPrice(levels[0].Price, model.MaxSlippageBps, model.Direction)
+
 	return nil
 }
+
+// walkStockBook consumes opposite side price levels (sorted best first) until the volume
+// is covered or the next level is worse than the protection price.
+func walkStockBook(levels []models.PriceLevel, volume float64, protectionPrice float64, direction int) stockBookWalk {
+	walk := stockBookWalk{}
+
+	for _, level := range levels {
+		if walk.volume >= volume {
+			break
+		}
+
+		if direction == int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY) && level.Price > protectionPrice {
+			break
+		}
+
+		if direction == int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL) && level.Price < protectionPrice {
+			break
+		}
+
+		levelVolume := utils.Min(level.Volume, volume-walk.volume)
+
+		walk.volume += levelVolume
+		walk.notional += levelVolume * level.Price
+	}
+
+	return walk
+}
+
+// buildOrderModel leaves Hidden unset, OpsCreateOrderRequest carries no hidden flag so CreateOrder
+// hides the limit orders of the accounts in accounts:hidden_orders.
+func buildOrderModel(request *ops.OpsCreateOrderRequest) models.OrderModel {
+	return models.OrderModel{
+		OrderId:      uuid.NewString(),
+		AccountId:    request.AccountId,
+		AssetId:      request.AssetId,
+		CurrencyPair: request.CurrencyPair,
+		Direction:    int(request.Direction),
+		LimitPrice:   request.LimitPrice,
+		AskVolume:    request.AskVolume,
+		Type:         int(request.Type),
+		CreationDate: time.Now().UTC().UnixMilli(),
+		UpdatedDate:  time.Now().UTC().UnixMilli(),
+		State:        int(ops.OpsOrderState_OPS_ORDER_STATE_NEW),
+	}
+}
@@ -2,8 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
-	"trade-order-processing-service/external/OPS"
+	"trade-order-processing-service/external/bps"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/utils"
 )
 
 func TestOrderService_CreateOrder(t *testing.T) {
@@ -13,7 +18,7 @@ func TestOrderService_CreateOrder(t *testing.T) {
 	}
 	type args struct {
 		ctx     context.Context
-		request *OPS.OpsCreateOrderRequest
+		request *ops.OpsCreateOrderRequest
 	}
 	tests := []struct {
 		name   string
@@ -32,3 +37,104 @@ func TestOrderService_CreateOrder(t *testing.T) {
 		})
 	}
 }
+
+func Test_walkStockBook(t *testing.T) {
+	asks := []models.PriceLevel{
+		{Price: 100, Volume: 1},
+		{Price: 101, Volume: 2},
+		{Price: 105, Volume: 5},
+	}
+	type args struct {
+		levels          []models.PriceLevel
+		volume          float64
+		protectionPrice float64
+		direction       int
+	}
+	tests := []struct {
+		name string
+		args args
+		want stockBookWalk
+	}{
+		{
+			name: "buy covered by first level",
+			args: args{levels: asks, volume: 0.5, protectionPrice: 110, direction: int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY)},
+			want: stockBookWalk{volume: 0.5, notional: 50},
+		},
+		{
+			name: "buy walks several levels",
+			args: args{levels: asks, volume: 4, protectionPrice: 110, direction: int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY)},
+			want: stockBookWalk{volume: 4, notional: 100 + 202 + 105},
+		},
+		{
+			name: "buy stops at protection price",
+			args: args{levels: asks, volume: 10, protectionPrice: 102, direction: int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY)},
+			want: stockBookWalk{volume: 3, notional: 302},
+		},
+		{
+			name: "sell stops at protection price",
+			args: args{
+				levels:          []models.PriceLevel{{Price: 100, Volume: 1}, {Price: 90, Volume: 1}},
+				volume:          2,
+				protectionPrice: 95,
+				direction:       int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL),
+			},
+			want: stockBookWalk{volume: 1, notional: 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := walkStockBook(tt.args.levels, tt.args.volume, tt.args.protectionPrice, tt.args.direction); got != tt.want {
+				t.Errorf("walkStockBook() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderService_CreateOrder_hiddenAccount(t *testing.T) {
+	tests := []struct {
+		name       string
+		hidden     bool
+		wantHidden bool
+	}{
+		{name: "limit order of a hidden orders account is hidden", hidden: true, wantHidden: true},
+		{name: "limit order of other accounts is displayed", hidden: false, wantHidden: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+
+			if err := env.accountStorage.SetHiddenOrdersAccount(ctx, "institution", tt.hidden); err != nil {
+				t.Fatalf("SetHiddenOrdersAccount() error = %v", err)
+			}
+
+			env.orderService.CreateOrder(ctx, &ops.OpsCreateOrderRequest{
+				Id: "request", AccountId: "institution", CurrencyPair: "BTC/USDT", Direction: ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL,
+				LimitPrice: 100, AskVolume: 1, Type: ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT,
+			})
+
+			created := env.drainTickets(t, ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION)
+
+			if len(created) != 1 {
+				t.Fatalf("created %d orders, want 1", len(created))
+			}
+
+			env.orderService.ApproveOrderCreation(ctx, &bps.BpsLockBalanceResponse{Id: created[0].GetOrderId()})
+			orderInfo := env.getOrder(t, created[0].GetOrderId())
+			env.matcherService.MatchOrder(ctx, utils.MapOrderInfoToProto(orderInfo))
+			orderInfo = env.getOrder(t, orderInfo.OrderId)
+
+			if orderInfo.Hidden != tt.wantHidden {
+				t.Errorf("order Hidden = %v, want %v", orderInfo.Hidden, tt.wantHidden)
+			}
+
+			if booked, _ := env.orderStorage.IsInStockBook(ctx, orderInfo); !booked {
+				t.Fatalf("order is not booked")
+			}
+
+			if _, err := env.orderStorage.GetStockBookLevels(ctx, "BTC/USDT", orderInfo.Direction, 0); errors.Is(err, staticerr.ErrorStockBookIsEmpty) != tt.wantHidden {
+				t.Errorf("GetStockBookLevels() error = %v, want the order displayed only when not hidden", err)
+			}
+		})
+	}
+}
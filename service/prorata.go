@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/utils"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// matchProRata fills the order level by level, splitting each best price level across its resting orders.
+func (m *MatcherService) matchProRata(ctx context.Context, orderModel *models.OrderModel, orders []string, lotSize float64) error {
+	candidates, err := m.orderStorage.GetOrdersFromStorage(ctx, orders)
+
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(candidates) && utils.GetRemainingVolume(*orderModel) > 0; {
+		end := start
+		for end < len(candidates) && candidates[end].LimitPrice == candidates[start].LimitPrice {
+			end++
+		}
+
+		if err = m.matchProRataLevel(ctx, orderModel, candidates[start:end], lotSize); err != nil {
+			return err
+		}
+
+		start = end
+	}
+
+	return nil
+}
+
+func (m *MatcherService) matchProRataLevel(ctx context.Context, orderModel *models.OrderModel, level []models.OrderModel, lotSize float64) error {
+	lockId := uuid.NewString()
+	resting := make([]models.OrderModel, 0, len(level))
+
+	for _, candidate := range level {
+		if err := m.orderStorage.TryLockOrder(ctx, candidate.OrderId, lockId); err != nil {
+			logrus.WithFields(logrus.Fields{
+				"orderId":        orderModel.OrderId,
+				"matchedOrderId": candidate.OrderId}).Warningln("MatchedOrderId is locked, skipping...")
+			continue
+		}
+
+		defer m.unlockOrder(ctx, candidate.OrderId, lockId)
+
+		matchingOrderInfo, err := m.orderStorage.GetOrderFromStorage(ctx, candidate.OrderId)
+
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"orderId":        orderModel.OrderId,
+				"matchedOrderId": candidate.OrderId}).Warningln("Internal error, skip this order...")
+			continue
+		}
+
+		if m.isMakerBlocked(ctx, *matchingOrderInfo) {
+			continue
+		}
+
+		resting = append(resting, *matchingOrderInfo)
+	}
+
+	sort.SliceStable(resting, func(i, j int) bool {
+		return resting[i].CreationDate < resting[j].CreationDate
+	})
+
+	displayed, hidden := splitHiddenOrders(resting)
+
+	for _, group := range [][]models.OrderModel{displayed, hidden} {
+		allocations := allocateProRata(utils.GetRemainingVolume(*orderModel), group, lotSize)
+
+		for i := range group {
+			if allocations[i] < utils.VolumeEpsilon {
+				continue
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"orderId":        orderModel.OrderId,
+				"matchedOrderId": group[i].OrderId}).Infoln("Pro-rata allocation: ", allocations[i])
+
+			if err := m.performMatchingOrders(ctx, orderModel, &group[i], group[i].LimitPrice, allocations[i], false); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// splitHiddenOrders separates displayed orders from hidden ones, hidden orders only get what displayed left.
+func splitHiddenOrders(orders []models.OrderModel) ([]models.OrderModel, []models.OrderModel) {
+	displayed := make([]models.OrderModel, 0, len(orders))
+	hidden := make([]models.OrderModel, 0)
+
+	for _, oInfo := range orders {
+		if oInfo.Hidden {
+			hidden = append(hidden, oInfo)
+			continue
+		}
+
+		displayed = append(displayed, oInfo)
+	}
+
+	return displayed, hidden
+}
+
+// allocateProRata splits the volume across resting orders (sorted by time priority) in proportion
+// to their remaining size, rounded down to the lot size; the remainder goes FIFO in whole lots and
+// a leftover smaller than one lot is not allocated.
+func allocateProRata(volume float64, resting []models.OrderModel, lotSize float64) []float64 {
+	allocations := make([]float64, len(resting))
+	total := 0.0
+
+	for _, oInfo := range resting {
+		total += utils.GetRemainingVolume(oInfo)
+	}
+
+	if total < utils.VolumeEpsilon {
+		return allocations
+	}
+
+	volume = utils.Min(volume, total)
+	leftover := volume
+
+	for i, oInfo := range resting {
+		allocations[i] = roundDownToLot(volume*utils.GetRemainingVolume(oInfo)/total, lotSize)
+		leftover -= allocations[i]
+	}
+
+	for i, oInfo := range resting {
+		if leftover < utils.VolumeEpsilon {
+			break
+		}
+
+		extra := roundDownToLot(utils.Min(utils.GetRemainingVolume(oInfo)-allocations[i], leftover), lotSize)
+
+		allocations[i] += extra
+		leftover -= extra
+	}
+
+	return allocations
+}
+
+func roundDownToLot(volume float64, lotSize float64) float64 {
+	if lotSize <= 0 {
+		return volume
+	}
+
+	return math.Floor(volume/lotSize+utils.VolumeEpsilon) * lotSize
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"trade-order-processing-service/models"
+)
+
+func Test_allocateProRata(t *testing.T) {
+	resting := func(volumes ...float64) []models.OrderModel {
+		orders := make([]models.OrderModel, 0, len(volumes))
+		for _, volume := range volumes {
+			orders = append(orders, models.OrderModel{AskVolume: volume})
+		}
+		return orders
+	}
+	tests := []struct {
+		name    string
+		volume  float64
+		resting []models.OrderModel
+		lotSize float64
+		want    []float64
+	}{
+		{
+			name:    "proportional split",
+			volume:  10,
+			resting: resting(10, 30),
+			want:    []float64{2.5, 7.5},
+		},
+		{
+			name:    "lot rounding with fifo remainder",
+			volume:  10,
+			resting: resting(10, 10, 10),
+			lotSize: 1,
+			want:    []float64{4, 3, 3},
+		},
+		{
+			name:    "volume larger than level",
+			volume:  50,
+			resting: resting(5, 15),
+			lotSize: 1,
+			want:    []float64{5, 15},
+		},
+		{
+			name:    "fifo remainder respects order size",
+			volume:  5,
+			resting: resting(1, 100),
+			lotSize: 1,
+			want:    []float64{1, 4},
+		},
+		{
+			name:    "leftover smaller than one lot is not allocated",
+			volume:  10.5,
+			resting: resting(10, 10),
+			lotSize: 1,
+			want:    []float64{5, 5},
+		},
+		{
+			name:    "fifo remainder is allocated in whole lots",
+			volume:  3.5,
+			resting: resting(10, 10, 10),
+			lotSize: 0.5,
+			want:    []float64{1.5, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocateProRata(tt.volume, tt.resting, tt.lotSize)
+			for i := range tt.want {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("allocateProRata() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func Test_roundDownToLot(t *testing.T) {
+	tests := []struct {
+		name    string
+		volume  float64
+		lotSize float64
+		want    float64
+	}{
+		{name: "no lot size", volume: 2.75, want: 2.75},
+		{name: "rounded down to the lot", volume: 2.75, lotSize: 0.5, want: 2.5},
+		{name: "float noise does not drop a lot", volume: 0.3, lotSize: 0.1, want: 0.3},
+		{name: "less than one lot", volume: 0.4, lotSize: 1, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roundDownToLot(tt.volume, tt.lotSize); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("roundDownToLot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_splitHiddenOrders(t *testing.T) {
+	ids := func(orders []models.OrderModel) []string {
+		ids := make([]string, 0, len(orders))
+		for _, orderInfo := range orders {
+			ids = append(ids, orderInfo.OrderId)
+		}
+		return ids
+	}
+	orders := []models.OrderModel{
+		{OrderId: "displayed-old"},
+		{OrderId: "hidden-old", Hidden: true},
+		{OrderId: "displayed-new"},
+		{OrderId: "hidden-new", Hidden: true},
+	}
+
+	displayed, hidden := splitHiddenOrders(orders)
+
+	if got := ids(displayed); !reflect.DeepEqual(got, []string{"displayed-old", "displayed-new"}) {
+		t.Errorf("splitHiddenOrders() displayed = %v", got)
+	}
+
+	if got := ids(hidden); !reflect.DeepEqual(got, []string{"hidden-old", "hidden-new"}) {
+		t.Errorf("splitHiddenOrders() hidden = %v", got)
+	}
+}
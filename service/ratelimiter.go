@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"expvar"
+	"trade-order-processing-service/models"
+)
+
+// rateLimitFailOpen counts requests let through because the bucket could not be read.
+var rateLimitFailOpen = expvar.NewInt("rate_limit_fail_open")
+
+type iRateLimitStorage interface {
+	TakeToken(ctx context.Context, accountId string, ratePerSec float64, burst float64) (bool, error)
+}
+
+type iAccountRateLimitStorage interface {
+	GetAccountRateLimit(ctx context.Context, accountId string) (*models.RateLimitSettings, error)
+}
+
+type RateLimiter struct {
+	rateLimitStorage  iRateLimitStorage
+	instrumentStorage iInstrumentStorage
+	accountStorage    iAccountRateLimitStorage
+	settings          models.RateLimitSettings
+}
+
+func NewRateLimiter(rateLimitStorage iRateLimitStorage, instrumentStorage iInstrumentStorage, accountStorage iAccountRateLimitStorage, settings models.RateLimitSettings) *RateLimiter {
+	return &RateLimiter{rateLimitStorage: rateLimitStorage, instrumentStorage: instrumentStorage, accountStorage: accountStorage, settings: settings}
+}
+
+// Allow takes a token from the bucket of the account, the limit is resolved for the pair but the bucket is
+// shared by all pairs. A zero rate disables limiting.
+func (r *RateLimiter) Allow(ctx context.Context, accountId string, currencyPair string) (bool, error) {
+	settings, err := r.getSettings(ctx, accountId, currencyPair)
+
+	if err != nil {
+		return false, err
+	}
+
+	if settings.RatePerSec <= 0 {
+		return true, nil
+	}
+
+	burst := settings.Burst
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return r.rateLimitStorage.TakeToken(ctx, accountId, settings.RatePerSec, burst)
+}
+
+// getSettings prefers the account limit, then the instrument limit, then the service default.
+func (r *RateLimiter) getSettings(ctx context.Context, accountId string, currencyPair string) (models.RateLimitSettings, error) {
+	accountSettings, err := r.accountStorage.GetAccountRateLimit(ctx, accountId)
+
+	if err != nil {
+		return models.RateLimitSettings{}, err
+	}
+
+	if accountSettings != nil {
+		return *accountSettings, nil
+	}
+
+	instrumentSettings, err := r.instrumentStorage.GetInstrumentSettings(ctx, currencyPair)
+
+	if err != nil {
+		return models.RateLimitSettings{}, err
+	}
+
+	if instrumentSettings.RateLimit.RatePerSec > 0 {
+		return instrumentSettings.RateLimit, nil
+	}
+
+	return r.settings, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	type take struct {
+		currencyPair string
+		want         bool
+	}
+	btc := func(want bool) take { return take{currencyPair: "BTC/USDT", want: want} }
+	tests := []struct {
+		name       string
+		defaults   models.RateLimitSettings
+		instrument models.RateLimitSettings
+		account    *models.RateLimitSettings
+		takes      []take
+	}{
+		{name: "limiting disabled", takes: []take{btc(true), btc(true), btc(true)}},
+		{name: "service default", defaults: models.RateLimitSettings{RatePerSec: 0.001, Burst: 2}, takes: []take{btc(true), btc(true), btc(false)}},
+		{name: "burst defaults to one", defaults: models.RateLimitSettings{RatePerSec: 0.001}, takes: []take{btc(true), btc(false)}},
+		{
+			name:       "instrument limit overrides the default",
+			defaults:   models.RateLimitSettings{RatePerSec: 0.001, Burst: 5},
+			instrument: models.RateLimitSettings{RatePerSec: 0.001, Burst: 1},
+			takes:      []take{btc(true), btc(false)},
+		},
+		{
+			name:       "account limit overrides the instrument",
+			instrument: models.RateLimitSettings{RatePerSec: 0.001, Burst: 1},
+			account:    &models.RateLimitSettings{RatePerSec: 0.001, Burst: 3},
+			takes:      []take{btc(true), btc(true), btc(true), btc(false)},
+		},
+		{
+			name:     "pairs share the account bucket",
+			defaults: models.RateLimitSettings{RatePerSec: 0.001, Burst: 2},
+			takes:    []take{btc(true), {currencyPair: "ETH/USDT", want: true}, {currencyPair: "ETH/USDT", want: false}, btc(false)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv(t)
+			settings := models.NewDefaultInstrumentSettings("BTC/USDT")
+			settings.RateLimit = tt.instrument
+			env.setSettings(t, settings)
+
+			if tt.account != nil {
+				if err := env.accountStorage.SetAccountRateLimit(ctx, "account", *tt.account); err != nil {
+					t.Fatalf("SetAccountRateLimit() error = %v", err)
+				}
+			}
+
+			r := NewRateLimiter(env.rateLimitStorage, env.instrumentStorage, env.accountStorage, tt.defaults)
+			got := make([]bool, 0, len(tt.takes))
+			want := make([]bool, 0, len(tt.takes))
+
+			for _, take := range tt.takes {
+				allowed, err := r.Allow(ctx, "account", take.currencyPair)
+
+				if err != nil {
+					t.Fatalf("Allow() error = %v", err)
+				}
+
+				got = append(got, allowed)
+				want = append(want, take.want)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Allow() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestOrderService_AllowCreateOrder(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	settings := models.NewDefaultInstrumentSettings("BTC/USDT")
+	settings.RateLimit = models.RateLimitSettings{RatePerSec: 0.001, Burst: 1}
+	env.setSettings(t, settings)
+	request := &ops.OpsCreateOrderRequest{Id: "request", AccountId: "account", CurrencyPair: "BTC/USDT"}
+
+	tests := []struct {
+		name       string
+		want       bool
+		wantReject bool
+	}{
+		{name: "first request takes the burst", want: true},
+		{name: "flood is rejected", want: false, wantReject: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := env.orderService.AllowCreateOrder(ctx, request); got != tt.want {
+				t.Errorf("AllowCreateOrder() = %v, want %v", got, tt.want)
+			}
+
+			tickets := env.drainTickets(t, ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION)
+
+			if !tt.wantReject {
+				if len(tickets) != 0 {
+					t.Errorf("AllowCreateOrder() wrote %v tickets, want none", len(tickets))
+				}
+				return
+			}
+
+			if len(tickets) != 1 {
+				t.Fatalf("AllowCreateOrder() wrote %v tickets, want the rejection", len(tickets))
+			}
+
+			rejected := tickets[0]
+
+			if rejected.GetState() != ops.OpsOrderState_OPS_ORDER_STATE_REJECTED || rejected.GetCause().GetErrorCode() != staticerr.OpsErrorCodeRateLimitExceeded {
+				t.Errorf("rejection = %v, cause %v, want REJECTED with %v", rejected.GetState(), rejected.GetCause(), staticerr.OpsErrorCodeRateLimitExceeded)
+			}
+
+			if _, err := env.orderStorage.GetOrderFromStorage(ctx, rejected.GetOrderId()); err == nil {
+				t.Errorf("rejected order %v is stored", rejected.GetOrderId())
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/utils"
+)
+
+type iRiskStorage interface {
+	GetAccountExposure(ctx context.Context, accountId string, currencyPair string) (*models.AccountExposure, error)
+}
+
+type RiskService struct {
+	riskStorage iRiskStorage
+}
+
+func NewRiskService(riskStorage iRiskStorage) *RiskService {
+	return &RiskService{riskStorage: riskStorage}
+}
+
+// CheckOrder validates a new order against the per order risk limits, zero limit means unlimited.
+func (r *RiskService) CheckOrder(orderInfo models.OrderModel, limits models.RiskLimits) error {
+	if limits.MaxOrderNotional > 0 && orderInfo.LimitPrice*orderInfo.AskVolume > limits.MaxOrderNotional {
+		return staticerr.ErrorOrderNotionalLimitExceeded
+	}
+
+	return nil
+}
+
+// CheckExposure validates the account exposure once the order is stored, stored orders reserve their
+// exposure so concurrent orders of the account cannot pass the limits together.
+func (r *RiskService) CheckExposure(ctx context.Context, orderInfo models.OrderModel, limits models.RiskLimits) error {
+	if limits.MaxOpenOrders == 0 && limits.MaxOpenNotional == 0 {
+		return nil
+	}
+
+	exposure, err := r.riskStorage.GetAccountExposure(ctx, orderInfo.AccountId, orderInfo.CurrencyPair)
+
+	if err != nil {
+		return err
+	}
+
+	return checkAccountExposure(*exposure, limits)
+}
+
+// checkAccountExposure expects the exposure to include the checked order.
+func checkAccountExposure(exposure models.AccountExposure, limits models.RiskLimits) error {
+	if limits.MaxOpenOrders > 0 && exposure.OpenOrders > limits.MaxOpenOrders {
+		return staticerr.ErrorOpenOrdersLimitExceeded
+	}
+
+	if limits.MaxOpenNotional > 0 && exposure.OpenNotional > limits.MaxOpenNotional+utils.VolumeEpsilon {
+		return staticerr.ErrorOpenNotionalLimitExceeded
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"trade-order-processing-service/external/bps"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/utils"
+)
+
+func Test_checkAccountExposure(t *testing.T) {
+	limits := models.RiskLimits{MaxOpenOrders: 2, MaxOpenNotional: 1000}
+	tests := []struct {
+		name     string
+		exposure models.AccountExposure
+		limits   models.RiskLimits
+		wantErr  error
+	}{
+		{
+			name:     "within limits",
+			exposure: models.AccountExposure{OpenOrders: 2, OpenNotional: 1000},
+			limits:   limits,
+		},
+		{
+			name:     "too many open orders",
+			exposure: models.AccountExposure{OpenOrders: 3, OpenNotional: 1},
+			limits:   limits,
+			wantErr:  staticerr.ErrorOpenOrdersLimitExceeded,
+		},
+		{
+			name:     "open notional exceeded",
+			exposure: models.AccountExposure{OpenOrders: 2, OpenNotional: 1100},
+			limits:   limits,
+			wantErr:  staticerr.ErrorOpenNotionalLimitExceeded,
+		},
+		{
+			name:     "no limits configured",
+			exposure: models.AccountExposure{OpenOrders: 100, OpenNotional: 1e9},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkAccountExposure(tt.exposure, tt.limits); err != tt.wantErr {
+				t.Errorf("checkAccountExposure() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRiskService_CheckOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   models.OrderModel
+		limits  models.RiskLimits
+		wantErr error
+	}{
+		{
+			name:   "order notional within limit",
+			order:  models.OrderModel{LimitPrice: 100, AskVolume: 5},
+			limits: models.RiskLimits{MaxOrderNotional: 500},
+		},
+		{
+			name:    "order notional exceeded",
+			order:   models.OrderModel{LimitPrice: 100, AskVolume: 5.01},
+			limits:  models.RiskLimits{MaxOrderNotional: 500},
+			wantErr: staticerr.ErrorOrderNotionalLimitExceeded,
+		},
+		{
+			name:  "no order notional limit",
+			order: models.OrderModel{LimitPrice: 100, AskVolume: 1e9},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewRiskService(nil).CheckOrder(tt.order, tt.limits); err != tt.wantErr {
+				t.Errorf("CheckOrder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRiskService_exposureLifecycle(t *testing.T) {
+	const account = "alice"
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	buy := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY
+	create := func(env *testEnv, direction ops.OpsOrderDirection, price, volume float64) {
+		env.orderService.CreateOrder(context.Background(), &ops.OpsCreateOrderRequest{
+			Id: "request", AccountId: account, CurrencyPair: "BTC/USDT", Direction: direction,
+			LimitPrice: price, AskVolume: volume, Type: ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT,
+		})
+	}
+	accountOrders := func(t *testing.T, env *testEnv) []models.OrderModel {
+		entries, err := env.orderStorage.GetAccountOrderIndex(context.Background(), account, nil, 0, 0, 0, 100)
+		if err != nil {
+			t.Fatalf("GetAccountOrderIndex() error = %v", err)
+		}
+		ids := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, entry.OrderId)
+		}
+		orders, err := env.orderStorage.GetOrdersFromStorage(context.Background(), ids)
+		if err != nil {
+			t.Fatalf("GetOrdersFromStorage() error = %v", err)
+		}
+		return orders
+	}
+	approve := func(t *testing.T, env *testEnv, lockErr *bps.BpsError) models.OrderModel {
+		orders := accountOrders(t, env)
+		if len(orders) == 0 {
+			t.Fatalf("no order to approve")
+		}
+		env.orderService.ApproveOrderCreation(context.Background(), &bps.BpsLockBalanceResponse{Id: orders[0].OrderId, Error: lockErr})
+		if lockErr != nil {
+			return orders[0]
+		}
+		orderInfo := env.getOrder(t, orders[0].OrderId)
+		env.matcherService.MatchOrder(context.Background(), utils.MapOrderInfoToProto(orderInfo))
+		return env.getOrder(t, orderInfo.OrderId)
+	}
+	tests := []struct {
+		name         string
+		limits       models.RiskLimits
+		run          func(t *testing.T, env *testEnv)
+		wantExposure models.AccountExposure
+	}{
+		{
+			name: "order pending the lock is reserved",
+			run: func(t *testing.T, env *testEnv) {
+				create(env, buy, 100, 2)
+			},
+			wantExposure: models.AccountExposure{OpenOrders: 1, OpenNotional: 200},
+		},
+		{
+			name: "rejected lock releases",
+			run: func(t *testing.T, env *testEnv) {
+				create(env, buy, 100, 2)
+				approve(t, env, &bps.BpsError{Message: "not enough"})
+			},
+		},
+		{
+			name: "booked order keeps its reservation",
+			run: func(t *testing.T, env *testEnv) {
+				create(env, buy, 100, 2)
+				approve(t, env, nil)
+			},
+			wantExposure: models.AccountExposure{OpenOrders: 1, OpenNotional: 200},
+		},
+		{
+			name: "cancel releases",
+			run: func(t *testing.T, env *testEnv) {
+				create(env, buy, 100, 2)
+				orderInfo := approve(t, env, nil)
+				if err := env.cancelService.cancelOrder(context.Background(), account, orderInfo.OrderId); err != nil {
+					t.Fatalf("cancelOrder() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "partial fill releases the filled part, full fill the rest",
+			run: func(t *testing.T, env *testEnv) {
+				create(env, buy, 100, 2)
+				orderInfo := approve(t, env, nil)
+				for i, volume := range []float64{0.5, 1.5} {
+					taker := testOrder("taker"+string(rune('a'+i)), sell, 100, volume)
+					if err := env.orderStorage.AddOrderToStorage(context.Background(), taker); err != nil {
+						t.Fatalf("AddOrderToStorage() error = %v", err)
+					}
+					env.matcherService.MatchOrder(context.Background(), utils.MapOrderInfoToProto(taker))
+				}
+				if got := env.getOrder(t, orderInfo.OrderId); got.State != int(ops.OpsOrderState_OPS_ORDER_STATE_FILLED) {
+					t.Fatalf("order state = %v, want FILLED", got.State)
+				}
+			},
+		},
+		{
+			name:   "second order over the limit is rejected and released",
+			limits: models.RiskLimits{MaxOpenOrders: 1},
+			run: func(t *testing.T, env *testEnv) {
+				create(env, buy, 100, 2)
+				create(env, buy, 99, 1)
+				if orders := accountOrders(t, env); len(orders) != 1 {
+					t.Fatalf("account orders = %d, want 1", len(orders))
+				}
+			},
+			wantExposure: models.AccountExposure{OpenOrders: 1, OpenNotional: 200},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			settings := models.NewDefaultInstrumentSettings("BTC/USDT")
+			settings.RiskLimits = tt.limits
+			env.setSettings(t, settings)
+
+			tt.run(t, env)
+
+			got, err := env.riskStorage.GetAccountExposure(context.Background(), account, "BTC/USDT")
+			if err != nil {
+				t.Fatalf("GetAccountExposure() error = %v", err)
+			}
+			if got.OpenOrders != tt.wantExposure.OpenOrders || math.Abs(got.OpenNotional-tt.wantExposure.OpenNotional) > 1e-9 {
+				t.Errorf("GetAccountExposure() = %+v, want %+v", *got, tt.wantExposure)
+			}
+		})
+	}
+}
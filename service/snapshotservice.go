@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/utils"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type iSnapshotStorage interface {
+	SaveSnapshot(snapshot models.StockBookSnapshot) (string, error)
+	LoadSnapshot(path string) (*models.StockBookSnapshot, error)
+}
+
+type SnapshotService struct {
+	orderStorage    iOrderStorage
+	snapshotStorage iSnapshotStorage
+	ticketStorage   iTicketStorage
+}
+
+func NewSnapshotService(orderStorage iOrderStorage, snapshotStorage iSnapshotStorage, ticketStorage iTicketStorage) *SnapshotService {
+	return &SnapshotService{orderStorage: orderStorage, snapshotStorage: snapshotStorage, ticketStorage: ticketStorage}
+}
+
+func (s *SnapshotService) SaveStockBook(ctx context.Context, request *models.SaveStockBookRequest) {
+	logrus.WithField("operator", request.Operator).Infoln("Received save stock book request")
+
+	if err := s.saveStockBook(ctx); err != nil {
+		logrus.WithField("id", request.Id).Errorln("Fail save stock book, reason: ", err.Error())
+	}
+}
+
+// RunSnapshotScheduler saves the stock book of every pair periodically.
+func (s *SnapshotService) RunSnapshotScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.saveStockBook(ctx); err != nil {
+				logrus.Errorln("Fail save stock book, reason: ", err.Error())
+			}
+		}
+	}
+}
+
+func (s *SnapshotService) saveStockBook(ctx context.Context) error {
+	ids, err := s.orderStorage.GetBookOrderIds(ctx, "", "")
+
+	if err != nil {
+		return err
+	}
+
+	orders, err := s.orderStorage.GetOrdersFromStorage(ctx, ids)
+
+	if err != nil {
+		return err
+	}
+
+	snapshot := models.StockBookSnapshot{
+		Version:     models.StockBookSnapshotVersion,
+		CreatedDate: time.Now().UTC().UnixMilli(),
+		Books:       groupOrdersByPair(orders),
+	}
+
+	for i := range snapshot.Books {
+		book := &snapshot.Books[i]
+
+		if book.Bids, err = s.getBookLevels(ctx, book.CurrencyPair, int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY)); err != nil {
+			return err
+		}
+
+		if book.Asks, err = s.getBookLevels(ctx, book.CurrencyPair, int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL)); err != nil {
+			return err
+		}
+	}
+
+	path, err := s.snapshotStorage.SaveSnapshot(snapshot)
+
+	if err != nil {
+		return err
+	}
+
+	logrus.WithField("path", path).Infoln("Stock book is saved, orders: ", len(orders))
+
+	return s.ticketStorage.AddNewTicket(ctx, ops.OpsTicketOperation_OPS_TICKET_OPERATION_SAVE_STOCK_BOOK, wrapperspb.String(path))
+}
+
+// RestoreStockBook rebuilds orders, book indexes, depth and risk counters from a snapshot.
+// A non-empty book is only restored with force, orders still booked or changed since the snapshot are kept as they are.
+func (s *SnapshotService) RestoreStockBook(ctx context.Context, request *models.RestoreStockBookRequest) {
+	logrus.WithFields(logrus.Fields{
+		"path":     request.Path,
+		"operator": request.Operator}).Warningln("Received restore stock book request")
+
+	restored, err := s.restoreStockBook(ctx, request.Path, request.Force)
+
+	if err != nil {
+		logrus.WithField("path", request.Path).Errorln("Fail restore stock book, reason: ", err.Error())
+		return
+	}
+
+	logrus.WithField("path", request.Path).Infoln("Stock book is restored, orders: ", restored)
+}
+
+func (s *SnapshotService) restoreStockBook(ctx context.Context, path string, force bool) (int, error) {
+	snapshot, err := s.snapshotStorage.LoadSnapshot(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	ids, err := s.orderStorage.GetBookOrderIds(ctx, "", "")
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ids) > 0 && !force {
+		return 0, staticerr.ErrorStockBookIsNotEmpty
+	}
+
+	restored := 0
+
+	for _, book := range snapshot.Books {
+		for _, orderInfo := range book.Orders {
+			booked, err := s.orderStorage.IsInStockBook(ctx, orderInfo)
+
+			if err != nil {
+				return restored, err
+			}
+
+			if booked {
+				continue
+			}
+
+			stored, err := s.orderStorage.GetOrdersFromStorage(ctx, []string{orderInfo.OrderId})
+
+			if err != nil {
+				return restored, err
+			}
+
+			if len(stored) > 0 && isOrderChangedSinceSnapshot(stored[0], orderInfo) {
+				logrus.WithField("orderId", orderInfo.OrderId).Warningln("Skip restoring order changed after the snapshot")
+				continue
+			}
+
+			if err = s.orderStorage.RebuildOrder(ctx, orderInfo, true); err != nil {
+				return restored, err
+			}
+
+			restored++
+		}
+
+		s.verifyRestoredDepth(ctx, book)
+	}
+
+	return restored, nil
+}
+
+// isOrderChangedSinceSnapshot reports whether the stored order was closed or updated after the snapshot was taken.
+func isOrderChangedSinceSnapshot(stored, orderInfo models.OrderModel) bool {
+	switch stored.State {
+	case int(ops.OpsOrderState_OPS_ORDER_STATE_FILLED), int(ops.OpsOrderState_OPS_ORDER_STATE_DONE), int(ops.OpsOrderState_OPS_ORDER_STATE_REJECTED):
+		return true
+	default:
+		return stored.UpdatedDate > orderInfo.UpdatedDate
+	}
+}
+
+// verifyRestoredDepth compares the depth rebuilt from orders with the one saved in the snapshot.
+func (s *SnapshotService) verifyRestoredDepth(ctx context.Context, book models.PairBookSnapshot) {
+	for direction, expected := range map[int][]models.PriceLevel{
+		int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY):  book.Bids,
+		int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL): book.Asks,
+	} {
+		actual, err := s.getBookLevels(ctx, book.CurrencyPair, direction)
+
+		if err != nil {
+			logrus.WithField("currencyPair", book.CurrencyPair).Errorln("Fail verify restored depth, reason: ", err.Error())
+			continue
+		}
+
+		if !isSameDepth(expected, actual) {
+			logrus.WithFields(logrus.Fields{
+				"currencyPair": book.CurrencyPair,
+				"direction":    direction}).Warningln("Restored depth differs from snapshot depth")
+		}
+	}
+}
+
+func (s *SnapshotService) getBookLevels(ctx context.Context, currencyPair string, direction int) ([]models.PriceLevel, error) {
+	levels, err := s.orderStorage.GetStockBookLevels(ctx, currencyPair, direction, 0)
+
+	if errors.Is(err, staticerr.ErrorStockBookIsEmpty) {
+		return nil, nil
+	}
+
+	return levels, err
+}
+
+func groupOrdersByPair(orders []models.OrderModel) []models.PairBookSnapshot {
+	byPair := make(map[string]*models.PairBookSnapshot)
+	pairs := make([]string, 0)
+
+	for _, orderInfo := range orders {
+		book, ok := byPair[orderInfo.CurrencyPair]
+
+		if !ok {
+			book = &models.PairBookSnapshot{CurrencyPair: orderInfo.CurrencyPair}
+			byPair[orderInfo.CurrencyPair] = book
+			pairs = append(pairs, orderInfo.CurrencyPair)
+		}
+
+		book.Orders = append(book.Orders, orderInfo)
+	}
+
+	sort.Strings(pairs)
+	books := make([]models.PairBookSnapshot, 0, len(pairs))
+
+	for _, pair := range pairs {
+		books = append(books, *byPair[pair])
+	}
+
+	return books
+}
+
+func isSameDepth(expected, actual []models.PriceLevel) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	for i := range expected {
+		if expected[i].Price != actual[i].Price || math.Abs(expected[i].Volume-actual[i].Volume) > utils.VolumeEpsilon {
+			return false
+		}
+	}
+
+	return true
+}
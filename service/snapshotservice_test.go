@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/storage"
+)
+
+func Test_groupOrdersByPair(t *testing.T) {
+	orders := []models.OrderModel{
+		{OrderId: "1", CurrencyPair: "ETH/USD"},
+		{OrderId: "2", CurrencyPair: "BTC/USD"},
+		{OrderId: "3", CurrencyPair: "ETH/USD"},
+	}
+
+	got := groupOrdersByPair(orders)
+
+	if len(got) != 2 {
+		t.Fatalf("groupOrdersByPair() len = %v, want 2", len(got))
+	}
+
+	if got[0].CurrencyPair != "BTC/USD" || len(got[0].Orders) != 1 {
+		t.Errorf("groupOrdersByPair() first book = %+v", got[0])
+	}
+
+	if got[1].CurrencyPair != "ETH/USD" || len(got[1].Orders) != 2 || got[1].Orders[0].OrderId != "1" {
+		t.Errorf("groupOrdersByPair() second book = %+v", got[1])
+	}
+}
+
+func Test_isSameDepth(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []models.PriceLevel
+		actual   []models.PriceLevel
+		want     bool
+	}{
+		{name: "both empty", want: true},
+		{name: "same levels", expected: []models.PriceLevel{{Price: 100, Volume: 1}}, actual: []models.PriceLevel{{Price: 100, Volume: 1 + 1e-12}}, want: true},
+		{name: "different volume", expected: []models.PriceLevel{{Price: 100, Volume: 1}}, actual: []models.PriceLevel{{Price: 100, Volume: 2}}, want: false},
+		{name: "missing level", expected: []models.PriceLevel{{Price: 100, Volume: 1}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSameDepth(tt.expected, tt.actual); got != tt.want {
+				t.Errorf("isSameDepth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotService_restoreStockBook(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	ctx := context.Background()
+	env := newTestEnv(t)
+	snapshotStorage := storage.NewSnapshotStorage(t.TempDir())
+	snapshotService := NewSnapshotService(env.orderStorage, snapshotStorage, env.ticketStorage)
+
+	missing := testOrder("missing", sell, 100, 1)
+	closed := testOrder("closed", sell, 101, 1)
+	updated := testOrder("updated", sell, 102, 1)
+	path, err := snapshotStorage.SaveSnapshot(models.StockBookSnapshot{
+		Version: models.StockBookSnapshotVersion,
+		Books:   []models.PairBookSnapshot{{CurrencyPair: "BTC/USDT", Orders: []models.OrderModel{missing, closed, updated}}},
+	})
+
+	if err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	closed.State = int(ops.OpsOrderState_OPS_ORDER_STATE_DONE)
+	updated.UpdatedDate = time.Now().Add(time.Minute).UnixMilli()
+
+	for _, orderInfo := range []models.OrderModel{closed, updated} {
+		if err = env.orderStorage.RebuildOrder(ctx, orderInfo, false); err != nil {
+			t.Fatalf("RebuildOrder() error = %v", err)
+		}
+	}
+
+	restored, err := snapshotService.restoreStockBook(ctx, filepath.Base(path), true)
+
+	if err != nil || restored != 1 {
+		t.Fatalf("restoreStockBook() = %v, %v, want 1", restored, err)
+	}
+
+	events, err := env.orderStorage.ReadOrderEvents(ctx, "0", 100)
+
+	if err != nil {
+		t.Fatalf("ReadOrderEvents() error = %v", err)
+	}
+
+	for _, event := range events {
+		if event.Order.OrderId == missing.OrderId {
+			t.Errorf("restore logged %v event for %v", event.EventType, missing.OrderId)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		orderInfo models.OrderModel
+		wantState int
+		wantBook  bool
+	}{
+		{name: "missing order is restored", orderInfo: missing, wantState: missing.State, wantBook: true},
+		{name: "closed order is kept closed", orderInfo: closed, wantState: closed.State},
+		{name: "order updated after the snapshot is kept", orderInfo: updated, wantState: updated.State},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored, err := env.orderStorage.GetOrderFromStorage(ctx, tt.orderInfo.OrderId)
+
+			if err != nil || stored.State != tt.wantState || stored.UpdatedDate != tt.orderInfo.UpdatedDate {
+				t.Fatalf("GetOrderFromStorage() = %+v, %v", stored, err)
+			}
+
+			if booked, _ := env.orderStorage.IsInStockBook(ctx, tt.orderInfo); booked != tt.wantBook {
+				t.Errorf("IsInStockBook() = %v, want %v", booked, tt.wantBook)
+			}
+		})
+	}
+}
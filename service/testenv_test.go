@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/storage"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type sentMessage struct {
+	exchange string
+	rk       string
+	message  interface{}
+}
+
+type messageSenderStub struct {
+	mu       sync.Mutex
+	messages []sentMessage
+}
+
+func (m *messageSenderStub) SendMessage(ctx context.Context, message protoreflect.ProtoMessage, exchange, rk string) error {
+	return m.SendJsonMessage(ctx, message, exchange, rk)
+}
+
+func (m *messageSenderStub) SendJsonMessage(ctx context.Context, message interface{}, exchange, rk string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, sentMessage{exchange: exchange, rk: rk, message: message})
+	return nil
+}
+
+func (m *messageSenderStub) sentTo(exchange string) []interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sent := make([]interface{}, 0)
+
+	for _, message := range m.messages {
+		if message.exchange == exchange {
+			sent = append(sent, message.message)
+		}
+	}
+
+	return sent
+}
+
+// testEnv wires the services the way the application does, on top of an in-memory redis.
+type testEnv struct {
+	server            *miniredis.Miniredis
+	orderStorage      *storage.OrdersStorage
+	ticketStorage     *storage.TicketStorage
+	instrumentStorage *storage.InstrumentStorage
+	tradeStorage      *storage.TradeStorage
+	marketStorage     *storage.MarketStorage
+	accountStorage    *storage.AccountStorage
+	riskStorage       *storage.RiskStorage
+	rateLimitStorage  *storage.RateLimitStorage
+	messageSender     *messageSenderStub
+	marketService     *MarketService
+	marketDataService *MarketDataService
+	cancelService     *CancelService
+	killSwitchService *KillSwitchService
+	candleService     *CandleService
+	riskService       *RiskService
+	rateLimiter       *RateLimiter
+	orderService      *OrderService
+	matcherService    *MatcherService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client, err := storage.NewRedisClient(server.Addr())
+
+	if err != nil {
+		t.Fatalf("NewRedisClient() error = %v", err)
+	}
+
+	env := &testEnv{
+		server:            server,
+		orderStorage:      storage.NewOrdersStorage(client),
+		ticketStorage:     storage.NewTicketStorage(client),
+		instrumentStorage: storage.NewInstrumentStorage(client),
+		tradeStorage:      storage.NewTradeStorage(client),
+		marketStorage:     storage.NewMarketStorage(client),
+		accountStorage:    storage.NewAccountStorage(client),
+		riskStorage:       storage.NewRiskStorage(client),
+		rateLimitStorage:  storage.NewRateLimitStorage(client),
+		messageSender:     &messageSenderStub{},
+	}
+
+	env.marketService = NewMarketService(env.marketStorage, env.orderStorage, env.ticketStorage, env.messageSender)
+	env.marketDataService = NewMarketDataService(env.orderStorage, storage.NewMarketDataStorage(client), env.messageSender)
+	env.cancelService = NewCancelService(env.orderStorage, env.ticketStorage, env.messageSender, env.marketDataService)
+	env.killSwitchService = NewKillSwitchService(env.accountStorage, env.cancelService, env.messageSender)
+	env.candleService = NewCandleService(storage.NewCandleStorage(client), env.messageSender)
+	env.riskService = NewRiskService(env.riskStorage)
+	env.rateLimiter = NewRateLimiter(env.rateLimitStorage, env.instrumentStorage, env.accountStorage, models.RateLimitSettings{})
+	env.orderService = NewOrderService(env.orderStorage, env.ticketStorage, env.instrumentStorage, env.accountStorage, env.riskService, env.marketService, env.killSwitchService, env.rateLimiter)
+	env.matcherService = NewMatcherService(env.orderStorage, env.ticketStorage, env.instrumentStorage, env.tradeStorage, env.messageSender,
+		NewFeeEngine(env.accountStorage), env.marketService, env.killSwitchService, env.marketDataService, env.candleService)
+
+	return env
+}
+
+func (e *testEnv) setSettings(t *testing.T, settings models.InstrumentSettings) {
+	t.Helper()
+
+	if err := e.instrumentStorage.SetInstrumentSettings(context.Background(), settings); err != nil {
+		t.Fatalf("SetInstrumentSettings() error = %v", err)
+	}
+}
+
+// bookOrders stores the orders and rests the limit ones in the book.
+func (e *testEnv) bookOrders(t *testing.T, orders ...models.OrderModel) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, orderInfo := range orders {
+		if err := e.orderStorage.AddOrderToStorage(ctx, orderInfo); err != nil {
+			t.Fatalf("AddOrderToStorage() error = %v", err)
+		}
+
+		if orderInfo.LimitPrice == 0 {
+			continue
+		}
+
+		if err := e.orderStorage.AddInStockBook(ctx, orderInfo); err != nil {
+			t.Fatalf("AddInStockBook() error = %v", err)
+		}
+	}
+}
+
+func (e *testEnv) getOrder(t *testing.T, id string) models.OrderModel {
+	t.Helper()
+
+	orderInfo, err := e.orderStorage.GetOrderFromStorage(context.Background(), id)
+
+	if err != nil {
+		t.Fatalf("GetOrderFromStorage(%s) error = %v", id, err)
+	}
+
+	return *orderInfo
+}
+
+// drainTickets pops every ticket of the operation type, newest first, and returns their order payloads.
+func (e *testEnv) drainTickets(t *testing.T, operation ops.OpsTicketOperation) []*ops.OpsOrderInfo {
+	t.Helper()
+	orders := make([]*ops.OpsOrderInfo, 0)
+
+	for {
+		ticket, err := e.ticketStorage.GetTicketFromStorage(context.Background())
+
+		if errors.Is(err, redis.Nil) {
+			return orders
+		}
+
+		if err != nil {
+			t.Fatalf("GetTicketFromStorage() error = %v", err)
+		}
+
+		if ticket.OperationType != operation {
+			continue
+		}
+
+		var orderInfo ops.OpsOrderInfo
+
+		if err = proto.Unmarshal(ticket.Data, &orderInfo); err != nil {
+			t.Fatalf("proto.Unmarshal() error = %v", err)
+		}
+
+		orders = append(orders, &orderInfo)
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	tickerExchange        = "e.ops.market_data.ticker"
+	tickerRoutingKey      = "ticker."
+	tickerQueryRoutingKey = "query."
+	tickerWindow          = 24 * time.Hour
+	tickerCandleInterval  = models.CandleInterval5m
+)
+
+type TickerService struct {
+	orderStorage      iOrderStorage
+	marketStorage     iMarketStorage
+	marketDataStorage iMarketDataStorage
+	candleService     *CandleService
+	messageSender     iMessageSender
+}
+
+func NewTickerService(orderStorage iOrderStorage, marketStorage iMarketStorage, marketDataStorage iMarketDataStorage, candleService *CandleService, messageSender iMessageSender) *TickerService {
+	return &TickerService{
+		orderStorage:      orderStorage,
+		marketStorage:     marketStorage,
+		marketDataStorage: marketDataStorage,
+		candleService:     candleService,
+		messageSender:     messageSender,
+	}
+}
+
+// GetTicker combines the top of the book with statistics over the 5m candles of the last 24h.
+func (t *TickerService) GetTicker(ctx context.Context, currencyPair string) (*models.TickerModel, error) {
+	now := time.Now().UTC()
+
+	candles, err := t.candleService.GetCandles(ctx, currencyPair, tickerCandleInterval, getTickerWindowStart(now), now.UnixMilli())
+
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := computeTickerStats(candles)
+	ticker.CurrencyPair = currencyPair
+	ticker.Timestamp = now.UnixMilli()
+
+	if ticker.LastPrice, err = t.marketStorage.GetLastPrice(ctx, currencyPair); err != nil {
+		return nil, err
+	}
+
+	bestBid, err := t.getBestLevel(ctx, currencyPair, int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY))
+
+	if err != nil {
+		return nil, err
+	}
+
+	bestAsk, err := t.getBestLevel(ctx, currencyPair, int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL))
+
+	if err != nil {
+		return nil, err
+	}
+
+	ticker.BestBid, ticker.BestBidVolume = bestBid.Price, bestBid.Volume
+	ticker.BestAsk, ticker.BestAskVolume = bestAsk.Price, bestAsk.Volume
+
+	return &ticker, nil
+}
+
+func (t *TickerService) SendTicker(ctx context.Context, request *models.TickerRequest) {
+	response := models.TickerResponse{Id: request.Id}
+
+	ticker, err := t.GetTicker(ctx, request.CurrencyPair)
+
+	if err != nil {
+		logrus.WithField("requestId", request.Id).Errorln("Fail get ticker, reason: ", err.Error())
+		response.Error = err.Error()
+	} else {
+		response.Ticker = ticker
+	}
+
+	if err = t.messageSender.SendJsonMessage(ctx, response, tickerExchange, tickerQueryRoutingKey+request.CurrencyPair); err != nil {
+		logrus.WithField("requestId", request.Id).Errorln("Fail send ticker, reason: ", err.Error())
+	}
+}
+
+// RunTickerScheduler pushes tickers of the pairs changed by trades or book updates since the last push.
+func (t *TickerService) RunTickerScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			currencyPairs, err := t.marketDataStorage.PopChangedTickers(ctx)
+
+			if err != nil {
+				logrus.Errorln("Fail get changed tickers, reason: ", err.Error())
+				continue
+			}
+
+			for _, currencyPair := range currencyPairs {
+				t.publishTicker(ctx, currencyPair)
+			}
+		}
+	}
+}
+
+func (t *TickerService) publishTicker(ctx context.Context, currencyPair string) {
+	ticker, err := t.GetTicker(ctx, currencyPair)
+
+	if err != nil {
+		logrus.WithField("currencyPair", currencyPair).Errorln("Fail get ticker, reason: ", err.Error())
+		return
+	}
+
+	if err = t.messageSender.SendJsonMessage(ctx, ticker, tickerExchange, tickerRoutingKey+currencyPair); err != nil {
+		logrus.WithField("currencyPair", currencyPair).Errorln("Fail send ticker, reason: ", err.Error())
+	}
+}
+
+func (t *TickerService) getBestLevel(ctx context.Context, currencyPair string, direction int) (models.PriceLevel, error) {
+	level, err := t.orderStorage.GetBestStockLevel(ctx, currencyPair, direction)
+
+	if errors.Is(err, staticerr.ErrorStockBookIsEmpty) {
+		return models.PriceLevel{}, nil
+	}
+
+	if err != nil {
+		return models.PriceLevel{}, err
+	}
+
+	return *level, nil
+}
+
+// getTickerWindowStart rounds the start of the window up to the next candle, the candle it falls in
+// started before the window so leaving it out keeps the window within 24h.
+func getTickerWindowStart(now time.Time) int64 {
+	duration := candleIntervals[tickerCandleInterval]
+	start := now.Add(-tickerWindow).UnixMilli()
+
+	return getCandleOpenTime(start+duration.Milliseconds()-1, duration)
+}
+
+// computeTickerStats expects candles ordered by open time.
+func computeTickerStats(candles []models.CandleModel) models.TickerModel {
+	ticker := models.TickerModel{}
+
+	if len(candles) == 0 {
+		return ticker
+	}
+
+	ticker.High24h = candles[0].High
+	ticker.Low24h = candles[0].Low
+
+	for _, candle := range candles {
+		ticker.High24h = math.Max(ticker.High24h, candle.High)
+		ticker.Low24h = math.Min(ticker.Low24h, candle.Low)
+		ticker.Volume24h += candle.Volume
+		ticker.QuoteVolume24h += candle.QuoteVolume
+		ticker.TradeCount24h += candle.TradeCount
+	}
+
+	open := candles[0].Open
+	ticker.Change24h = candles[len(candles)-1].Close - open
+
+	if open > 0 {
+		ticker.ChangePercent24h = ticker.Change24h / open * 100
+	}
+
+	if ticker.Volume24h > 0 {
+		ticker.Vwap24h = ticker.QuoteVolume24h / ticker.Volume24h
+	}
+
+	return ticker
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+	"trade-order-processing-service/models"
+)
+
+func Test_computeTickerStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		candles []models.CandleModel
+		want    models.TickerModel
+	}{
+		{
+			name:    "no trades",
+			candles: nil,
+			want:    models.TickerModel{},
+		},
+		{
+			name: "several candles",
+			candles: []models.CandleModel{
+				{Open: 100, High: 105, Low: 99, Close: 104, Volume: 2, QuoteVolume: 204, TradeCount: 2},
+				{Open: 104, High: 110, Low: 95, Close: 108, Volume: 3, QuoteVolume: 306, TradeCount: 4},
+			},
+			want: models.TickerModel{
+				High24h:          110,
+				Low24h:           95,
+				Volume24h:        5,
+				QuoteVolume24h:   510,
+				Change24h:        8,
+				ChangePercent24h: 8,
+				Vwap24h:          102,
+				TradeCount24h:    6,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeTickerStats(tt.candles)
+
+			if got.TradeCount24h != tt.want.TradeCount24h {
+				t.Errorf("computeTickerStats() trade count = %v, want %v", got.TradeCount24h, tt.want.TradeCount24h)
+			}
+
+			checks := map[string][2]float64{
+				"high":          {got.High24h, tt.want.High24h},
+				"low":           {got.Low24h, tt.want.Low24h},
+				"volume":        {got.Volume24h, tt.want.Volume24h},
+				"quoteVolume":   {got.QuoteVolume24h, tt.want.QuoteVolume24h},
+				"change":        {got.Change24h, tt.want.Change24h},
+				"changePercent": {got.ChangePercent24h, tt.want.ChangePercent24h},
+				"vwap":          {got.Vwap24h, tt.want.Vwap24h},
+			}
+
+			for field, values := range checks {
+				if math.Abs(values[0]-values[1]) > 1e-9 {
+					t.Errorf("computeTickerStats() %s = %v, want %v", field, values[0], values[1])
+				}
+			}
+		})
+	}
+}
+
+func Test_getTickerWindowStart(t *testing.T) {
+	boundary := time.Date(2024, 1, 2, 12, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{name: "window starting on a candle keeps it", now: boundary, want: boundary.Add(-24 * time.Hour)},
+		{name: "partial first candle is left out", now: boundary.Add(time.Millisecond), want: boundary.Add(-24*time.Hour + 5*time.Minute)},
+		{name: "window just before a candle starts with it", now: boundary.Add(5*time.Minute - time.Millisecond), want: boundary.Add(-24*time.Hour + 5*time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getTickerWindowStart(tt.now)
+
+			if got != tt.want.UnixMilli() {
+				t.Errorf("getTickerWindowStart() = %v, want %v", time.UnixMilli(got).UTC(), tt.want)
+			}
+
+			if window := tt.now.UnixMilli() - got; window > (24 * time.Hour).Milliseconds() {
+				t.Errorf("window = %v, want at most 24h", time.Duration(window)*time.Millisecond)
+			}
+		})
+	}
+}
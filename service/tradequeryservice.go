@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	tradesQueryExchange          = "e.ops.trades"
+	tradesQueryOrderRoutingKey   = "order."
+	tradesQueryAccountRoutingKey = "account."
+	tradesQueryDefaultLimit      = 100
+	tradesQueryMaxLimit          = 1000
+)
+
+type iTradeQueryStorage interface {
+	GetTradesByOrder(ctx context.Context, orderId string) ([]models.TradeModel, error)
+	GetAccountTradeIndex(ctx context.Context, accountId string, fromDate, offset, count int64) ([]models.TradeIndexEntry, error)
+	GetTradesFromStorage(ctx context.Context, ids []string) ([]models.TradeModel, error)
+}
+
+type TradeQueryService struct {
+	tradeStorage  iTradeQueryStorage
+	messageSender iMessageSender
+}
+
+func NewTradeQueryService(tradeStorage iTradeQueryStorage, messageSender iMessageSender) *TradeQueryService {
+	return &TradeQueryService{tradeStorage: tradeStorage, messageSender: messageSender}
+}
+
+func (q *TradeQueryService) SendTrades(ctx context.Context, request *models.TradesRequest) {
+	response := models.TradesResponse{Id: request.Id, OrderId: request.OrderId, AccountId: request.AccountId, Trades: []models.TradeModel{}}
+
+	trades, nextCursor, err := q.GetTrades(ctx, request)
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"orderId":   request.OrderId,
+			"accountId": request.AccountId}).Errorln("Fail get trades, reason: ", err.Error())
+		response.Error = err.Error()
+	} else {
+		response.Trades = trades
+		response.NextCursor = nextCursor
+	}
+
+	routingKey := tradesQueryAccountRoutingKey + request.AccountId
+
+	if request.OrderId != "" {
+		routingKey = tradesQueryOrderRoutingKey + request.OrderId
+	}
+
+	if err = q.messageSender.SendJsonMessage(ctx, response, tradesQueryExchange, routingKey); err != nil {
+		logrus.WithField("requestId", request.Id).Errorln("Fail send trades, reason: ", err.Error())
+	}
+}
+
+// GetTrades returns the order trades or a page of the account trades in execution order,
+// with the cursor of the next account page.
+func (q *TradeQueryService) GetTrades(ctx context.Context, request *models.TradesRequest) ([]models.TradeModel, string, error) {
+	switch {
+	case request.OrderId != "":
+		trades, err := q.tradeStorage.GetTradesByOrder(ctx, request.OrderId)
+		return trades, "", err
+	case request.AccountId != "":
+		return q.GetAccountTrades(ctx, request)
+	default:
+		return nil, "", staticerr.ErrorTradesQueryScopeIsEmpty
+	}
+}
+
+// GetAccountTrades returns a page of account trades from the oldest to the newest and the cursor of the next page,
+// the cursor is empty when there are no more trades.
+func (q *TradeQueryService) GetAccountTrades(ctx context.Context, request *models.TradesRequest) ([]models.TradeModel, string, error) {
+	limit := request.Limit
+
+	if limit <= 0 {
+		limit = tradesQueryDefaultLimit
+	}
+
+	if limit > tradesQueryMaxLimit {
+		limit = tradesQueryMaxLimit
+	}
+
+	fromDate := int64(0)
+	var after *models.TradeIndexEntry
+
+	if request.Cursor != "" {
+		cursor, err := decodeTradeCursor(request.Cursor)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		fromDate = cursor.Date
+		after = cursor
+	}
+
+	ids := make([]string, 0, limit)
+	offset := int64(0)
+	var last models.TradeIndexEntry
+
+	for int64(len(ids)) < limit {
+		entries, err := q.tradeStorage.GetAccountTradeIndex(ctx, request.AccountId, fromDate, offset, limit)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		if len(entries) == 0 {
+			break
+		}
+
+		offset += int64(len(entries))
+
+		for _, entry := range entries {
+			if after != nil && entry.Date == after.Date && entry.TradeId <= after.TradeId {
+				continue
+			}
+
+			ids = append(ids, entry.TradeId)
+			last = entry
+
+			if int64(len(ids)) == limit {
+				break
+			}
+		}
+	}
+
+	trades, err := q.tradeStorage.GetTradesFromStorage(ctx, ids)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if int64(len(ids)) < limit {
+		return trades, "", nil
+	}
+
+	return trades, encodeTradeCursor(last), nil
+}
+
+func encodeTradeCursor(entry models.TradeIndexEntry) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", entry.Date, entry.TradeId)))
+}
+
+func decodeTradeCursor(cursor string) (*models.TradeIndexEntry, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil {
+		return nil, staticerr.ErrorInvalidCursor
+	}
+
+	tradeDate, tradeId, found := strings.Cut(string(data), ":")
+
+	if !found || tradeId == "" {
+		return nil, staticerr.ErrorInvalidCursor
+	}
+
+	date, err := strconv.ParseInt(tradeDate, 10, 64)
+
+	if err != nil {
+		return nil, staticerr.ErrorInvalidCursor
+	}
+
+	return &models.TradeIndexEntry{TradeId: tradeId, Date: date}, nil
+}
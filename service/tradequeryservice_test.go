@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+)
+
+func TestTradeQueryService_SendTrades(t *testing.T) {
+	now := time.Now().UnixMilli()
+	trades := []models.TradeModel{
+		{TradeId: "trade-1", MakerOrderId: "maker", TakerOrderId: "taker", MakerAccountId: "alice", TakerAccountId: "bob", TradeDate: now - 2000},
+		{TradeId: "trade-2", MakerOrderId: "other", TakerOrderId: "taker", MakerAccountId: "carol", TakerAccountId: "bob", TradeDate: now - 1000},
+	}
+	tests := []struct {
+		name           string
+		request        models.TradesRequest
+		wantRoutingKey string
+		wantTrades     int
+		wantErr        error
+	}{
+		{
+			name:           "order trades",
+			request:        models.TradesRequest{Id: "1", OrderId: "taker", AccountId: "bob"},
+			wantRoutingKey: "order.taker",
+			wantTrades:     2,
+		},
+		{
+			name:           "account trades",
+			request:        models.TradesRequest{Id: "2", AccountId: "alice"},
+			wantRoutingKey: "account.alice",
+			wantTrades:     1,
+		},
+		{
+			name:           "empty scope",
+			request:        models.TradesRequest{Id: "3"},
+			wantRoutingKey: "account.",
+			wantErr:        staticerr.ErrorTradesQueryScopeIsEmpty,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			service := NewTradeQueryService(env.tradeStorage, env.messageSender)
+
+			for _, tradeInfo := range trades {
+				if err := env.tradeStorage.AddTradeToStorage(context.Background(), tradeInfo); err != nil {
+					t.Fatalf("AddTradeToStorage() error = %v", err)
+				}
+			}
+
+			if _, _, err := service.GetTrades(context.Background(), &tt.request); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetTrades() error = %v, want %v", err, tt.wantErr)
+			}
+
+			service.SendTrades(context.Background(), &tt.request)
+
+			if len(env.messageSender.messages) != 1 {
+				t.Fatalf("SendTrades() sent %d messages, want 1", len(env.messageSender.messages))
+			}
+
+			sent := env.messageSender.messages[0]
+			response := sent.message.(models.TradesResponse)
+
+			if sent.rk != tt.wantRoutingKey || len(response.Trades) != tt.wantTrades || (tt.wantErr != nil) != (response.Error != "") {
+				t.Errorf("SendTrades() rk = %v, trades = %d, error = %q, want %v, %d", sent.rk, len(response.Trades), response.Error, tt.wantRoutingKey, tt.wantTrades)
+			}
+		})
+	}
+}
+
+func TestTradeQueryService_GetAccountTrades(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UnixMilli()
+	env := newTestEnv(t)
+	service := NewTradeQueryService(env.tradeStorage, env.messageSender)
+	trades := []models.TradeModel{
+		{TradeId: "trade-3", MakerAccountId: "alice", TakerAccountId: "bob", TradeDate: now - 1000},
+		{TradeId: "trade-1", MakerAccountId: "alice", TakerAccountId: "bob", TradeDate: now - 2000},
+		{TradeId: "trade-2", MakerAccountId: "carol", TakerAccountId: "alice", TradeDate: now - 2000},
+		{TradeId: "trade-4", MakerAccountId: "carol", TakerAccountId: "bob", TradeDate: now},
+	}
+
+	for _, tradeInfo := range trades {
+		if err := env.tradeStorage.AddTradeToStorage(ctx, tradeInfo); err != nil {
+			t.Fatalf("AddTradeToStorage() error = %v", err)
+		}
+	}
+
+	request := &models.TradesRequest{AccountId: "alice", Limit: 2}
+	pages := [][]string{{"trade-1", "trade-2"}, {"trade-3"}}
+
+	for i, want := range pages {
+		got, cursor, err := service.GetAccountTrades(ctx, request)
+
+		if err != nil {
+			t.Fatalf("GetAccountTrades() page %d error = %v", i, err)
+		}
+
+		ids := make([]string, 0, len(got))
+		for _, tradeInfo := range got {
+			ids = append(ids, tradeInfo.TradeId)
+		}
+
+		if !reflect.DeepEqual(ids, want) {
+			t.Errorf("GetAccountTrades() page %d = %v, want %v", i, ids, want)
+		}
+
+		if (cursor == "") != (i == len(pages)-1) {
+			t.Errorf("GetAccountTrades() page %d cursor = %q", i, cursor)
+		}
+
+		request.Cursor = cursor
+	}
+
+	request.Cursor = "invalid"
+
+	if _, _, err := service.GetAccountTrades(ctx, request); !errors.Is(err, staticerr.ErrorInvalidCursor) {
+		t.Errorf("GetAccountTrades() error = %v, want %v", err, staticerr.ErrorInvalidCursor)
+	}
+}
@@ -0,0 +1,18 @@
+package staticerr
+
+import "trade-order-processing-service/external/ops"
+
+// Error codes not yet released in trade-protos, kept in sync with OpsErrorCode numbering.
+const (
+	OpsErrorCodeOpenOrdersLimitExceeded    = ops.OpsErrorCode(6)
+	OpsErrorCodeOpenNotionalLimitExceeded  = ops.OpsErrorCode(7)
+	OpsErrorCodeOrderNotionalLimitExceeded = ops.OpsErrorCode(8)
+	OpsErrorCodePriceOutOfBand             = ops.OpsErrorCode(9)
+	OpsErrorCodeTradingHalted              = ops.OpsErrorCode(10)
+	OpsErrorCodePairIsNotOpen              = ops.OpsErrorCode(11)
+	OpsErrorCodeOrderIsNotCancellable      = ops.OpsErrorCode(12)
+	OpsErrorCodeOrderNotBelongsToAccount   = ops.OpsErrorCode(13)
+	OpsErrorCodeAccountIsBlocked           = ops.OpsErrorCode(15)
+	OpsErrorCodeRateLimitExceeded          = ops.OpsErrorCode(16)
+	OpsErrorCodeMarketConvertedToLimit     = ops.OpsErrorCode(17)
+)
@@ -3,8 +3,34 @@ package staticerr
 import "errors"
 
 var (
-	ErrorRabbitConnectionFail = errors.New("RabbitUnvailable")
-	ErrorResourceIsLocked     = errors.New("ResourceIsLocked")
-	ErrorStockBookIsEmpty     = errors.New("StockBookIsEmpty")
-	ErrorOrderExpired         = errors.New("OrderExpired")
+	ErrorRabbitConnectionFail       = errors.New("RabbitUnvailable")
+	ErrorResourceIsLocked           = errors.New("ResourceIsLocked")
+	ErrorStockBookIsEmpty           = errors.New("StockBookIsEmpty")
+	ErrorOrderExpired               = errors.New("OrderExpired")
+	ErrorLockAmountExhausted        = errors.New("LockAmountExhausted")
+	ErrorOpenOrdersLimitExceeded    = errors.New("OpenOrdersLimitExceeded")
+	ErrorOpenNotionalLimitExceeded  = errors.New("OpenNotionalLimitExceeded")
+	ErrorOrderNotionalLimitExceeded = errors.New("OrderNotionalLimitExceeded")
+	ErrorPriceOutOfBand             = errors.New("PriceOutOfBand")
+	ErrorTradingHalted              = errors.New("TradingHalted")
+	ErrorQueueIsEmpty               = errors.New("QueueIsEmpty")
+	ErrorPairIsNotOpen              = errors.New("PairIsNotOpen")
+	ErrorPairIsNotInAuction         = errors.New("PairIsNotInAuction")
+	ErrorOrderIsNotCancellable      = errors.New("OrderIsNotCancellable")
+	ErrorOrderNotBelongsToAccount   = errors.New("OrderNotBelongsToAccount")
+	ErrorMassCancelScopeIsEmpty     = errors.New("MassCancelScopeIsEmpty")
+	ErrorAccountIsBlocked           = errors.New("AccountIsBlocked")
+	ErrorRateLimitExceeded          = errors.New("RateLimitExceeded")
+	ErrorUnknownCandleInterval      = errors.New("UnknownCandleInterval")
+	ErrorSnapshotIsCorrupted        = errors.New("SnapshotIsCorrupted")
+	ErrorSnapshotVersionUnsupported = errors.New("SnapshotVersionUnsupported")
+	ErrorSnapshotPathOutsideDir     = errors.New("SnapshotPathOutsideDir")
+	ErrorStockBookIsNotEmpty        = errors.New("StockBookIsNotEmpty")
+	ErrorOrderEventIsCorrupted      = errors.New("OrderEventIsCorrupted")
+	ErrorAuditChainIsBroken         = errors.New("AuditChainIsBroken")
+	ErrorAuditSigningKeyIsEmpty     = errors.New("AuditSigningKeyIsEmpty")
+	ErrorAuditCheckpointIsInvalid   = errors.New("AuditCheckpointIsInvalid")
+	ErrorInvalidCursor              = errors.New("InvalidCursor")
+	ErrorTradeIsMissing             = errors.New("TradeIsMissing")
+	ErrorTradesQueryScopeIsEmpty    = errors.New("TradesQueryScopeIsEmpty")
 )
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"trade-order-processing-service/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	accountTiersHashKey      = "accounts:tiers"
+	accountKillSwitchHashKey = "accounts:kill_switch"
+	accountKillSwitchAudit   = "accounts:kill_switch:audit:"
+	accountRateLimitsHashKey = "accounts:rate_limits"
+	accountHiddenOrdersKey   = "accounts:hidden_orders"
+)
+
+type AccountStorage struct {
+	client *RedisClient
+}
+
+func NewAccountStorage(client *RedisClient) *AccountStorage {
+	return &AccountStorage{client: client}
+}
+
+func (a *AccountStorage) GetAccountTier(ctx context.Context, accountId string) (string, error) {
+	tier, err := a.client.getFromHash(ctx, accountTiersHashKey, accountId)
+
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return *tier, nil
+}
+
+func (a *AccountStorage) SetAccountTier(ctx context.Context, accountId string, tier string) error {
+	return a.client.addInHash(ctx, accountTiersHashKey, accountId, tier)
+}
+
+// GetAccountRateLimit returns the limit set for the account, nil when it follows the instrument limit.
+func (a *AccountStorage) GetAccountRateLimit(ctx context.Context, accountId string) (*models.RateLimitSettings, error) {
+	data, err := a.client.getFromHash(ctx, accountRateLimitsHashKey, accountId)
+
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var settings models.RateLimitSettings
+
+	if err = json.Unmarshal([]byte(*data), &settings); err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+func (a *AccountStorage) SetAccountRateLimit(ctx context.Context, accountId string, settings models.RateLimitSettings) error {
+	data, err := json.Marshal(settings)
+
+	if err != nil {
+		return err
+	}
+
+	return a.client.addInHash(ctx, accountRateLimitsHashKey, accountId, data)
+}
+
+// IsHiddenOrdersAccount tells whether the limit orders of the account are booked hidden.
+func (a *AccountStorage) IsHiddenOrdersAccount(ctx context.Context, accountId string) (bool, error) {
+	return a.client.isSetMember(ctx, accountHiddenOrdersKey, accountId)
+}
+
+func (a *AccountStorage) SetHiddenOrdersAccount(ctx context.Context, accountId string, hidden bool) error {
+	if hidden {
+		return a.client.addInSet(ctx, accountHiddenOrdersKey, accountId)
+	}
+
+	return a.client.removeFromSet(ctx, accountHiddenOrdersKey, accountId)
+}
+
+func (a *AccountStorage) GetKillSwitch(ctx context.Context, accountId string) (*models.KillSwitchModel, error) {
+	data, err := a.client.getFromHash(ctx, accountKillSwitchHashKey, accountId)
+
+	if errors.Is(err, redis.Nil) {
+		return &models.KillSwitchModel{AccountId: accountId}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var state models.KillSwitchModel
+
+	if err = json.Unmarshal([]byte(*data), &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// SetKillSwitch saves the switch state and appends it to the account audit list.
+func (a *AccountStorage) SetKillSwitch(ctx context.Context, state models.KillSwitchModel) error {
+	data, err := json.Marshal(state)
+
+	if err != nil {
+		return err
+	}
+
+	tx := a.client.performTx(ctx)
+
+	tx.
+		addInHash(ctx, accountKillSwitchHashKey, state.AccountId, data).
+		appendInList(ctx, accountKillSwitchAudit+state.AccountId, data)
+
+	return tx.execTx(ctx)
+}
+
+func (a *AccountStorage) GetKillSwitchAudit(ctx context.Context, accountId string) ([]models.KillSwitchModel, error) {
+	values, err := a.client.getAllFromList(ctx, accountKillSwitchAudit+accountId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	audit := make([]models.KillSwitchModel, 0, len(values))
+
+	for _, value := range values {
+		var state models.KillSwitchModel
+
+		if err = json.Unmarshal([]byte(value), &state); err != nil {
+			return nil, err
+		}
+
+		audit = append(audit, state)
+	}
+
+	return audit, nil
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"trade-order-processing-service/models"
+)
+
+// Audit entries are kept per correlation id: the order id, or the transfer id for settlement tickets.
+// A trail expires with the order events it was recorded with, auditRetention after its last entry.
+const (
+	auditKey       = "audit:"
+	auditRetention = ordersEventsRetention
+)
+
+func (x *TxContainer) appendAudit(ctx context.Context, correlationId string, entry models.AuditEntryModel) *TxContainer {
+	data, err := json.Marshal(entry)
+
+	if err != nil {
+		return x
+	}
+
+	return x.
+		appendInList(ctx, auditKey+correlationId, data).
+		expireKey(ctx, auditKey+correlationId, auditRetention)
+}
+
+func getAudit(ctx context.Context, client *RedisClient, correlationId string) ([]models.AuditEntryModel, error) {
+	values, err := client.getAllFromList(ctx, auditKey+correlationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.AuditEntryModel, 0, len(values))
+
+	for _, value := range values {
+		var entry models.AuditEntryModel
+
+		if err = json.Unmarshal([]byte(value), &entry); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (o *OrdersStorage) AppendOrderAudit(ctx context.Context, orderId string, entry models.AuditEntryModel) error {
+	tx := o.client.performTx(ctx)
+	tx.appendAudit(ctx, orderId, entry)
+
+	return tx.execTx(ctx)
+}
+
+func (o *OrdersStorage) GetAudit(ctx context.Context, correlationId string) ([]models.AuditEntryModel, error) {
+	return getAudit(ctx, o.client, correlationId)
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+
+	redisLib "github.com/redis/go-redis/v9"
+)
+
+// Every order event is queued per pair in the transaction that logs it, a sealer moves the queued records
+// to the pair chain and links them with a keyed hash of the previous hash and the data.
+const (
+	auditChainKey           = "audit:chain:"
+	auditChainPendingKey    = "audit:chain:pending:"
+	auditChainHeadKey       = "audit:chain:head:"
+	auditChainPairsKey      = "audit:chain:pairs"
+	auditChainCheckpointKey = "audit:chain:checkpoint"
+	auditCheckpointClaimKey = "audit:chain:checkpoint:claim:"
+	auditCheckpointClaimTTL = 48 * time.Hour
+	auditChainSealRetries   = 3
+	auditChainData          = "data"
+	auditChainPrev          = "prev"
+	auditChainHash          = "hash"
+)
+
+func (x *TxContainer) appendChainRecord(ctx context.Context, currencyPair string, data []byte) *TxContainer {
+	x.tx.XAdd(ctx, &redisLib.XAddArgs{
+		Stream: auditChainPendingKey + currencyPair,
+		Values: map[string]interface{}{auditChainData: data},
+	})
+
+	return x.addInSet(ctx, auditChainPairsKey, currencyPair)
+}
+
+type AuditChainStorage struct {
+	client *RedisClient
+}
+
+func NewAuditChainStorage(client *RedisClient) *AuditChainStorage {
+	return &AuditChainStorage{client: client}
+}
+
+func (a *AuditChainStorage) GetChainPairs(ctx context.Context) ([]string, error) {
+	return a.client.getSetMembers(ctx, auditChainPairsKey)
+}
+
+func (a *AuditChainStorage) GetChainHead(ctx context.Context, currencyPair string) (string, error) {
+	value, err := a.client.getValue(ctx, auditChainHeadKey+currencyPair)
+
+	if errors.Is(err, redisLib.Nil) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return *value, nil
+}
+
+// SealChainRecords links up to count queued records of the pair to the chain, the head is watched
+// so concurrent sealers can not fork the chain. It returns the number of sealed records.
+func (a *AuditChainStorage) SealChainRecords(ctx context.Context, currencyPair string, count int64, hash func(prevHash string, data []byte) string) (int, error) {
+	headKey := auditChainHeadKey + currencyPair
+	pendingKey := auditChainPendingKey + currencyPair
+	sealed := 0
+
+	seal := func(tx *redisLib.Tx) error {
+		prevHash, err := tx.Get(ctx, headKey).Result()
+
+		if err != nil && !errors.Is(err, redisLib.Nil) {
+			return err
+		}
+
+		messages, err := tx.XRangeN(ctx, pendingKey, "-", "+", count).Result()
+
+		if err != nil || len(messages) == 0 {
+			sealed = 0
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redisLib.Pipeliner) error {
+			ids := make([]string, 0, len(messages))
+
+			for _, message := range messages {
+				data, _ := message.Values[auditChainData].(string)
+				recordHash := hash(prevHash, []byte(data))
+
+				pipe.XAdd(ctx, &redisLib.XAddArgs{
+					Stream: auditChainKey + currencyPair,
+					Values: []interface{}{auditChainData, data, auditChainPrev, prevHash, auditChainHash, recordHash},
+				})
+
+				prevHash = recordHash
+				ids = append(ids, message.ID)
+			}
+
+			pipe.Set(ctx, headKey, prevHash, 0)
+			pipe.XDel(ctx, pendingKey, ids...)
+
+			return nil
+		})
+
+		sealed = len(messages)
+		return err
+	}
+
+	var err error
+
+	for i := 0; i < auditChainSealRetries; i++ {
+		if err = a.client.cli.Watch(ctx, seal, headKey); !errors.Is(err, redisLib.TxFailedErr) {
+			break
+		}
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return sealed, nil
+}
+
+// ReadChainRecords returns up to count records after the given stream id, "0" starts from the beginning.
+func (a *AuditChainStorage) ReadChainRecords(ctx context.Context, currencyPair string, afterId string, count int64) ([]models.AuditChainRecord, error) {
+	messages, err := a.client.cli.XRangeN(ctx, auditChainKey+currencyPair, "("+afterId, "+", count).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]models.AuditChainRecord, 0, len(messages))
+
+	for _, message := range messages {
+		data, okData := message.Values[auditChainData].(string)
+		prev, okPrev := message.Values[auditChainPrev].(string)
+		hash, okHash := message.Values[auditChainHash].(string)
+
+		if !okData || !okPrev || !okHash {
+			return nil, staticerr.ErrorAuditChainIsBroken
+		}
+
+		records = append(records, models.AuditChainRecord{Id: message.ID, Data: []byte(data), PrevHash: prev, Hash: hash})
+	}
+
+	return records, nil
+}
+
+// GetLastCheckpoint returns the last exported checkpoint, nil when none was exported yet.
+func (a *AuditChainStorage) GetLastCheckpoint(ctx context.Context) (*models.AuditCheckpoint, error) {
+	value, err := a.client.getValue(ctx, auditChainCheckpointKey)
+
+	if errors.Is(err, redisLib.Nil) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := models.AuditCheckpoint{}
+
+	if err = json.Unmarshal([]byte(*value), &checkpoint); err != nil {
+		return nil, err
+	}
+
+	return &checkpoint, nil
+}
+
+func (a *AuditChainStorage) SaveLastCheckpoint(ctx context.Context, checkpoint models.AuditCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+
+	if err != nil {
+		return err
+	}
+
+	return a.client.setValue(ctx, auditChainCheckpointKey, data)
+}
+
+// ClaimCheckpoint makes sure only one replica exports the checkpoint of a day.
+func (a *AuditChainStorage) ClaimCheckpoint(ctx context.Context, date string) (bool, error) {
+	return a.client.cli.SetNX(ctx, auditCheckpointClaimKey+date, 1, auditCheckpointClaimTTL).Result()
+}
+
+func (a *AuditChainStorage) ReleaseCheckpoint(ctx context.Context, date string) error {
+	return a.client.deleteKey(ctx, auditCheckpointClaimKey+date)
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+)
+
+func testChainHash(prevHash string, data []byte) string {
+	return prevHash + "+" + string(data[:1])
+}
+
+func TestAuditChainStorage_SealChainRecords(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	tests := []struct {
+		name       string
+		events     int
+		count      int64
+		wantSealed []int
+	}{
+		{name: "nothing queued", events: 0, count: 10, wantSealed: []int{0}},
+		{name: "queued records are sealed at once", events: 3, count: 10, wantSealed: []int{3, 0}},
+		{name: "queued records are sealed in batches", events: 3, count: 2, wantSealed: []int{2, 1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, _ := newTestRedisClient(t)
+			o := NewOrdersStorage(client)
+			a := NewAuditChainStorage(client)
+
+			for i := 0; i < tt.events; i++ {
+				if err := o.AppendOrderEvent(ctx, models.OrderLogRejected, newTestOrder("order", sell, 100, 1, int64(i))); err != nil {
+					t.Fatalf("AppendOrderEvent() error = %v", err)
+				}
+			}
+
+			for _, want := range tt.wantSealed {
+				sealed, err := a.SealChainRecords(ctx, testPair, tt.count, testChainHash)
+
+				if err != nil {
+					t.Fatalf("SealChainRecords() error = %v", err)
+				}
+
+				if sealed != want {
+					t.Fatalf("SealChainRecords() = %v, want %v", sealed, want)
+				}
+			}
+
+			records, err := a.ReadChainRecords(ctx, testPair, "0", 10)
+
+			if err != nil {
+				t.Fatalf("ReadChainRecords() error = %v", err)
+			}
+
+			if len(records) != tt.events {
+				t.Fatalf("ReadChainRecords() = %v records, want %v", len(records), tt.events)
+			}
+
+			prevHash := ""
+
+			for i, record := range records {
+				if record.PrevHash != prevHash || record.Hash != testChainHash(prevHash, record.Data) {
+					t.Errorf("record %v = %+v is not linked to %v", i, record, prevHash)
+				}
+
+				prevHash = record.Hash
+			}
+
+			head, err := a.GetChainHead(ctx, testPair)
+
+			if err != nil {
+				t.Fatalf("GetChainHead() error = %v", err)
+			}
+
+			if head != prevHash {
+				t.Errorf("GetChainHead() = %v, want %v", head, prevHash)
+			}
+
+			if pending, _ := client.cli.XLen(ctx, auditChainPendingKey+testPair).Result(); pending != 0 {
+				t.Errorf("pending records = %v, want none", pending)
+			}
+		})
+	}
+}
+
+func TestAuditChainStorage_ClaimCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestRedisClient(t)
+	a := NewAuditChainStorage(client)
+
+	if claimed, err := a.ClaimCheckpoint(ctx, "2024-01-01"); err != nil || !claimed {
+		t.Fatalf("ClaimCheckpoint() = %v, %v, want claimed", claimed, err)
+	}
+
+	if claimed, _ := a.ClaimCheckpoint(ctx, "2024-01-01"); claimed {
+		t.Errorf("ClaimCheckpoint() claimed the day twice")
+	}
+
+	if err := a.ReleaseCheckpoint(ctx, "2024-01-01"); err != nil {
+		t.Fatalf("ReleaseCheckpoint() error = %v", err)
+	}
+
+	if claimed, _ := a.ClaimCheckpoint(ctx, "2024-01-01"); !claimed {
+		t.Errorf("ClaimCheckpoint() must claim a released day")
+	}
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+	"trade-order-processing-service/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	candlesIndexKey  = "market:candles:%s:%s"
+	candleKey        = "market:candles:%s:%s:%d"
+	candleClosedKey  = "market:candles:closed:%s:%s:%d"
+	candleClosedTime = 24 * time.Hour
+)
+
+// candleUpdateScript applies a trade to the candle hash. Out of order trades only move open and close
+// when they are earlier or later than the ones already applied. The candle expires after the retention
+// and older open times are trimmed from the index.
+var candleUpdateScript = redis.NewScript(`
+local price = tonumber(ARGV[1])
+local ts = tonumber(ARGV[3])
+if redis.call('HSETNX', KEYS[1], 'open', ARGV[1]) == 1 then
+	redis.call('HSET', KEYS[1], 'high', ARGV[1], 'low', ARGV[1], 'close', ARGV[1], 'open_ts', ARGV[3], 'close_ts', ARGV[3])
+	redis.call('ZADD', KEYS[2], ARGV[4], ARGV[4])
+else
+	if price > tonumber(redis.call('HGET', KEYS[1], 'high')) then
+		redis.call('HSET', KEYS[1], 'high', ARGV[1])
+	end
+	if price < tonumber(redis.call('HGET', KEYS[1], 'low')) then
+		redis.call('HSET', KEYS[1], 'low', ARGV[1])
+	end
+	if ts < tonumber(redis.call('HGET', KEYS[1], 'open_ts')) then
+		redis.call('HSET', KEYS[1], 'open', ARGV[1], 'open_ts', ARGV[3])
+	end
+	if ts >= tonumber(redis.call('HGET', KEYS[1], 'close_ts')) then
+		redis.call('HSET', KEYS[1], 'close', ARGV[1], 'close_ts', ARGV[3])
+	end
+end
+redis.call('HINCRBYFLOAT', KEYS[1], 'volume', ARGV[2])
+redis.call('HINCRBYFLOAT', KEYS[1], 'quote_volume', ARGV[5])
+redis.call('HINCRBY', KEYS[1], 'trades', 1)
+redis.call('PEXPIREAT', KEYS[1], ARGV[6])
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', '(' .. ARGV[7])
+return 1
+`)
+
+type CandleStorage struct {
+	client *RedisClient
+}
+
+func NewCandleStorage(client *RedisClient) *CandleStorage {
+	return &CandleStorage{client: client}
+}
+
+func (c *CandleStorage) AddTradeToCandle(ctx context.Context, tradeInfo models.TradeModel, interval string, openTime int64, retention time.Duration) error {
+	keys := []string{
+		fmt.Sprintf(candleKey, tradeInfo.CurrencyPair, interval, openTime),
+		fmt.Sprintf(candlesIndexKey, tradeInfo.CurrencyPair, interval),
+	}
+
+	return candleUpdateScript.Run(ctx, c.client.cli, keys, tradeInfo.Price, tradeInfo.Volume, tradeInfo.TradeDate, openTime, tradeInfo.Price*tradeInfo.Volume,
+		openTime+retention.Milliseconds(), openTime-retention.Milliseconds()).Err()
+}
+
+func (c *CandleStorage) GetCandles(ctx context.Context, currencyPair string, interval string, from int64, to int64) ([]models.CandleModel, error) {
+	openTimes, err := c.client.cli.ZRangeByScore(ctx, fmt.Sprintf(candlesIndexKey, currencyPair, interval), &redis.ZRangeBy{
+		Min: strconv.FormatInt(from, 10),
+		Max: strconv.FormatInt(to, 10),
+	}).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := c.client.cli.Pipeline()
+	cmds := make(map[int64]*redis.MapStringStringCmd, len(openTimes))
+	order := make([]int64, 0, len(openTimes))
+
+	for _, value := range openTimes {
+		openTime, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cmds[openTime] = pipe.HGetAll(ctx, fmt.Sprintf(candleKey, currencyPair, interval, openTime))
+		order = append(order, openTime)
+	}
+
+	if _, err = pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	candles := make([]models.CandleModel, 0, len(order))
+
+	for _, openTime := range order {
+		values := cmds[openTime].Val()
+
+		if len(values) == 0 {
+			continue
+		}
+
+		candles = append(candles, parseCandle(currencyPair, interval, openTime, values))
+	}
+
+	return candles, nil
+}
+
+// GetCandle returns nil when there was no trade in the interval.
+func (c *CandleStorage) GetCandle(ctx context.Context, currencyPair string, interval string, openTime int64) (*models.CandleModel, error) {
+	values, err := c.client.getAllFromHash(ctx, fmt.Sprintf(candleKey, currencyPair, interval, openTime))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	candle := parseCandle(currencyPair, interval, openTime, values)
+
+	return &candle, nil
+}
+
+func parseCandle(currencyPair string, interval string, openTime int64, values map[string]string) models.CandleModel {
+	candle := models.CandleModel{CurrencyPair: currencyPair, Interval: interval, OpenTime: openTime}
+	candle.Open, _ = strconv.ParseFloat(values["open"], 64)
+	candle.High, _ = strconv.ParseFloat(values["high"], 64)
+	candle.Low, _ = strconv.ParseFloat(values["low"], 64)
+	candle.Close, _ = strconv.ParseFloat(values["close"], 64)
+	candle.Volume, _ = strconv.ParseFloat(values["volume"], 64)
+	candle.QuoteVolume, _ = strconv.ParseFloat(values["quote_volume"], 64)
+	candle.TradeCount, _ = strconv.ParseInt(values["trades"], 10, 64)
+
+	return candle
+}
+
+// ClaimClosedCandle makes sure only one replica publishes a closed candle.
+func (c *CandleStorage) ClaimClosedCandle(ctx context.Context, currencyPair string, interval string, openTime int64) (bool, error) {
+	return c.client.cli.SetNX(ctx, fmt.Sprintf(candleClosedKey, currencyPair, interval, openTime), 1, candleClosedTime).Result()
+}
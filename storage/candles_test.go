@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+	"trade-order-processing-service/models"
+)
+
+func TestCandleStorage_AddTradeToCandle(t *testing.T) {
+	openTime := time.Now().UTC().Truncate(time.Minute).UnixMilli()
+	trade := func(price, volume float64, offset int64) models.TradeModel {
+		return models.TradeModel{CurrencyPair: testPair, Price: price, Volume: volume, TradeDate: openTime + offset}
+	}
+	tests := []struct {
+		name   string
+		trades []models.TradeModel
+		want   models.CandleModel
+	}{
+		{
+			name:   "single trade",
+			trades: []models.TradeModel{trade(100, 2, 10)},
+			want:   models.CandleModel{Open: 100, High: 100, Low: 100, Close: 100, Volume: 2, QuoteVolume: 200, TradeCount: 1},
+		},
+		{
+			name:   "trades in order",
+			trades: []models.TradeModel{trade(100, 1, 10), trade(105, 1, 20), trade(95, 1, 30), trade(101, 1, 40)},
+			want:   models.CandleModel{Open: 100, High: 105, Low: 95, Close: 101, Volume: 4, QuoteVolume: 401, TradeCount: 4},
+		},
+		{
+			name:   "late trades move open and close only by their time",
+			trades: []models.TradeModel{trade(100, 1, 20), trade(90, 1, 10), trade(110, 1, 15), trade(102, 1, 30)},
+			want:   models.CandleModel{Open: 90, High: 110, Low: 90, Close: 102, Volume: 4, QuoteVolume: 402, TradeCount: 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, server := newTestRedisClient(t)
+			c := NewCandleStorage(client)
+
+			for _, tradeInfo := range tt.trades {
+				if err := c.AddTradeToCandle(ctx, tradeInfo, models.CandleInterval1m, openTime, time.Hour); err != nil {
+					t.Fatalf("AddTradeToCandle() error = %v", err)
+				}
+			}
+
+			got, err := c.GetCandle(ctx, testPair, models.CandleInterval1m, openTime)
+
+			if err != nil || got == nil {
+				t.Fatalf("GetCandle() = %v, %v", got, err)
+			}
+
+			tt.want.CurrencyPair, tt.want.Interval, tt.want.OpenTime = testPair, models.CandleInterval1m, openTime
+
+			if *got != tt.want {
+				t.Errorf("GetCandle() = %+v, want %+v", *got, tt.want)
+			}
+
+			if ttl := server.TTL(fmt.Sprintf(candleKey, testPair, models.CandleInterval1m, openTime)); ttl <= 0 || ttl > time.Hour {
+				t.Errorf("candle ttl = %v, want up to %v", ttl, time.Hour)
+			}
+		})
+	}
+}
+
+func TestCandleStorage_AddTradeToCandle_retention(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestRedisClient(t)
+	c := NewCandleStorage(client)
+	now := time.Now().UTC().Truncate(time.Minute)
+	tests := []struct {
+		name     string
+		openTime time.Time
+		want     int
+	}{
+		{name: "old candle is indexed", openTime: now.Add(-2 * time.Hour), want: 1},
+		{name: "candle within retention is kept", openTime: now.Add(-61 * time.Minute), want: 2},
+		{name: "candles out of retention are trimmed", openTime: now, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tradeInfo := models.TradeModel{CurrencyPair: testPair, Price: 100, Volume: 1, TradeDate: tt.openTime.UnixMilli()}
+
+			if err := c.AddTradeToCandle(ctx, tradeInfo, models.CandleInterval1m, tt.openTime.UnixMilli(), time.Hour); err != nil {
+				t.Fatalf("AddTradeToCandle() error = %v", err)
+			}
+
+			count, err := client.cli.ZCard(ctx, fmt.Sprintf(candlesIndexKey, testPair, models.CandleInterval1m)).Result()
+
+			if err != nil || int(count) != tt.want {
+				t.Errorf("indexed candles = %v, %v, want %v", count, err, tt.want)
+			}
+		})
+	}
+}
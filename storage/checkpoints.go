@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"trade-order-processing-service/models"
+)
+
+const checkpointFileName = "audit_checkpoint_%s.json"
+
+type CheckpointStorage struct {
+	dir string
+}
+
+func NewCheckpointStorage(dir string) *CheckpointStorage {
+	return &CheckpointStorage{dir: dir}
+}
+
+func (c *CheckpointStorage) SaveCheckpoint(checkpoint models.AuditCheckpoint) (string, error) {
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+
+	if err != nil {
+		return "", err
+	}
+
+	if err = os.MkdirAll(c.dir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(c.dir, fmt.Sprintf(checkpointFileName, checkpoint.Date))
+	tmpPath := path + ".tmp"
+
+	if err = os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return "", err
+	}
+
+	if err = os.Rename(tmpPath, path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
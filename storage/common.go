@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"time"
 	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/utils"
 
 	redisLib "github.com/redis/go-redis/v9"
 	logger "github.com/sirupsen/logrus"
@@ -81,6 +83,24 @@ func (r *RedisClient) deleteWithValue(ctx context.Context, key string, value int
 	return nil
 }
 
+func (r *RedisClient) increment(ctx context.Context, key string) (int64, error) {
+	return r.cli.Incr(ctx, key).Result()
+}
+
+func (r *RedisClient) getValue(ctx context.Context, key string) (*string, error) {
+	value, err := r.cli.Get(ctx, key).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+func (r *RedisClient) setValue(ctx context.Context, key string, value interface{}) error {
+	return r.cli.Set(ctx, key, value, 0).Err()
+}
+
 func (r *RedisClient) deleteKey(ctx context.Context, id string) error {
 	_, err := r.cli.Del(ctx, id).Result()
 
@@ -97,6 +117,23 @@ func (x *TxContainer) addInZSet(ctx context.Context, key string, value interface
 	return x
 }
 
+// addInZSetNX keeps the score of a member that is already in the set.
+func (x *TxContainer) addInZSetNX(ctx context.Context, key string, value interface{}, weight float64) *TxContainer {
+	x.tx.ZAddNX(ctx, key, redisLib.Z{Score: weight, Member: value})
+
+	return x
+}
+
+func (r *RedisClient) getFromZSet(ctx context.Context, key string) ([]string, error) {
+	values, err := r.cli.ZRange(ctx, key, 0, -1).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
 func (x *TxContainer) removeFromZSet(ctx context.Context, key string, value interface{}) *TxContainer {
 	x.tx.ZRem(ctx, key, value)
 
@@ -125,6 +162,50 @@ func (r *RedisClient) addInSet(ctx context.Context, key string, value interface{
 	return nil
 }
 
+func (r *RedisClient) getSetMembers(ctx context.Context, key string) ([]string, error) {
+	values, err := r.cli.SMembers(ctx, key).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func (r *RedisClient) popFromSet(ctx context.Context, key string, count int64) ([]string, error) {
+	values, err := r.cli.SPopN(ctx, key, count).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func (r *RedisClient) isSetMember(ctx context.Context, key string, value interface{}) (bool, error) {
+	return r.cli.SIsMember(ctx, key, value).Result()
+}
+
+func (r *RedisClient) getSetsIntersection(ctx context.Context, keys ...string) ([]string, error) {
+	values, err := r.cli.SInter(ctx, keys...).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func (r *RedisClient) getSetsUnion(ctx context.Context, keys ...string) ([]string, error) {
+	values, err := r.cli.SUnion(ctx, keys...).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
 func (r *RedisClient) removeFromSet(ctx context.Context, key string, value interface{}) error {
 	_, err := r.cli.SRem(ctx, key, value).Result()
 
@@ -171,6 +252,43 @@ func (r *RedisClient) getAllFromHash(ctx context.Context, key string) (map[strin
 	return value, nil
 }
 
+func (r *RedisClient) getManyFromHash(ctx context.Context, key string, fields ...string) ([]string, error) {
+	values, err := r.cli.HMGet(ctx, key, fields...).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(values))
+
+	for _, value := range values {
+		if strValue, ok := value.(string); ok {
+			result = append(result, strValue)
+		}
+	}
+
+	return result, nil
+}
+
+// getFieldsFromHash keeps the fields order, a missing field comes back as nil.
+func (r *RedisClient) getFieldsFromHash(ctx context.Context, key string, fields ...string) ([]*string, error) {
+	values, err := r.cli.HMGet(ctx, key, fields...).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*string, len(values))
+
+	for i, value := range values {
+		if strValue, ok := value.(string); ok {
+			result[i] = &strValue
+		}
+	}
+
+	return result, nil
+}
+
 func (r *RedisClient) removeFromHash(ctx context.Context, key string, field string) error {
 	_, err := r.cli.HDel(ctx, key, field).Result()
 
@@ -197,6 +315,38 @@ func (r *RedisClient) addInList(ctx context.Context, key string, value interface
 	return nil
 }
 
+func (r *RedisClient) appendInList(ctx context.Context, key string, value interface{}) error {
+	_, err := r.cli.RPush(ctx, key, value).Result()
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (x *TxContainer) addInList(ctx context.Context, key string, value interface{}) *TxContainer {
+	x.tx.LPush(ctx, key, value)
+
+	return x
+}
+
+func (x *TxContainer) appendInList(ctx context.Context, key string, value interface{}) *TxContainer {
+	x.tx.RPush(ctx, key, value)
+
+	return x
+}
+
+func (r *RedisClient) getAllFromList(ctx context.Context, key string) ([]string, error) {
+	values, err := r.cli.LRange(ctx, key, 0, -1).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
 func (r *RedisClient) getFromList(ctx context.Context, key string) (*string, error) {
 	value, err := r.cli.LPop(ctx, key).Result()
 
@@ -226,3 +376,69 @@ func (x *TxContainer) incrementHash(ctx context.Context, key, field string, valu
 	x.tx.HIncrByFloat(ctx, key, field, value)
 	return x
 }
+
+// stockLevelScript changes the level volume and keeps the level in the price index only while it has volume.
+var stockLevelScript = redisLib.NewScript(`
+local volume = tonumber(redis.call('HINCRBYFLOAT', KEYS[1], ARGV[1], ARGV[2]))
+if volume > tonumber(ARGV[4]) then
+	redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+else
+	redis.call('ZREM', KEYS[2], ARGV[1])
+end
+return tostring(volume)
+`)
+
+func (x *TxContainer) changeStockLevel(ctx context.Context, stockKey, levelsKey string, price float64, volume float64) *TxContainer {
+	stockLevelScript.Eval(ctx, x.tx, []string{stockKey, levelsKey}, fmt.Sprintf("%f", price), volume, price, utils.VolumeEpsilon)
+	return x
+}
+
+func (x *TxContainer) incrementHashInt(ctx context.Context, key, field string, value int64) *TxContainer {
+	x.tx.HIncrBy(ctx, key, field, value)
+	return x
+}
+
+func (x *TxContainer) removeFromZSetByScore(ctx context.Context, key string, min, max string) *TxContainer {
+	x.tx.ZRemRangeByScore(ctx, key, min, max)
+	return x
+}
+
+func (x *TxContainer) expireKey(ctx context.Context, key string, ttl time.Duration) *TxContainer {
+	x.tx.PExpire(ctx, key, ttl)
+	return x
+}
+
+func (x *TxContainer) deleteKey(ctx context.Context, key string) *TxContainer {
+	x.tx.Del(ctx, key)
+	return x
+}
+
+func (r *RedisClient) scanKeys(ctx context.Context, pattern string) ([]string, error) {
+	keys := make([]string, 0)
+	iter := r.cli.Scan(ctx, 0, pattern, 1000).Iterator()
+
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+
+	return keys, iter.Err()
+}
+
+// scanHash walks the hash in batches without loading it whole.
+func (r *RedisClient) scanHash(ctx context.Context, key string, handle func(field, value string) error) error {
+	iter := r.cli.HScan(ctx, key, 0, "", 1000).Iterator()
+
+	for iter.Next(ctx) {
+		field := iter.Val()
+
+		if !iter.Next(ctx) {
+			break
+		}
+
+		if err := handle(field, iter.Val()); err != nil {
+			return err
+		}
+	}
+
+	return iter.Err()
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedisClient(t *testing.T) (*RedisClient, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client, err := NewRedisClient(server.Addr())
+
+	if err != nil {
+		t.Fatalf("NewRedisClient() error = %v", err)
+	}
+
+	t.Cleanup(func() { client.cli.Close() })
+
+	return client, server
+}
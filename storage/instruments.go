@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"trade-order-processing-service/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	instrumentsHashKey = "instruments"
+)
+
+type InstrumentStorage struct {
+	client *RedisClient
+}
+
+func NewInstrumentStorage(client *RedisClient) *InstrumentStorage {
+	return &InstrumentStorage{client: client}
+}
+
+// GetInstrumentSettings reads the stored settings over the defaults, so fields a partial config leaves out,
+// or stores as zero, keep their default value.
+func (i *InstrumentStorage) GetInstrumentSettings(ctx context.Context, currencyPair string) (*models.InstrumentSettings, error) {
+	settings := models.NewDefaultInstrumentSettings(currencyPair)
+	jsonData, err := i.client.getFromHash(ctx, instrumentsHashKey, currencyPair)
+
+	if errors.Is(err, redis.Nil) {
+		return &settings, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal([]byte(*jsonData), &settings); err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+func (i *InstrumentStorage) SetInstrumentSettings(ctx context.Context, settings models.InstrumentSettings) error {
+	jsonData, err := json.Marshal(settings)
+
+	if err != nil {
+		return err
+	}
+
+	return i.client.addInHash(ctx, instrumentsHashKey, settings.CurrencyPair, jsonData)
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"trade-order-processing-service/models"
+)
+
+func TestInstrumentStorage_GetInstrumentSettings(t *testing.T) {
+	defaults := models.NewDefaultInstrumentSettings(testPair)
+	tests := []struct {
+		name         string
+		stored       string
+		wantSlippage float64
+		wantBuffer   float64
+		wantLotSize  float64
+	}{
+		{
+			name:         "unconfigured pair gets the defaults",
+			wantSlippage: defaults.MaxSlippageBps,
+			wantBuffer:   defaults.LockBufferBps,
+		},
+		{
+			name:         "partial config keeps the defaults of missing fields",
+			stored:       `{"currency_pair":"BTC/USDT","lot_size":0.5}`,
+			wantSlippage: defaults.MaxSlippageBps,
+			wantBuffer:   defaults.LockBufferBps,
+			wantLotSize:  0.5,
+		},
+		{
+			name:         "configured fields override the defaults",
+			stored:       `{"currency_pair":"BTC/USDT","max_slippage_bps":25,"lock_buffer_bps":10}`,
+			wantSlippage: 25,
+			wantBuffer:   10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, _ := newTestRedisClient(t)
+			i := NewInstrumentStorage(client)
+
+			if tt.stored != "" {
+				client.cli.HSet(ctx, instrumentsHashKey, testPair, tt.stored)
+			}
+
+			got, err := i.GetInstrumentSettings(ctx, testPair)
+
+			if err != nil {
+				t.Fatalf("GetInstrumentSettings() error = %v", err)
+			}
+
+			if got.CurrencyPair != testPair || got.MaxSlippageBps != tt.wantSlippage || got.LockBufferBps != tt.wantBuffer || got.LotSize != tt.wantLotSize {
+				t.Errorf("GetInstrumentSettings() = %+v, want slippage %v, buffer %v, lot size %v", got, tt.wantSlippage, tt.wantBuffer, tt.wantLotSize)
+			}
+		})
+	}
+}
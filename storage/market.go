@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	marketStatusHashKey    = "market:status"
+	marketLastPriceHashKey = "market:last_price"
+	marketPricesKey        = "market:prices:"
+	marketQueuedOrdersKey  = "market:queue:orders:"
+	marketQueuedMatchesKey = "market:queue:matches:"
+	marketQueuedPairsKey   = "market:queue:pairs"
+)
+
+type MarketStorage struct {
+	client *RedisClient
+}
+
+func NewMarketStorage(client *RedisClient) *MarketStorage {
+	return &MarketStorage{client: client}
+}
+
+func (m *MarketStorage) GetPairStatus(ctx context.Context, currencyPair string) (*models.PairStatusModel, error) {
+	jsonData, err := m.client.getFromHash(ctx, marketStatusHashKey, currencyPair)
+
+	if errors.Is(err, redis.Nil) {
+		return &models.PairStatusModel{CurrencyPair: currencyPair, Status: models.PairStatusOpen}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var status models.PairStatusModel
+
+	if err = json.Unmarshal([]byte(*jsonData), &status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+func (m *MarketStorage) GetPairStatuses(ctx context.Context) ([]models.PairStatusModel, error) {
+	values, err := m.client.getAllFromHash(ctx, marketStatusHashKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]models.PairStatusModel, 0, len(values))
+
+	for _, jsonData := range values {
+		var status models.PairStatusModel
+
+		if err = json.Unmarshal([]byte(jsonData), &status); err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (m *MarketStorage) SetPairStatus(ctx context.Context, status models.PairStatusModel) error {
+	jsonData, err := json.Marshal(status)
+
+	if err != nil {
+		return err
+	}
+
+	return m.client.addInHash(ctx, marketStatusHashKey, status.CurrencyPair, jsonData)
+}
+
+func (m *MarketStorage) GetLastPrice(ctx context.Context, currencyPair string) (float64, error) {
+	value, err := m.client.getFromHash(ctx, marketLastPriceHashKey, currencyPair)
+
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(*value, 64)
+}
+
+// AddTradePrice saves the trade as last price and returns all trade prices inside the window.
+func (m *MarketStorage) AddTradePrice(ctx context.Context, tradeInfo models.TradeModel, window time.Duration) ([]float64, error) {
+	windowStart := tradeInfo.TradeDate - window.Milliseconds()
+
+	tx := m.client.performTx(ctx)
+
+	err := tx.
+		addInHash(ctx, marketLastPriceHashKey, tradeInfo.CurrencyPair, tradeInfo.Price).
+		addInZSet(ctx, marketPricesKey+tradeInfo.CurrencyPair, fmt.Sprintf("%s:%f", tradeInfo.TradeId, tradeInfo.Price), float64(tradeInfo.TradeDate)).
+		removeFromZSetByScore(ctx, marketPricesKey+tradeInfo.CurrencyPair, "-inf", fmt.Sprintf("(%d", windowStart)).
+		execTx(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := m.client.getFromZSet(ctx, marketPricesKey+tradeInfo.CurrencyPair)
+
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make([]float64, 0, len(members))
+
+	for _, member := range members {
+		price, err := strconv.ParseFloat(member[strings.LastIndex(member, ":")+1:], 64)
+
+		if err != nil {
+			continue
+		}
+
+		prices = append(prices, price)
+	}
+
+	return prices, nil
+}
+
+func (m *MarketStorage) QueueOrderRequest(ctx context.Context, currencyPair string, request []byte) error {
+	tx := m.client.performTx(ctx)
+
+	return tx.appendInList(ctx, marketQueuedOrdersKey+currencyPair, request).
+		addInSet(ctx, marketQueuedPairsKey, currencyPair).
+		execTx(ctx)
+}
+
+func (m *MarketStorage) PopQueuedOrderRequest(ctx context.Context, currencyPair string) ([]byte, error) {
+	value, err := m.client.getFromList(ctx, marketQueuedOrdersKey+currencyPair)
+
+	if errors.Is(err, redis.Nil) {
+		return nil, staticerr.ErrorQueueIsEmpty
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(*value), nil
+}
+
+func (m *MarketStorage) QueueMatch(ctx context.Context, currencyPair string, orderId string) error {
+	tx := m.client.performTx(ctx)
+
+	return tx.appendInList(ctx, marketQueuedMatchesKey+currencyPair, orderId).
+		addInSet(ctx, marketQueuedPairsKey, currencyPair).
+		execTx(ctx)
+}
+
+// GetQueuedPairs returns the pairs that ever had an order request or a match queued.
+func (m *MarketStorage) GetQueuedPairs(ctx context.Context) ([]string, error) {
+	return m.client.getSetMembers(ctx, marketQueuedPairsKey)
+}
+
+func (m *MarketStorage) PopQueuedMatch(ctx context.Context, currencyPair string) (string, error) {
+	value, err := m.client.getFromList(ctx, marketQueuedMatchesKey+currencyPair)
+
+	if errors.Is(err, redis.Nil) {
+		return "", staticerr.ErrorQueueIsEmpty
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return *value, nil
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"trade-order-processing-service/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	marketDepthSequenceKey     = "market:depth:seq:"
+	marketOrderFeedSequenceKey = "market:orders:seq:"
+	marketTickerChangedKey     = "market:ticker:changed"
+	marketTickerPopCount       = 1000
+)
+
+// depthLevelScript reads the level volume and takes the next depth sequence in one step, so two updates
+// of the same level can not be published with their volumes and sequences crossed.
+var depthLevelScript = redis.NewScript(`
+local volume = redis.call('HGET', KEYS[1], ARGV[1])
+local sequence = redis.call('INCR', KEYS[2])
+return {volume or '0', sequence}
+`)
+
+type MarketDataStorage struct {
+	client *RedisClient
+}
+
+func NewMarketDataStorage(client *RedisClient) *MarketDataStorage {
+	return &MarketDataStorage{client: client}
+}
+
+// NextDepthLevel returns the current volume of the price level together with the next depth sequence.
+func (m *MarketDataStorage) NextDepthLevel(ctx context.Context, currencyPair string, direction int, price float64) (*models.PriceLevel, int64, error) {
+	keys := []string{buildStockKey(currencyPair, direction), marketDepthSequenceKey + currencyPair}
+	values, err := depthLevelScript.Run(ctx, m.client.cli, keys, fmt.Sprintf("%f", price)).Slice()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(values) != 2 {
+		return nil, 0, fmt.Errorf("unexpected depth level reply: %v", values)
+	}
+
+	volume, err := strconv.ParseFloat(fmt.Sprint(values[0]), 64)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sequence, ok := values[1].(int64)
+
+	if !ok {
+		return nil, 0, fmt.Errorf("unexpected depth sequence: %v", values[1])
+	}
+
+	return &models.PriceLevel{Price: price, Volume: volume}, sequence, nil
+}
+
+func (m *MarketDataStorage) GetDepthSequence(ctx context.Context, currencyPair string) (int64, error) {
+	value, err := m.client.getValue(ctx, marketDepthSequenceKey+currencyPair)
+
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(*value, 10, 64)
+}
+
+func (m *MarketDataStorage) MarkTickerChanged(ctx context.Context, currencyPair string) error {
+	return m.client.addInSet(ctx, marketTickerChangedKey, currencyPair)
+}
+
+func (m *MarketDataStorage) PopChangedTickers(ctx context.Context) ([]string, error) {
+	return m.client.popFromSet(ctx, marketTickerChangedKey, marketTickerPopCount)
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"trade-order-processing-service/external/ops"
+)
+
+func TestMarketDataStorage_NextDepthLevel(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	tests := []struct {
+		name         string
+		price        float64
+		wantVolume   float64
+		wantSequence int64
+	}{
+		{name: "booked level", price: 100, wantVolume: 3, wantSequence: 1},
+		{name: "empty level", price: 101, wantVolume: 0, wantSequence: 2},
+		{name: "sequence keeps growing", price: 100, wantVolume: 3, wantSequence: 3},
+	}
+	client, _ := newTestRedisClient(t)
+	bookTestOrders(t, NewOrdersStorage(client), newTestOrder("ask-1", sell, 100, 1, 1), newTestOrder("ask-2", sell, 100, 2, 2))
+	m := NewMarketDataStorage(client)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, sequence, err := m.NextDepthLevel(context.Background(), testPair, int(sell), tt.price)
+
+			if err != nil {
+				t.Fatalf("NextDepthLevel() error = %v", err)
+			}
+
+			if level.Price != tt.price || level.Volume != tt.wantVolume || sequence != tt.wantSequence {
+				t.Errorf("NextDepthLevel() = %+v, %v, want %v, %v", *level, sequence, tt.wantVolume, tt.wantSequence)
+			}
+		})
+	}
+}
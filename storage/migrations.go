@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/utils"
+)
+
+// Migrations rebuild keys derived from the orders and trades hashes for entries written before the keys existed.
+// They run once through cmd/migrate while order intake is stopped.
+
+const ordersIndexMigrationBatch = 1000
+
+func (o *OrdersStorage) scanOrders(ctx context.Context, handle func(orderInfo models.OrderModel) error) error {
+	return o.client.scanHash(ctx, ordersHashKey, func(_, jsonData string) error {
+		var orderInfo models.OrderModel
+
+		if err := json.Unmarshal([]byte(jsonData), &orderInfo); err != nil {
+			return err
+		}
+
+		return handle(orderInfo)
+	})
+}
+
+// RebuildRiskExposure recomputes every account exposure from its open orders and returns the number of accounts.
+func (o *OrdersStorage) RebuildRiskExposure(ctx context.Context) (int, error) {
+	exposures := make(map[string][]models.OrderModel)
+
+	err := o.scanOrders(ctx, func(orderInfo models.OrderModel) error {
+		if isOrderOpen(orderInfo) {
+			exposures[orderInfo.AccountId] = append(exposures[orderInfo.AccountId], orderInfo)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	keys, err := o.client.scanKeys(ctx, riskAccountKey+"*")
+
+	if err != nil {
+		return 0, err
+	}
+
+	tx := o.client.performTx(ctx)
+
+	for _, key := range keys {
+		tx.deleteKey(ctx, key)
+	}
+
+	for _, orders := range exposures {
+		for i := range orders {
+			changeExposureTx(ctx, &tx, nil, &orders[i])
+		}
+	}
+
+	return len(exposures), tx.execTx(ctx)
+}
+
+// RebuildAccountBookIndex adds booked orders to their account index and returns the number of orders added.
+func (o *OrdersStorage) RebuildAccountBookIndex(ctx context.Context) (int, error) {
+	tx := o.client.performTx(ctx)
+	count := 0
+
+	err := o.scanOrders(ctx, func(orderInfo models.OrderModel) error {
+		booked, err := o.IsInStockBook(ctx, orderInfo)
+
+		if err != nil || !booked {
+			return err
+		}
+
+		tx.addInSet(ctx, ordersAccountKey+orderInfo.AccountId, orderInfo.OrderId)
+		count++
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, tx.execTx(ctx)
+}
+
+// RebuildOrderIndexes indexes every stored order per account and per state and returns the number of orders indexed.
+// UpdatedDate stored in seconds is rewritten in milliseconds, orders already in a state index keep their date.
+func (o *OrdersStorage) RebuildOrderIndexes(ctx context.Context) (int, error) {
+	tx := o.client.performTx(ctx)
+	count := 0
+
+	err := o.scanOrders(ctx, func(orderInfo models.OrderModel) error {
+		if orderInfo.UpdatedDate > 0 && orderInfo.UpdatedDate < secondsDateLimit {
+			orderInfo.UpdatedDate = toMilliseconds(orderInfo.UpdatedDate)
+			jsonData, err := json.Marshal(orderInfo)
+
+			if err != nil {
+				return err
+			}
+
+			tx.addInHash(ctx, ordersHashKey, orderInfo.OrderId, jsonData)
+		}
+
+		indexOrderTx(ctx, &tx, nil, orderInfo)
+		count++
+
+		if count%ordersIndexMigrationBatch != 0 {
+			return nil
+		}
+
+		err := tx.execTx(ctx)
+		tx = o.client.performTx(ctx)
+
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, tx.execTx(ctx)
+}
+
+// RebuildOrderFeedBook fills the order feed book of every pair from the displayed booked orders
+// and returns the number of orders added.
+func (o *OrdersStorage) RebuildOrderFeedBook(ctx context.Context) (int, error) {
+	keys, err := o.client.scanKeys(ctx, marketOrderFeedBookKey+"*")
+
+	if err != nil {
+		return 0, err
+	}
+
+	tx := o.client.performTx(ctx)
+	count := 0
+
+	for _, key := range keys {
+		tx.deleteKey(ctx, key)
+	}
+
+	err = o.scanOrders(ctx, func(orderInfo models.OrderModel) error {
+		if !isOrderDisplayed(orderInfo) {
+			return nil
+		}
+
+		booked, err := o.IsInStockBook(ctx, orderInfo)
+
+		if err != nil || !booked {
+			return err
+		}
+
+		data, err := json.Marshal(buildOrderFeedEvent(models.OrderEventAdd, orderInfo, 0))
+
+		if err != nil {
+			return err
+		}
+
+		tx.addInHash(ctx, marketOrderFeedBookKey+orderInfo.CurrencyPair, orderInfo.OrderId, data)
+		count++
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, tx.execTx(ctx)
+}
+
+// RebuildStockLevels rebuilds every price level index from the depth hash of its side
+// and returns the number of levels indexed.
+func (o *OrdersStorage) RebuildStockLevels(ctx context.Context) (int, error) {
+	keys, err := o.client.scanKeys(ctx, ordersStockPrices+"*")
+
+	if err != nil {
+		return 0, err
+	}
+
+	tx := o.client.performTx(ctx)
+	count := 0
+
+	for _, key := range keys {
+		separator := strings.LastIndex(key, ":")
+		direction, err := strconv.Atoi(key[separator+1:])
+
+		if err != nil {
+			return 0, err
+		}
+
+		levelsKey := buildStockLevelsKey(strings.TrimPrefix(key[:separator], ordersStockPrices), direction)
+		values, err := o.client.getAllFromHash(ctx, key)
+
+		if err != nil {
+			return 0, err
+		}
+
+		tx.deleteKey(ctx, levelsKey)
+
+		for field, value := range values {
+			price, err := strconv.ParseFloat(field, 64)
+
+			if err != nil {
+				return 0, err
+			}
+
+			volume, err := strconv.ParseFloat(value, 64)
+
+			if err != nil {
+				return 0, err
+			}
+
+			if volume > utils.VolumeEpsilon {
+				tx.addInZSet(ctx, levelsKey, field, price)
+				count++
+			}
+		}
+	}
+
+	return count, tx.execTx(ctx)
+}
+
+// RebuildTradeRetention indexes every stored trade by date, drops the trades older than tradesRetention
+// and trims the order and account trade indexes, it returns the number of trades kept.
+func (t *TradeStorage) RebuildTradeRetention(ctx context.Context) (int, error) {
+	cutoff := getTradesCutoff()
+	tx := t.client.performTx(ctx)
+	count := 0
+	scanned := 0
+
+	err := t.client.scanHash(ctx, tradesHashKey, func(tradeId, jsonData string) error {
+		var tradeInfo models.TradeModel
+
+		if err := json.Unmarshal([]byte(jsonData), &tradeInfo); err != nil {
+			return err
+		}
+
+		if tradeInfo.TradeDate < cutoff {
+			tx.removeTrades(ctx, []string{tradeId})
+		} else {
+			tx.addInZSet(ctx, tradesByDateIndex, tradeId, float64(tradeInfo.TradeDate))
+			count++
+		}
+
+		scanned++
+
+		if scanned%ordersIndexMigrationBatch != 0 {
+			return nil
+		}
+
+		err := tx.execTx(ctx)
+		tx = t.client.performTx(ctx)
+
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, pattern := range []string{tradesByOrderIndex + "*", tradesByAccountIndex + "*"} {
+		keys, err := t.client.scanKeys(ctx, pattern)
+
+		if err != nil {
+			return 0, err
+		}
+
+		for _, key := range keys {
+			tx.
+				removeFromZSetByScore(ctx, key, "-inf", "("+strconv.FormatInt(cutoff, 10)).
+				expireKey(ctx, key, tradesRetention)
+		}
+	}
+
+	return count, tx.execTx(ctx)
+}
@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// writeLegacyOrders stores orders the way older releases did, without any derived key.
+func writeLegacyOrders(t *testing.T, client *RedisClient, orders ...models.OrderModel) {
+	t.Helper()
+
+	for _, orderInfo := range orders {
+		jsonData, _ := json.Marshal(orderInfo)
+
+		if err := client.addInHash(context.Background(), ordersHashKey, orderInfo.OrderId, jsonData); err != nil {
+			t.Fatalf("addInHash() error = %v", err)
+		}
+	}
+}
+
+func TestOrdersStorage_RebuildRiskExposure(t *testing.T) {
+	buy := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY
+	withState := func(orderInfo models.OrderModel, state ops.OpsOrderState, filled float64) models.OrderModel {
+		orderInfo.State = int(state)
+		orderInfo.FilledVolume = filled
+		return orderInfo
+	}
+	tests := []struct {
+		name    string
+		orders  []models.OrderModel
+		account string
+		want    models.AccountExposure
+	}{
+		{
+			name: "open orders of every state count with their remainder",
+			orders: []models.OrderModel{
+				withState(newTestOrder("new", buy, 100, 1, 1), ops.OpsOrderState_OPS_ORDER_STATE_NEW, 0),
+				withState(newTestOrder("approved", buy, 100, 2, 1), ops.OpsOrderState_OPS_ORDER_STATE_APPROVED, 0),
+				withState(newTestOrder("part", buy, 10, 4, 1), ops.OpsOrderState_OPS_ORDER_STATE_PART_FILLED, 1),
+				withState(newTestOrder("filled", buy, 100, 1, 1), ops.OpsOrderState_OPS_ORDER_STATE_FILLED, 1),
+				withState(newTestOrder("done", buy, 100, 1, 1), ops.OpsOrderState_OPS_ORDER_STATE_DONE, 0),
+			},
+			account: "alice",
+			want:    models.AccountExposure{OpenOrders: 3, OpenNotional: 100 + 200 + 30},
+		},
+		{
+			name:    "stale counters of accounts without open orders are dropped",
+			orders:  []models.OrderModel{withState(newTestOrder("done", buy, 100, 1, 1), ops.OpsOrderState_OPS_ORDER_STATE_DONE, 0)},
+			account: "alice",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestRedisClient(t)
+			o := NewOrdersStorage(client)
+
+			for i := range tt.orders {
+				tt.orders[i].AccountId = tt.account
+			}
+
+			writeLegacyOrders(t, client, tt.orders...)
+			client.cli.HSet(context.Background(), buildRiskKey(tt.account), buildRiskOrdersField(testPair), 42)
+
+			if _, err := o.RebuildRiskExposure(context.Background()); err != nil {
+				t.Fatalf("RebuildRiskExposure() error = %v", err)
+			}
+
+			got, err := NewRiskStorage(client).GetAccountExposure(context.Background(), tt.account, testPair)
+
+			if err != nil {
+				t.Fatalf("GetAccountExposure() error = %v", err)
+			}
+
+			if got.OpenOrders != tt.want.OpenOrders || math.Abs(got.OpenNotional-tt.want.OpenNotional) > 1e-9 {
+				t.Errorf("GetAccountExposure() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrdersStorage_RebuildAccountBookIndex(t *testing.T) {
+	ctx := context.Background()
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	booked := newTestOrder("booked", sell, 100, 1, 1000)
+	unbooked := newTestOrder("unbooked", sell, 100, 1, 1000)
+
+	client, _ := newTestRedisClient(t)
+	o := NewOrdersStorage(client)
+	writeLegacyOrders(t, client, booked, unbooked)
+	client.cli.SAdd(ctx, fmt.Sprintf(ordersCurrencyDirectionKey, testPair, booked.Direction), booked.OrderId)
+
+	count, err := o.RebuildAccountBookIndex(ctx)
+
+	if err != nil || count != 1 {
+		t.Fatalf("RebuildAccountBookIndex() = %v, %v, want 1", count, err)
+	}
+
+	tests := []struct {
+		name      string
+		orderInfo models.OrderModel
+		want      []string
+	}{
+		{name: "booked order is indexed", orderInfo: booked, want: []string{booked.OrderId}},
+		{name: "unbooked order is not indexed", orderInfo: unbooked, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := o.GetBookOrderIds(ctx, tt.orderInfo.AccountId, "")
+
+			if err != nil {
+				t.Fatalf("GetBookOrderIds() error = %v", err)
+			}
+
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("GetBookOrderIds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrdersStorage_RebuildOrderIndexes(t *testing.T) {
+	ctx := context.Background()
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	open := newTestOrder("open", sell, 100, 1, 1000)
+	open.AccountId = "alice"
+	done := newTestOrder("done", sell, 100, 1, 2000)
+	done.AccountId = "alice"
+	done.State = int(ops.OpsOrderState_OPS_ORDER_STATE_DONE)
+	done.UpdatedDate = 1700000000
+
+	client, _ := newTestRedisClient(t)
+	o := NewOrdersStorage(client)
+	writeLegacyOrders(t, client, open, done)
+	client.cli.SAdd(ctx, ordersAccountKey+"alice", open.OrderId)
+
+	count, err := o.RebuildOrderIndexes(ctx)
+
+	if err != nil || count != 2 {
+		t.Fatalf("RebuildOrderIndexes() = %v, %v, want 2", count, err)
+	}
+
+	entries, err := o.GetAccountOrderIndex(ctx, "alice", nil, 0, 0, 0, 10)
+
+	if err != nil || len(entries) != 2 || entries[0].OrderId != done.OrderId {
+		t.Errorf("GetAccountOrderIndex() = %v, %v, want both orders newest first", entries, err)
+	}
+
+	entries, err = o.GetAccountOrderIndex(ctx, "alice", &done.State, 0, 0, 0, 10)
+
+	if err != nil || len(entries) != 1 || entries[0].OrderId != done.OrderId {
+		t.Errorf("GetAccountOrderIndex() by state = %v, %v, want the done order", entries, err)
+	}
+
+	if entries, _ = o.GetStateOrderIndex(ctx, done.State, 0, 0, 10); len(entries) != 1 || entries[0].Date != 1700000000000 {
+		t.Errorf("GetStateOrderIndex() = %v, want the done order since its update in milliseconds", entries)
+	}
+
+	if stored, _ := o.GetOrderFromStorage(ctx, done.OrderId); stored == nil || stored.UpdatedDate != 1700000000000 {
+		t.Errorf("stored order = %+v, want UpdatedDate in milliseconds", stored)
+	}
+
+	if members, _ := client.cli.SMembers(ctx, ordersAccountKey+"alice").Result(); len(members) != 1 {
+		t.Errorf("account book index = %v, must be kept", members)
+	}
+}
+
+func TestOrdersStorage_RebuildOrderFeedBook(t *testing.T) {
+	ctx := context.Background()
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	booked := newTestOrder("booked", sell, 100, 1, 1000)
+	hidden := newTestOrder("hidden", sell, 100, 1, 1000)
+	hidden.Hidden = true
+	unbooked := newTestOrder("unbooked", sell, 100, 1, 1000)
+
+	client, _ := newTestRedisClient(t)
+	o := NewOrdersStorage(client)
+	writeLegacyOrders(t, client, booked, hidden, unbooked)
+	client.cli.SAdd(ctx, fmt.Sprintf(ordersCurrencyDirectionKey, testPair, sell), booked.OrderId, hidden.OrderId)
+	client.cli.HSet(ctx, marketOrderFeedBookKey+testPair, "stale", "{}")
+
+	count, err := o.RebuildOrderFeedBook(ctx)
+
+	if err != nil || count != 1 {
+		t.Fatalf("RebuildOrderFeedBook() = %v, %v, want 1", count, err)
+	}
+
+	book, _, err := o.GetOrderFeedBook(ctx, testPair)
+
+	if err != nil {
+		t.Fatalf("GetOrderFeedBook() error = %v", err)
+	}
+
+	if len(book) != 1 || book[0].OrderId != booked.OrderId || book[0].RemainingVolume != 1 {
+		t.Errorf("GetOrderFeedBook() = %+v, want only %v", book, booked.OrderId)
+	}
+}
+
+func TestOrdersStorage_RebuildStockLevels(t *testing.T) {
+	ctx := context.Background()
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	stockKey := buildStockKey(testPair, int(sell))
+	levelsKey := buildStockLevelsKey(testPair, int(sell))
+
+	client, _ := newTestRedisClient(t)
+	o := NewOrdersStorage(client)
+	client.cli.HSet(ctx, stockKey, "100.000000", "2", "101.000000", "0", "102.000000", "1.5")
+	client.cli.ZAdd(ctx, levelsKey, redis.Z{Score: 103, Member: "103.000000"})
+
+	count, err := o.RebuildStockLevels(ctx)
+
+	if err != nil || count != 2 {
+		t.Fatalf("RebuildStockLevels() = %v, %v, want 2", count, err)
+	}
+
+	tests := []struct {
+		name  string
+		price float64
+		want  float64
+	}{
+		{name: "best level comes from the depth hash", price: 100, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, err := o.GetBestStockLevel(ctx, testPair, int(sell))
+
+			if err != nil || level.Price != tt.price || level.Volume != tt.want {
+				t.Errorf("GetBestStockLevel() = %+v, %v, want %v %v", level, err, tt.price, tt.want)
+			}
+		})
+	}
+
+	if levels, _ := client.cli.ZRange(ctx, levelsKey, 0, -1).Result(); len(levels) != 2 || levels[1] != "102.000000" {
+		t.Errorf("levels = %v, want the two levels with volume", levels)
+	}
+}
+
+func TestTradeStorage_RebuildTradeRetention(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UnixMilli()
+	expired := now - tradesRetention.Milliseconds() - time.Hour.Milliseconds()
+	client, server := newTestRedisClient(t)
+	s := NewTradeStorage(client)
+
+	for _, tradeInfo := range []models.TradeModel{{TradeId: "old", TradeDate: expired}, {TradeId: "new", TradeDate: now}} {
+		jsonData, _ := json.Marshal(tradeInfo)
+		client.cli.HSet(ctx, tradesHashKey, tradeInfo.TradeId, jsonData)
+		client.cli.ZAdd(ctx, tradesByAccountIndex+"alice", redis.Z{Score: float64(tradeInfo.TradeDate), Member: tradeInfo.TradeId})
+	}
+
+	count, err := s.RebuildTradeRetention(ctx)
+
+	if err != nil || count != 1 {
+		t.Fatalf("RebuildTradeRetention() = %v, %v, want 1", count, err)
+	}
+
+	if server.HGet(tradesHashKey, "old") != "" {
+		t.Errorf("expired trade is kept in %v", tradesHashKey)
+	}
+
+	for _, key := range []string{tradesByDateIndex, tradesByAccountIndex + "alice"} {
+		if got, _ := client.cli.ZRange(ctx, key, 0, -1).Result(); len(got) != 1 || got[0] != "new" {
+			t.Errorf("%v = %v, want only the kept trade", key, got)
+		}
+	}
+
+	if ttl := server.TTL(tradesByAccountIndex + "alice"); ttl != tradesRetention {
+		t.Errorf("account index expires in %v, want %v", ttl, tradesRetention)
+	}
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Order events are protobuf encoded like snapshots:
+//
+//	message OrderLogEvent { string event_type = 1; int64 timestamp = 2; Order order = 3; }
+//
+// The stream keeps ordersEventsRetention of events, a rebuild of older book state starts from a snapshot restore.
+const (
+	ordersEventsStreamKey = "orders:events"
+	orderEventDataField   = "data"
+	ordersEventsRetention = 90 * 24 * time.Hour
+)
+
+func (x *TxContainer) appendOrderEvent(ctx context.Context, eventType string, orderInfo models.OrderModel) *TxContainer {
+	now := time.Now().UTC().UnixMilli()
+	details, _ := json.Marshal(orderInfo)
+
+	x.appendAudit(ctx, orderInfo.OrderId, models.AuditEntryModel{
+		Timestamp:     now,
+		Source:        models.AuditSourceOrderLog,
+		Type:          eventType,
+		CorrelationId: orderInfo.OrderId,
+		Details:       details,
+	})
+
+	data := marshalOrderEvent(eventType, now, orderInfo)
+
+	x.tx.XAdd(ctx, &redis.XAddArgs{
+		Stream: ordersEventsStreamKey,
+		MinID:  fmt.Sprintf("%d-0", now-ordersEventsRetention.Milliseconds()),
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":              eventType,
+			"order_id":          orderInfo.OrderId,
+			orderEventDataField: data,
+		},
+	})
+
+	return x.appendChainRecord(ctx, orderInfo.CurrencyPair, data)
+}
+
+// AppendOrderEvent logs transitions of orders that are not written to the orders hash, like rejections.
+func (o *OrdersStorage) AppendOrderEvent(ctx context.Context, eventType string, orderInfo models.OrderModel) error {
+	tx := o.client.performTx(ctx)
+	tx.appendOrderEvent(ctx, eventType, orderInfo)
+
+	return tx.execTx(ctx)
+}
+
+// ReadOrderEvents returns up to count events after the given stream id, "0" starts from the beginning.
+func (o *OrdersStorage) ReadOrderEvents(ctx context.Context, afterId string, count int64) ([]models.OrderLogEventModel, error) {
+	messages, err := o.client.cli.XRangeN(ctx, ordersEventsStreamKey, "("+afterId, "+", count).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.OrderLogEventModel, 0, len(messages))
+
+	for _, message := range messages {
+		data, ok := message.Values[orderEventDataField].(string)
+
+		if !ok {
+			return nil, staticerr.ErrorOrderEventIsCorrupted
+		}
+
+		event, err := unmarshalOrderEvent([]byte(data))
+
+		if err != nil {
+			return nil, err
+		}
+
+		event.EventId = message.ID
+		events = append(events, *event)
+	}
+
+	return events, nil
+}
+
+// RebuildOrder writes the order and, when booked, its book indexes without logging new order events,
+// the book is still journaled to the order feed.
+func (o *OrdersStorage) RebuildOrder(ctx context.Context, orderInfo models.OrderModel, booked bool) error {
+	jsonData, err := json.Marshal(orderInfo)
+
+	if err != nil {
+		return err
+	}
+
+	return o.writeOrders(ctx, []string{orderInfo.OrderId}, func(tx *TxContainer, stored []*models.OrderModel) error {
+		tx.addInHash(ctx, ordersHashKey, orderInfo.OrderId, jsonData)
+		indexOrderTx(ctx, tx, stored[0], orderInfo)
+		changeExposureTx(ctx, tx, stored[0], &orderInfo)
+
+		if booked {
+			addInStockBookTx(ctx, tx, orderInfo)
+			tx.appendOrderFeedEvent(ctx, models.OrderEventAdd, orderInfo, 0)
+		}
+
+		return nil
+	})
+}
+
+// getOrderEventType derives the logged transition from the state an order is saved with.
+// A closed order is logged as cancelled unless its close event is given to CloseOrder.
+func getOrderEventType(orderInfo models.OrderModel) string {
+	switch orderInfo.State {
+	case int(ops.OpsOrderState_OPS_ORDER_STATE_APPROVED):
+		return models.OrderLogApproved
+	case int(ops.OpsOrderState_OPS_ORDER_STATE_REJECTED):
+		return models.OrderLogRejected
+	case int(ops.OpsOrderState_OPS_ORDER_STATE_PART_FILLED), int(ops.OpsOrderState_OPS_ORDER_STATE_FILLED):
+		return models.OrderLogFilled
+	case int(ops.OpsOrderState_OPS_ORDER_STATE_DONE):
+		return models.OrderLogCancelled
+	default:
+		return models.OrderLogUpdated
+	}
+}
+
+func marshalOrderEvent(eventType string, timestamp int64, orderInfo models.OrderModel) []byte {
+	b := appendString(nil, 1, eventType)
+	b = appendVarint(b, 2, uint64(timestamp))
+	return appendMessage(b, 3, marshalOrder(orderInfo))
+}
+
+func unmarshalOrderEvent(b []byte) (*models.OrderLogEventModel, error) {
+	event := models.OrderLogEventModel{}
+
+	err := consumeFields(b, func(num protowire.Number, v wireValue) error {
+		switch num {
+		case 1:
+			event.EventType = string(v.bytes)
+		case 2:
+			event.Timestamp = int64(v.varint)
+		case 3:
+			order, err := unmarshalOrder(v.bytes)
+
+			if err != nil {
+				return err
+			}
+
+			event.Order = *order
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestOrdersStorage_closeEvents(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	expired := newTestOrder("expired", sell, 100, 1, 1)
+	expired.ExpirationDate = time.Now().Add(-time.Hour).UnixMilli()
+	expired.State = int(ops.OpsOrderState_OPS_ORDER_STATE_DONE)
+	tests := []struct {
+		name  string
+		close func(ctx context.Context, o *OrdersStorage) error
+		want  string
+	}{
+		{
+			name: "closed order is logged as cancelled even past its expiration",
+			close: func(ctx context.Context, o *OrdersStorage) error {
+				return o.UpdateOrderInfo(ctx, expired)
+			},
+			want: models.OrderLogCancelled,
+		},
+		{
+			name: "explicit close event is logged as given",
+			close: func(ctx context.Context, o *OrdersStorage) error {
+				return o.CloseOrder(ctx, models.OrderLogExpired, expired)
+			},
+			want: models.OrderLogExpired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, _ := newTestRedisClient(t)
+			o := NewOrdersStorage(client)
+
+			if err := tt.close(ctx, o); err != nil {
+				t.Fatalf("close error = %v", err)
+			}
+
+			events, err := o.ReadOrderEvents(ctx, "0", 10)
+
+			if err != nil || len(events) != 1 || events[0].EventType != tt.want {
+				t.Errorf("ReadOrderEvents() = %+v, %v, want one %v", events, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrdersStorage_AppendOrderEvent_retention(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestRedisClient(t)
+	o := NewOrdersStorage(client)
+	client.cli.XAdd(ctx, &redis.XAddArgs{Stream: ordersEventsStreamKey, ID: "1-0", Values: map[string]interface{}{orderEventDataField: ""}})
+
+	if err := o.AppendOrderEvent(ctx, models.OrderLogRejected, newTestOrder("rejected", ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL, 100, 1, 1)); err != nil {
+		t.Fatalf("AppendOrderEvent() error = %v", err)
+	}
+
+	events, err := o.ReadOrderEvents(ctx, "0", 10)
+
+	if err != nil || len(events) != 1 || events[0].Order.OrderId != "rejected" {
+		t.Errorf("ReadOrderEvents() = %+v, %v, want only the new event", events, err)
+	}
+}
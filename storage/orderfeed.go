@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+	"trade-order-processing-service/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// The order feed (L3) is journaled in the transactions that change the book, a relay publishes
+// the journal and consumers resync from the feed book, which holds the displayed orders of a pair.
+const (
+	marketOrderFeedStreamKey = "market:orders:feed"
+	marketOrderFeedCursorKey = "market:orders:feed:cursor"
+	marketOrderFeedBookKey   = "market:orders:book:"
+	marketOrderFeedMaxLen    = 100000
+	orderFeedSequenceField   = "sequence"
+	orderFeedDataField       = "data"
+)
+
+// orderFeedScript takes the next pair sequence, applies the event to the feed book and journals it.
+var orderFeedScript = redis.NewScript(`
+local sequence = redis.call('INCR', KEYS[1])
+if ARGV[2] == ARGV[3] then
+	redis.call('HDEL', KEYS[2], ARGV[4])
+else
+	redis.call('HSET', KEYS[2], ARGV[4], ARGV[5])
+end
+redis.call('XADD', KEYS[3], 'MAXLEN', '~', ARGV[1], '*', 'sequence', sequence, 'data', ARGV[5])
+return sequence
+`)
+
+// orderFeedBookScript reads the feed book together with the sequence it was built up to.
+var orderFeedBookScript = redis.NewScript(`
+return {redis.call('GET', KEYS[1]) or '0', redis.call('HVALS', KEYS[2])}
+`)
+
+func isOrderDisplayed(orderInfo models.OrderModel) bool {
+	return !orderInfo.Hidden && orderInfo.Type == int(ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT)
+}
+
+func (x *TxContainer) appendOrderFeedEvent(ctx context.Context, eventType string, orderInfo models.OrderModel, executedVolume float64) *TxContainer {
+	if !isOrderDisplayed(orderInfo) {
+		return x
+	}
+
+	data, _ := json.Marshal(buildOrderFeedEvent(eventType, orderInfo, executedVolume))
+	keys := []string{marketOrderFeedSequenceKey + orderInfo.CurrencyPair, marketOrderFeedBookKey + orderInfo.CurrencyPair, marketOrderFeedStreamKey}
+
+	orderFeedScript.Eval(ctx, x.tx, keys, marketOrderFeedMaxLen, eventType, models.OrderEventDelete, orderInfo.OrderId, data)
+
+	return x
+}
+
+func buildOrderFeedEvent(eventType string, orderInfo models.OrderModel, executedVolume float64) models.OrderEventModel {
+	event := models.OrderEventModel{
+		EventType:       eventType,
+		OrderId:         orderInfo.OrderId,
+		CurrencyPair:    orderInfo.CurrencyPair,
+		Direction:       orderInfo.Direction,
+		Price:           orderInfo.LimitPrice,
+		RemainingVolume: utils.GetRemainingVolume(orderInfo),
+		ExecutedVolume:  executedVolume,
+		CreationDate:    orderInfo.CreationDate,
+		Timestamp:       time.Now().UTC().UnixMilli(),
+	}
+
+	if eventType == models.OrderEventDelete {
+		event.RemainingVolume = 0
+	}
+
+	return event
+}
+
+// ExecuteInStockBook replaces the booked order with its executed state in one transaction,
+// the remainder is booked back while it has volume.
+func (o *OrdersStorage) ExecuteInStockBook(ctx context.Context, bookedOrder, orderInfo models.OrderModel) error {
+	tx := o.client.performTx(ctx)
+
+	dropFromStockBookTx(ctx, &tx, bookedOrder)
+	tx.
+		appendOrderEvent(ctx, models.OrderLogUnbooked, bookedOrder).
+		appendOrderFeedEvent(ctx, models.OrderEventExecute, orderInfo, orderInfo.FilledVolume-bookedOrder.FilledVolume)
+
+	if utils.GetRemainingVolume(orderInfo) > 0 {
+		addInStockBookTx(ctx, &tx, orderInfo)
+		tx.appendOrderEvent(ctx, models.OrderLogBooked, orderInfo)
+	} else {
+		tx.appendOrderFeedEvent(ctx, models.OrderEventDelete, orderInfo, 0)
+	}
+
+	return tx.execTx(ctx)
+}
+
+// ReadOrderFeed returns up to count journaled events after the relay cursor and the stream id of the last one.
+func (o *OrdersStorage) ReadOrderFeed(ctx context.Context, count int64) ([]models.OrderEventModel, string, error) {
+	cursor, err := o.client.getValue(ctx, marketOrderFeedCursorKey)
+
+	if errors.Is(err, redis.Nil) {
+		start := "0"
+		cursor = &start
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	messages, err := o.client.cli.XRangeN(ctx, marketOrderFeedStreamKey, "("+*cursor, "+", count).Result()
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	events := make([]models.OrderEventModel, 0, len(messages))
+
+	for _, message := range messages {
+		event, err := parseOrderFeedMessage(message)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		events = append(events, *event)
+	}
+
+	if len(messages) == 0 {
+		return events, *cursor, nil
+	}
+
+	return events, messages[len(messages)-1].ID, nil
+}
+
+// AckOrderFeed moves the relay cursor past the published events.
+func (o *OrdersStorage) AckOrderFeed(ctx context.Context, lastId string) error {
+	return o.client.setValue(ctx, marketOrderFeedCursorKey, lastId)
+}
+
+func parseOrderFeedMessage(message redis.XMessage) (*models.OrderEventModel, error) {
+	data, ok := message.Values[orderFeedDataField].(string)
+
+	if !ok {
+		return nil, staticerr.ErrorOrderEventIsCorrupted
+	}
+
+	event := models.OrderEventModel{}
+
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil, err
+	}
+
+	sequence, err := strconv.ParseInt(fmt.Sprint(message.Values[orderFeedSequenceField]), 10, 64)
+
+	if err != nil {
+		return nil, err
+	}
+
+	event.Sequence = sequence
+
+	return &event, nil
+}
+
+// GetOrderFeedBook returns the displayed orders of the pair and the feed sequence they are current at.
+func (o *OrdersStorage) GetOrderFeedBook(ctx context.Context, currencyPair string) ([]models.OrderEventModel, int64, error) {
+	keys := []string{marketOrderFeedSequenceKey + currencyPair, marketOrderFeedBookKey + currencyPair}
+	values, err := orderFeedBookScript.Run(ctx, o.client.cli, keys).Slice()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(values) != 2 {
+		return nil, 0, fmt.Errorf("unexpected order feed book reply: %v", values)
+	}
+
+	sequence, err := strconv.ParseInt(fmt.Sprint(values[0]), 10, 64)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries, _ := values[1].([]interface{})
+	orders := make([]models.OrderEventModel, 0, len(entries))
+
+	for _, entry := range entries {
+		event := models.OrderEventModel{}
+
+		if err = json.Unmarshal([]byte(fmt.Sprint(entry)), &event); err != nil {
+			return nil, 0, err
+		}
+
+		orders = append(orders, event)
+	}
+
+	return orders, sequence, nil
+}
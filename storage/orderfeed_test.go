@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+)
+
+func TestOrdersStorage_ReadOrderFeed(t *testing.T) {
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	hidden := newTestOrder("hidden", sell, 100, 1, 1)
+	hidden.Hidden = true
+	partial := newTestOrder("partial", sell, 100, 2, 2)
+	partial.FilledVolume = 0.5
+	filled := newTestOrder("filled", sell, 100, 1, 3)
+	filled.FilledVolume = 1
+	tests := []struct {
+		name       string
+		change     func(ctx context.Context, o *OrdersStorage) error
+		wantEvents []models.OrderEventModel
+		wantBook   []string
+	}{
+		{
+			name: "booked order is added",
+			change: func(ctx context.Context, o *OrdersStorage) error {
+				return o.AddInStockBook(ctx, newTestOrder("partial", sell, 100, 2, 2))
+			},
+			wantEvents: []models.OrderEventModel{{EventType: models.OrderEventAdd, OrderId: "partial", RemainingVolume: 2, Sequence: 1}},
+			wantBook:   []string{"partial"},
+		},
+		{
+			name: "hidden order is not journaled",
+			change: func(ctx context.Context, o *OrdersStorage) error {
+				return o.AddInStockBook(ctx, hidden)
+			},
+		},
+		{
+			name: "partial execution keeps the remainder",
+			change: func(ctx context.Context, o *OrdersStorage) error {
+				booked := newTestOrder("partial", sell, 100, 2, 2)
+
+				if err := o.AddInStockBook(ctx, booked); err != nil {
+					return err
+				}
+
+				return o.ExecuteInStockBook(ctx, booked, partial)
+			},
+			wantEvents: []models.OrderEventModel{
+				{EventType: models.OrderEventAdd, OrderId: "partial", RemainingVolume: 2, Sequence: 1},
+				{EventType: models.OrderEventExecute, OrderId: "partial", RemainingVolume: 1.5, ExecutedVolume: 0.5, Sequence: 2},
+			},
+			wantBook: []string{"partial"},
+		},
+		{
+			name: "full execution is followed by a delete",
+			change: func(ctx context.Context, o *OrdersStorage) error {
+				booked := newTestOrder("filled", sell, 100, 1, 3)
+
+				if err := o.AddInStockBook(ctx, booked); err != nil {
+					return err
+				}
+
+				return o.ExecuteInStockBook(ctx, booked, filled)
+			},
+			wantEvents: []models.OrderEventModel{
+				{EventType: models.OrderEventAdd, OrderId: "filled", RemainingVolume: 1, Sequence: 1},
+				{EventType: models.OrderEventExecute, OrderId: "filled", ExecutedVolume: 1, Sequence: 2},
+				{EventType: models.OrderEventDelete, OrderId: "filled", Sequence: 3},
+			},
+		},
+		{
+			name: "rebuilt order is added",
+			change: func(ctx context.Context, o *OrdersStorage) error {
+				return o.RebuildOrder(ctx, newTestOrder("rebuilt", sell, 100, 1, 4), true)
+			},
+			wantEvents: []models.OrderEventModel{{EventType: models.OrderEventAdd, OrderId: "rebuilt", RemainingVolume: 1, Sequence: 1}},
+			wantBook:   []string{"rebuilt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, _ := newTestRedisClient(t)
+			o := NewOrdersStorage(client)
+
+			if err := tt.change(ctx, o); err != nil {
+				t.Fatalf("change error = %v", err)
+			}
+
+			events, lastId, err := o.ReadOrderFeed(ctx, 10)
+
+			if err != nil {
+				t.Fatalf("ReadOrderFeed() error = %v", err)
+			}
+
+			if len(events) != len(tt.wantEvents) {
+				t.Fatalf("ReadOrderFeed() = %+v, want %+v", events, tt.wantEvents)
+			}
+
+			for i, event := range events {
+				want := tt.wantEvents[i]
+
+				if event.EventType != want.EventType || event.OrderId != want.OrderId || event.RemainingVolume != want.RemainingVolume ||
+					event.ExecutedVolume != want.ExecutedVolume || event.Sequence != want.Sequence {
+					t.Errorf("ReadOrderFeed()[%v] = %+v, want %+v", i, event, want)
+				}
+			}
+
+			if err = o.AckOrderFeed(ctx, lastId); err != nil {
+				t.Fatalf("AckOrderFeed() error = %v", err)
+			}
+
+			if events, _, _ = o.ReadOrderFeed(ctx, 10); len(events) != 0 {
+				t.Errorf("ReadOrderFeed() after ack = %+v, want none", events)
+			}
+
+			book, sequence, err := o.GetOrderFeedBook(ctx, testPair)
+
+			if err != nil {
+				t.Fatalf("GetOrderFeedBook() error = %v", err)
+			}
+
+			if sequence != int64(len(tt.wantEvents)) || len(book) != len(tt.wantBook) {
+				t.Fatalf("GetOrderFeedBook() = %+v, %v, want %v at %v", book, sequence, tt.wantBook, len(tt.wantEvents))
+			}
+
+			for i, orderInfo := range book {
+				if orderInfo.OrderId != tt.wantBook[i] {
+					t.Errorf("GetOrderFeedBook()[%v] = %v, want %v", i, orderInfo.OrderId, tt.wantBook[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"trade-order-processing-service/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Account indexes keep every order of the account scored by creation date, in total and per state.
+// State indexes keep every order scored by the date it entered its state.
+// They are kept apart from the orders:account: book index of the account.
+const (
+	ordersAccountCreatedKey = "orders:index:account:"
+	ordersAccountStateKey   = "orders:index:account-state:%s:%d"
+	ordersStateKey          = "orders:state:"
+	// dates below are taken as seconds, in milliseconds it is March 1973
+	secondsDateLimit = 100000000000
+)
+
+func buildAccountStateKey(accountId string, state int) string {
+	return fmt.Sprintf(ordersAccountStateKey, accountId, state)
+}
+
+func buildStateKey(state int) string {
+	return ordersStateKey + strconv.Itoa(state)
+}
+
+// getStateSinceDate is the date of the write that moved the order to its state,
+// older releases stored UpdatedDate in seconds.
+func getStateSinceDate(orderInfo models.OrderModel) int64 {
+	if orderInfo.UpdatedDate > 0 {
+		return toMilliseconds(orderInfo.UpdatedDate)
+	}
+
+	return orderInfo.CreationDate
+}
+
+func toMilliseconds(date int64) int64 {
+	if date < secondsDateLimit {
+		return date * 1000
+	}
+
+	return date
+}
+
+// indexOrderTx moves the order from the index of its stored state to the index of its current one,
+// a nil stored order is indexed as new. The state index keeps the date the order entered the state.
+func indexOrderTx(ctx context.Context, tx *TxContainer, stored *models.OrderModel, orderInfo models.OrderModel) {
+	score := float64(orderInfo.CreationDate)
+
+	if stored != nil && stored.State != orderInfo.State {
+		tx.
+			removeFromZSet(ctx, buildAccountStateKey(stored.AccountId, stored.State), stored.OrderId).
+			removeFromZSet(ctx, buildStateKey(stored.State), stored.OrderId)
+	}
+
+	tx.
+		addInZSet(ctx, ordersAccountCreatedKey+orderInfo.AccountId, orderInfo.OrderId, score).
+		addInZSet(ctx, buildAccountStateKey(orderInfo.AccountId, orderInfo.State), orderInfo.OrderId, score).
+		addInZSetNX(ctx, buildStateKey(orderInfo.State), orderInfo.OrderId, float64(getStateSinceDate(orderInfo)))
+}
+
+func unindexOrderTx(ctx context.Context, tx *TxContainer, orderInfo models.OrderModel) {
+	tx.
+		removeFromZSet(ctx, ordersAccountCreatedKey+orderInfo.AccountId, orderInfo.OrderId).
+		removeFromZSet(ctx, buildAccountStateKey(orderInfo.AccountId, orderInfo.State), orderInfo.OrderId).
+		removeFromZSet(ctx, buildStateKey(orderInfo.State), orderInfo.OrderId)
+}
+
+// GetAccountOrderIndex returns account orders from the newest to the oldest within the creation date range,
+// zero dates leave the range open.
+func (o *OrdersStorage) GetAccountOrderIndex(ctx context.Context, accountId string, state *int, fromDate, toDate int64, offset, count int64) ([]models.OrderIndexEntry, error) {
+	key := ordersAccountCreatedKey + accountId
+
+	if state != nil {
+		key = buildAccountStateKey(accountId, *state)
+	}
+
+	return o.getIndexPage(ctx, key, fromDate, toDate, offset, count, true)
+}
+
+// GetStateOrderIndex returns orders of the state from the oldest state change up to toDate.
+func (o *OrdersStorage) GetStateOrderIndex(ctx context.Context, state int, toDate int64, offset, count int64) ([]models.OrderIndexEntry, error) {
+	return o.getIndexPage(ctx, buildStateKey(state), 0, toDate, offset, count, false)
+}
+
+func (o *OrdersStorage) getIndexPage(ctx context.Context, key string, fromDate, toDate int64, offset, count int64, newestFirst bool) ([]models.OrderIndexEntry, error) {
+	rangeBy := &redis.ZRangeBy{Min: "-inf", Max: "+inf", Offset: offset, Count: count}
+
+	if fromDate > 0 {
+		rangeBy.Min = strconv.FormatInt(fromDate, 10)
+	}
+
+	if toDate > 0 {
+		rangeBy.Max = strconv.FormatInt(toDate, 10)
+	}
+
+	var values []redis.Z
+	var err error
+
+	if newestFirst {
+		values, err = o.client.cli.ZRevRangeByScoreWithScores(ctx, key, rangeBy).Result()
+	} else {
+		values, err = o.client.cli.ZRangeByScoreWithScores(ctx, key, rangeBy).Result()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.OrderIndexEntry, 0, len(values))
+
+	for _, value := range values {
+		orderId, _ := value.Member.(string)
+		entries = append(entries, models.OrderIndexEntry{OrderId: orderId, Date: int64(value.Score)})
+	}
+
+	return entries, nil
+}
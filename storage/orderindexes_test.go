@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+)
+
+func TestOrdersStorage_indexOrder(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestRedisClient(t)
+	o := NewOrdersStorage(client)
+	orderInfo := newTestOrder("order", ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL, 100, 1, 1)
+	orderInfo.State = int(ops.OpsOrderState_OPS_ORDER_STATE_NEW)
+
+	if err := o.AddOrderToStorage(ctx, orderInfo); err != nil {
+		t.Fatalf("AddOrderToStorage() error = %v", err)
+	}
+
+	orderInfo.State = int(ops.OpsOrderState_OPS_ORDER_STATE_APPROVED)
+
+	if err := o.UpdateOrderInfo(ctx, orderInfo); err != nil {
+		t.Fatalf("UpdateOrderInfo() error = %v", err)
+	}
+
+	for state, want := range map[int]int{int(ops.OpsOrderState_OPS_ORDER_STATE_NEW): 0, int(ops.OpsOrderState_OPS_ORDER_STATE_APPROVED): 1} {
+		accountEntries, err := o.GetAccountOrderIndex(ctx, orderInfo.AccountId, &state, 0, 0, 0, 10)
+
+		if err != nil {
+			t.Fatalf("GetAccountOrderIndex() error = %v", err)
+		}
+
+		stateEntries, err := o.GetStateOrderIndex(ctx, state, 0, 0, 10)
+
+		if err != nil {
+			t.Fatalf("GetStateOrderIndex() error = %v", err)
+		}
+
+		if len(accountEntries) != want || len(stateEntries) != want {
+			t.Errorf("state %v indexes = %v, %v, want %v entries", state, accountEntries, stateEntries, want)
+		}
+	}
+
+	if err := o.DeleteOrderFromStorage(ctx, orderInfo.OrderId); err != nil {
+		t.Fatalf("DeleteOrderFromStorage() error = %v", err)
+	}
+
+	if entries, _ := o.GetAccountOrderIndex(ctx, orderInfo.AccountId, nil, 0, 0, 0, 10); len(entries) != 0 {
+		t.Errorf("GetAccountOrderIndex() after delete = %v, want none", entries)
+	}
+}
+
+func TestOrdersStorage_stateSinceDate(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestRedisClient(t)
+	o := NewOrdersStorage(client)
+	orderInfo := newTestOrder("order", ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL, 100, 2, 1000)
+	orderInfo.State = int(ops.OpsOrderState_OPS_ORDER_STATE_PART_FILLED)
+	orderInfo.UpdatedDate = 1700000000000
+
+	stateSince := func(state ops.OpsOrderState) []models.OrderIndexEntry {
+		entries, err := o.GetStateOrderIndex(ctx, int(state), 0, 0, 10)
+
+		if err != nil {
+			t.Fatalf("GetStateOrderIndex() error = %v", err)
+		}
+
+		return entries
+	}
+
+	if err := o.AddOrderToStorage(ctx, orderInfo); err != nil {
+		t.Fatalf("AddOrderToStorage() error = %v", err)
+	}
+
+	orderInfo.FilledVolume = 1
+
+	if err := o.UpdateOrderInfo(ctx, orderInfo); err != nil {
+		t.Fatalf("UpdateOrderInfo() error = %v", err)
+	}
+
+	if entries := stateSince(ops.OpsOrderState_OPS_ORDER_STATE_PART_FILLED); len(entries) != 1 || entries[0].Date != 1700000000000 {
+		t.Errorf("same state write moved the entry date: %v, want 1700000000000", entries)
+	}
+
+	orderInfo.State = int(ops.OpsOrderState_OPS_ORDER_STATE_FILLED)
+	before := time.Now().UnixMilli()
+
+	if err := o.UpdateOrderInfo(ctx, orderInfo); err != nil {
+		t.Fatalf("UpdateOrderInfo() error = %v", err)
+	}
+
+	if entries := stateSince(ops.OpsOrderState_OPS_ORDER_STATE_PART_FILLED); len(entries) != 0 {
+		t.Errorf("previous state index = %v, want none", entries)
+	}
+
+	if entries := stateSince(ops.OpsOrderState_OPS_ORDER_STATE_FILLED); len(entries) != 1 || entries[0].Date < before {
+		t.Errorf("new state index = %v, want the date of the state change", entries)
+	}
+}
+
+func Test_getStateSinceDate(t *testing.T) {
+	tests := []struct {
+		name      string
+		orderInfo models.OrderModel
+		want      int64
+	}{
+		{name: "milliseconds", orderInfo: models.OrderModel{CreationDate: 1, UpdatedDate: 1700000000123}, want: 1700000000123},
+		{name: "seconds of older releases", orderInfo: models.OrderModel{CreationDate: 1, UpdatedDate: 1700000000}, want: 1700000000000},
+		{name: "never updated", orderInfo: models.OrderModel{CreationDate: 1700000000123}, want: 1700000000123},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getStateSinceDate(tt.orderInfo); got != tt.want {
+				t.Errorf("getStateSinceDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
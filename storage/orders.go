@@ -3,7 +3,9 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
@@ -25,6 +27,10 @@ const (
 	ordersLocksKey             = "lock_order:"
 	matchingCandidatesIndex    = "orders:matching:"
 	limitPriceIndex            = "orders:limit:"
+	ordersAccountKey           = "orders:account:"
+	ordersStockLevelsKey       = "orders:levels:%s:%d"
+	ordersHiddenKey            = "orders:hidden"
+	ordersWriteRetries         = 10
 )
 
 var (
@@ -38,11 +44,15 @@ func buildStockKey(currencyPair string, direction int) string {
 	return fmt.Sprintf(ordersStockPrices+"%s:%d", currencyPair, direction)
 }
 
+func buildStockLevelsKey(currencyPair string, direction int) string {
+	return fmt.Sprintf(ordersStockLevelsKey, currencyPair, direction)
+}
+
 type OrdersStorage struct {
 	client *RedisClient
 }
 
-func newOrdersStorage(cleint *RedisClient) *OrdersStorage {
+func NewOrdersStorage(cleint *RedisClient) *OrdersStorage {
 	return &OrdersStorage{client: cleint}
 }
 
@@ -53,11 +63,71 @@ func (o *OrdersStorage) AddOrderToStorage(ctx context.Context, orderInfo models.
 		return err
 	}
 
-	if err = o.client.addInHash(ctx, ordersHashKey, orderInfo.OrderId, jsonData); err != nil {
+	return o.writeOrders(ctx, []string{orderInfo.OrderId}, func(tx *TxContainer, stored []*models.OrderModel) error {
+		tx.
+			addInHash(ctx, ordersHashKey, orderInfo.OrderId, jsonData).
+			appendOrderEvent(ctx, models.OrderLogCreated, orderInfo)
+
+		indexOrderTx(ctx, tx, stored[0], orderInfo)
+		changeExposureTx(ctx, tx, stored[0], &orderInfo)
+
+		return nil
+	})
+}
+
+// writeOrders runs write in a transaction with the stored versions of the orders the indexes and exposure
+// deltas are computed from. The orders hash is watched, so a write in between retries with the new versions.
+func (o *OrdersStorage) writeOrders(ctx context.Context, ids []string, write func(tx *TxContainer, stored []*models.OrderModel) error) error {
+	txf := func(watched *redis.Tx) error {
+		stored, err := getStoredOrders(ctx, watched, ids...)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = watched.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return write(&TxContainer{tx: pipe}, stored)
+		})
+
 		return err
 	}
 
-	return nil
+	var err error
+
+	for i := 0; i < ordersWriteRetries; i++ {
+		if err = o.client.cli.Watch(ctx, txf, ordersHashKey); !errors.Is(err, redis.TxFailedErr) {
+			break
+		}
+	}
+
+	return err
+}
+
+// getStoredOrders reads the current versions of the orders, nil for the ones not stored.
+func getStoredOrders(ctx context.Context, cli redis.Cmdable, ids ...string) ([]*models.OrderModel, error) {
+	values, err := cli.HMGet(ctx, ordersHashKey, ids...).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]*models.OrderModel, len(values))
+
+	for i, value := range values {
+		jsonData, ok := value.(string)
+
+		if !ok {
+			continue
+		}
+
+		orders[i] = &models.OrderModel{}
+
+		if err = json.Unmarshal([]byte(jsonData), orders[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return orders, nil
 }
 
 func (o *OrdersStorage) GetOrderFromStorage(ctx context.Context, id string) (*models.OrderModel, error) {
@@ -77,8 +147,114 @@ func (o *OrdersStorage) GetOrderFromStorage(ctx context.Context, id string) (*mo
 
 }
 
+func (o *OrdersStorage) GetBookOrders(ctx context.Context, currencyPair string, direction int) ([]models.OrderModel, error) {
+	ids, err := o.client.getSetMembers(ctx, fmt.Sprintf(ordersCurrencyDirectionKey, currencyPair, direction))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return o.GetOrdersFromStorage(ctx, ids)
+}
+
+func (o *OrdersStorage) IsInStockBook(ctx context.Context, orderInfo models.OrderModel) (bool, error) {
+	return o.client.isSetMember(ctx, fmt.Sprintf(ordersCurrencyDirectionKey, orderInfo.CurrencyPair, orderInfo.Direction), orderInfo.OrderId)
+}
+
+// GetBookOrderIds returns ids of orders resting in the book for the account, the pair or both,
+// with neither of them it returns every booked order.
+func (o *OrdersStorage) GetBookOrderIds(ctx context.Context, accountId string, currencyPair string) ([]string, error) {
+	pairKeys := []string{
+		fmt.Sprintf(ordersCurrencyDirectionKey, currencyPair, int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY)),
+		fmt.Sprintf(ordersCurrencyDirectionKey, currencyPair, int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL)),
+	}
+
+	switch {
+	case accountId != "" && currencyPair != "":
+		ids := make([]string, 0)
+
+		for _, pairKey := range pairKeys {
+			values, err := o.client.getSetsIntersection(ctx, ordersAccountKey+accountId, pairKey)
+
+			if err != nil {
+				return nil, err
+			}
+
+			ids = append(ids, values...)
+		}
+
+		return ids, nil
+	case accountId != "":
+		return o.client.getSetMembers(ctx, ordersAccountKey+accountId)
+	case currencyPair != "":
+		return o.client.getSetsUnion(ctx, pairKeys...)
+	default:
+		return o.client.getFromZSet(ctx, ordersPriceKey)
+	}
+}
+
+func (o *OrdersStorage) GetOrdersFromStorage(ctx context.Context, ids []string) ([]models.OrderModel, error) {
+	if len(ids) == 0 {
+		return []models.OrderModel{}, nil
+	}
+
+	values, err := o.client.getManyFromHash(ctx, ordersHashKey, ids...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]models.OrderModel, 0, len(values))
+
+	for _, jsonData := range values {
+		var orderInfo models.OrderModel
+
+		if err = json.Unmarshal([]byte(jsonData), &orderInfo); err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, orderInfo)
+	}
+
+	return orders, nil
+}
+
 func (o *OrdersStorage) UpdateOrderInfo(ctx context.Context, orderInfo models.OrderModel) error {
-	orderInfo.UpdatedDate = time.Now().UTC().Unix()
+	return o.UpdateOrdersInfo(ctx, orderInfo)
+}
+
+func (o *OrdersStorage) UpdateOrdersInfo(ctx context.Context, ordersInfo ...models.OrderModel) error {
+	return o.updateOrdersInfo(ctx, "", ordersInfo...)
+}
+
+// CloseOrder saves a closed order with the close event given by the caller, like cancelled or expired.
+func (o *OrdersStorage) CloseOrder(ctx context.Context, eventType string, orderInfo models.OrderModel) error {
+	return o.updateOrdersInfo(ctx, eventType, orderInfo)
+}
+
+// updateOrdersInfo logs the given event, or the one derived from the saved state when it is empty.
+func (o *OrdersStorage) updateOrdersInfo(ctx context.Context, eventType string, ordersInfo ...models.OrderModel) error {
+	ids := make([]string, 0, len(ordersInfo))
+
+	for _, orderInfo := range ordersInfo {
+		ids = append(ids, orderInfo.OrderId)
+	}
+
+	return o.writeOrders(ctx, ids, func(tx *TxContainer, stored []*models.OrderModel) error {
+		now := time.Now().UTC()
+
+		for i, orderInfo := range ordersInfo {
+			if err := updateOrderTx(ctx, tx, eventType, stored[i], orderInfo, now); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func updateOrderTx(ctx context.Context, tx *TxContainer, eventType string, stored *models.OrderModel, orderInfo models.OrderModel, now time.Time) error {
+	orderInfo.UpdatedDate = now.UnixMilli()
 
 	jsonData, err := json.Marshal(orderInfo)
 
@@ -86,88 +262,203 @@ func (o *OrdersStorage) UpdateOrderInfo(ctx context.Context, orderInfo models.Or
 		return err
 	}
 
-	if err = o.client.addInHash(ctx, ordersHashKey, orderInfo.OrderId, jsonData); err != nil {
-		return err
+	if eventType == "" {
+		eventType = getOrderEventType(orderInfo)
 	}
 
+	tx.
+		addInHash(ctx, ordersHashKey, orderInfo.OrderId, jsonData).
+		appendOrderEvent(ctx, eventType, orderInfo)
+
+	indexOrderTx(ctx, tx, stored, orderInfo)
+	changeExposureTx(ctx, tx, stored, &orderInfo)
+
 	return nil
 }
 
 func (o *OrdersStorage) DeleteOrderFromStorage(ctx context.Context, id string) error {
+	return o.writeOrders(ctx, []string{id}, func(tx *TxContainer, stored []*models.OrderModel) error {
+		if stored[0] == nil {
+			return nil
+		}
 
-	if err := o.client.removeFromHash(ctx, ordersHashKey, id); err != nil {
-		return err
-	}
+		tx.removeFromHash(ctx, ordersHashKey, id)
+		unindexOrderTx(ctx, tx, *stored[0])
+		changeExposureTx(ctx, tx, stored[0], nil)
 
-	return nil
+		return nil
+	})
 }
 
 func (o *OrdersStorage) AddInStockBook(ctx context.Context, orderInfo models.OrderModel) error {
 	tx := o.client.performTx(ctx)
 
-	err := tx.
+	addInStockBookTx(ctx, &tx, orderInfo)
+	tx.
+		appendOrderEvent(ctx, models.OrderLogBooked, orderInfo).
+		appendOrderFeedEvent(ctx, models.OrderEventAdd, orderInfo, 0)
+
+	return tx.execTx(ctx)
+}
+
+func addInStockBookTx(ctx context.Context, tx *TxContainer, orderInfo models.OrderModel) {
+	tx.
 		addInZSet(ctx, ordersPriceKey, orderInfo.OrderId, orderInfo.LimitPrice).
 		addInZSet(ctx, ordersCreationDateKey, orderInfo.OrderId, float64(orderInfo.CreationDate)).
 		addInSet(ctx, fmt.Sprintf(ordersCurrencyDirectionKey, orderInfo.CurrencyPair, orderInfo.Direction), orderInfo.OrderId).
-		addInHash(ctx, ordersExpirationDateKey, orderInfo.OrderId, orderInfo.ExpirationDate).
-		incrementHash(ctx, buildStockKey(orderInfo.CurrencyPair, orderInfo.Direction), fmt.Sprintf("%f", orderInfo.LimitPrice), orderInfo.AskVolume).
-		execTx(ctx)
+		addInSet(ctx, ordersAccountKey+orderInfo.AccountId, orderInfo.OrderId).
+		addInHash(ctx, ordersExpirationDateKey, orderInfo.OrderId, orderInfo.ExpirationDate)
+
+	if orderInfo.Hidden {
+		tx.addInSet(ctx, ordersHiddenKey, orderInfo.OrderId)
+	} else {
+		tx.changeStockLevel(ctx, buildStockKey(orderInfo.CurrencyPair, orderInfo.Direction), buildStockLevelsKey(orderInfo.CurrencyPair, orderInfo.Direction), orderInfo.LimitPrice, utils.GetRemainingVolume(orderInfo))
+	}
+}
+
+// GetBestStockLevel reads the top of the book from the level index instead of scanning the depth hash.
+func (o *OrdersStorage) GetBestStockLevel(ctx context.Context, currencyPair string, direction int) (*models.PriceLevel, error) {
+	key := buildStockLevelsKey(currencyPair, direction)
+	cmd := o.client.cli.ZRangeWithScores(ctx, key, 0, 0)
+
+	if direction == int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY) {
+		cmd = o.client.cli.ZRevRangeWithScores(ctx, key, 0, 0)
+	}
+
+	best, err := cmd.Result()
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	if len(best) == 0 {
+		return nil, staticerr.ErrorStockBookIsEmpty
+	}
+
+	volume, err := o.GetStockBookLevel(ctx, currencyPair, direction, best[0].Score)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PriceLevel{Price: best[0].Score, Volume: volume}, nil
 }
 
-func (o OrdersStorage) GetStockPriceByCurrencyPairAndDirection(ctx context.Context, currencyPair string, direction int) (float64, error) {
-	values, err := o.client.getAllFromHash(ctx, buildStockKey(currencyPair, direction))
+func (o *OrdersStorage) GetStockBookLevel(ctx context.Context, currencyPair string, direction int, price float64) (float64, error) {
+	volume, err := o.client.getFromHash(ctx, buildStockKey(currencyPair, direction), fmt.Sprintf("%f", price))
+
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
 
 	if err != nil {
 		return 0, err
 	}
 
-	totalVolume := 0.00
-	avgPrice := 0.00
+	return strconv.ParseFloat(*volume, 64)
+}
 
-	for price, volume := range values {
-		floatPrice, err := strconv.ParseFloat(price, 2)
-		if err != nil {
+// GetStockBookLevels returns up to count displayed levels from the best price, every level when count is 0.
+// The prices are read in order from the level index, their volumes from the depth hash.
+func (o *OrdersStorage) GetStockBookLevels(ctx context.Context, currencyPair string, direction int, count int64) ([]models.PriceLevel, error) {
+	key := buildStockLevelsKey(currencyPair, direction)
+	stop := count - 1
+
+	if count <= 0 {
+		stop = -1
+	}
+
+	cmd := o.client.cli.ZRangeWithScores(ctx, key, 0, stop)
+
+	if direction == int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY) {
+		cmd = o.client.cli.ZRevRangeWithScores(ctx, key, 0, stop)
+	}
+
+	values, err := cmd.Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, staticerr.ErrorStockBookIsEmpty
+	}
+
+	fields := make([]string, 0, len(values))
+
+	for _, value := range values {
+		field, _ := value.Member.(string)
+		fields = append(fields, field)
+	}
+
+	volumes, err := o.client.getFieldsFromHash(ctx, buildStockKey(currencyPair, direction), fields...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	levels := make([]models.PriceLevel, 0, len(values))
+
+	for i, volume := range volumes {
+		if volume == nil {
 			continue
 		}
-		floatVolume, err := strconv.ParseFloat(volume, 2)
 
-		if err != nil {
+		floatVolume, err := strconv.ParseFloat(*volume, 64)
+
+		if err != nil || floatVolume <= 0 {
 			continue
 		}
 
-		totalVolume += floatVolume
-		avgPrice += floatPrice * floatVolume
+		levels = append(levels, models.PriceLevel{Price: values[i].Score, Volume: floatVolume})
 	}
 
-	if totalVolume == 0 {
-		return 0, staticerr.ErrorStockBookIsEmpty
+	if len(levels) == 0 {
+		return nil, staticerr.ErrorStockBookIsEmpty
 	}
 
-	return avgPrice / totalVolume, nil
+	return levels, nil
 }
 
 func (o *OrdersStorage) DropFromStockBook(ctx context.Context, orderInfo models.OrderModel) error {
 	tx := o.client.performTx(ctx)
 
-	err := tx.
+	dropFromStockBookTx(ctx, &tx, orderInfo)
+	tx.
+		appendOrderEvent(ctx, models.OrderLogUnbooked, orderInfo).
+		appendOrderFeedEvent(ctx, models.OrderEventDelete, orderInfo, 0)
+
+	return tx.execTx(ctx)
+}
+
+// CloseBookedOrder drops the stored order from the book and saves it closed with the given event in one transaction.
+func (o *OrdersStorage) CloseBookedOrder(ctx context.Context, eventType string, orderInfo models.OrderModel) error {
+	return o.writeOrders(ctx, []string{orderInfo.OrderId}, func(tx *TxContainer, stored []*models.OrderModel) error {
+		if stored[0] == nil {
+			return redis.Nil
+		}
+
+		dropFromStockBookTx(ctx, tx, *stored[0])
+		tx.
+			appendOrderEvent(ctx, models.OrderLogUnbooked, *stored[0]).
+			appendOrderFeedEvent(ctx, models.OrderEventDelete, *stored[0], 0)
+
+		return updateOrderTx(ctx, tx, eventType, stored[0], orderInfo, time.Now().UTC())
+	})
+}
+
+func dropFromStockBookTx(ctx context.Context, tx *TxContainer, orderInfo models.OrderModel) {
+	tx.
 		removeFromZSet(ctx, ordersPriceKey, orderInfo.OrderId).
 		removeFromZSet(ctx, ordersCreationDateKey, orderInfo.OrderId).
 		removeFromSet(ctx, fmt.Sprintf(ordersCurrencyDirectionKey, orderInfo.CurrencyPair, orderInfo.Direction), orderInfo.OrderId).
-		removeFromHash(ctx, ordersExpirationDateKey, orderInfo.OrderId).
-		decrementHash(ctx, buildStockKey(orderInfo.CurrencyPair, orderInfo.Direction), fmt.Sprintf("%f", orderInfo.LimitPrice), orderInfo.AskVolume).
-		execTx(ctx)
+		removeFromSet(ctx, ordersAccountKey+orderInfo.AccountId, orderInfo.OrderId).
+		removeFromSet(ctx, ordersHiddenKey, orderInfo.OrderId).
+		removeFromHash(ctx, ordersExpirationDateKey, orderInfo.OrderId)
 
-	if err != nil {
-		return err
+	if !orderInfo.Hidden {
+		tx.changeStockLevel(ctx, buildStockKey(orderInfo.CurrencyPair, orderInfo.Direction), buildStockLevelsKey(orderInfo.CurrencyPair, orderInfo.Direction), orderInfo.LimitPrice, -utils.GetRemainingVolume(orderInfo))
 	}
-
-	return nil
 }
 
 func (o *OrdersStorage) TryLockOrder(ctx context.Context, id string, guid string) error {
@@ -186,14 +477,15 @@ func (o *OrdersStorage) GetOrdersForMatch(ctx context.Context, id string) ([]str
 	}
 
 	priceIndex := ordersPriceKey
-	if orderInfo.Type == int(ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT) {
-		priceIndex, err := o.getPriceIndexForLimit(ctx, *orderInfo)
+	if orderInfo.LimitPrice > 0 {
+		limitIndex, err := o.getPriceIndexForLimit(ctx, *orderInfo)
 
 		if err != nil {
 			return nil, err
 		}
 
-		defer o.client.deleteKey(ctx, *priceIndex)
+		defer o.client.deleteKey(ctx, *limitIndex)
+		priceIndex = *limitIndex
 	}
 
 	_, err = o.client.cli.ZInterStore(ctx, matchingCandidatesIndex+id, &redis.ZStore{
@@ -216,7 +508,68 @@ func (o *OrdersStorage) GetOrdersForMatch(ctx context.Context, id string) ([]str
 		return nil, staticerr.ErrorStockBookIsEmpty
 	}
 
-	return ids, nil
+	return o.moveHiddenBehindDisplayed(ctx, ids)
+}
+
+// moveHiddenBehindDisplayed keeps the price-time order of the candidates but puts hidden orders after
+// the displayed ones of the same price level, so a hidden order never crosses into another level.
+func (o *OrdersStorage) moveHiddenBehindDisplayed(ctx context.Context, ids []string) ([]string, error) {
+	members := make([]interface{}, len(ids))
+
+	for i, id := range ids {
+		members[i] = id
+	}
+
+	hidden, err := o.client.cli.SMIsMember(ctx, ordersHiddenKey, members...).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := o.client.cli.ZMScore(ctx, ordersPriceKey, ids...).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sortHiddenLastInLevel(ids, prices, hidden), nil
+}
+
+// sortHiddenLastInLevel expects ids grouped by price level, as the matching index returns them.
+func sortHiddenLastInLevel(ids []string, prices []float64, hidden []bool) []string {
+	levels := make([]int, len(ids))
+
+	for i := 1; i < len(ids); i++ {
+		levels[i] = levels[i-1]
+
+		if prices[i] != prices[i-1] {
+			levels[i]++
+		}
+	}
+
+	positions := make([]int, len(ids))
+
+	for i := range positions {
+		positions[i] = i
+	}
+
+	sort.SliceStable(positions, func(a, b int) bool {
+		i, j := positions[a], positions[b]
+
+		if levels[i] != levels[j] {
+			return levels[i] < levels[j]
+		}
+
+		return !hidden[i] && hidden[j]
+	})
+
+	sorted := make([]string, len(ids))
+
+	for i, position := range positions {
+		sorted[i] = ids[position]
+	}
+
+	return sorted
 }
 
 func (o OrdersStorage) getPriceIndexForLimit(ctx context.Context, orderInfo models.OrderModel) (*string, error) {
@@ -0,0 +1,311 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+)
+
+const testPair = "BTC/USDT"
+
+func bookTestOrders(t *testing.T, o *OrdersStorage, orders ...models.OrderModel) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, orderInfo := range orders {
+		if err := o.AddOrderToStorage(ctx, orderInfo); err != nil {
+			t.Fatalf("AddOrderToStorage() error = %v", err)
+		}
+
+		if orderInfo.LimitPrice == 0 {
+			continue
+		}
+
+		if err := o.AddInStockBook(ctx, orderInfo); err != nil {
+			t.Fatalf("AddInStockBook() error = %v", err)
+		}
+	}
+}
+
+func newTestOrder(id string, direction ops.OpsOrderDirection, price, volume float64, creationDate int64) models.OrderModel {
+	return models.OrderModel{
+		OrderId:        id,
+		AccountId:      "account-" + id,
+		CurrencyPair:   testPair,
+		Direction:      int(direction),
+		LimitPrice:     price,
+		AskVolume:      volume,
+		Type:           int(ops.OpsOrderType_OPS_ORDER_TYPE_LIMIT),
+		CreationDate:   creationDate,
+		ExpirationDate: time.Now().Add(time.Hour).UnixMilli(),
+		State:          int(ops.OpsOrderState_OPS_ORDER_STATE_IN_PROCESS),
+	}
+}
+
+func TestOrdersStorage_GetOrdersForMatch(t *testing.T) {
+	now := time.Now().UnixMilli()
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	buy := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY
+	asks := []models.OrderModel{
+		newTestOrder("ask-101-old", sell, 101, 1, now-2000),
+		newTestOrder("ask-100", sell, 100, 1, now-1000),
+		newTestOrder("ask-101-new", sell, 101, 1, now),
+		newTestOrder("ask-103", sell, 103, 1, now-3000),
+	}
+	bids := []models.OrderModel{
+		newTestOrder("bid-98", buy, 98, 1, now-3000),
+		newTestOrder("bid-99", buy, 99, 1, now),
+	}
+	hidden := func(orderInfo models.OrderModel) models.OrderModel {
+		orderInfo.Hidden = true
+		return orderInfo
+	}
+	tiered := []models.OrderModel{
+		hidden(newTestOrder("hidden-100-old", sell, 100, 1, now-5000)),
+		newTestOrder("ask-100", sell, 100, 1, now),
+		newTestOrder("ask-100.001", sell, 100.001, 1, now-9000),
+		hidden(newTestOrder("hidden-100.001", sell, 100.001, 1, now-9500)),
+		newTestOrder("ask-100.002", sell, 100.002, 1, now-10000),
+	}
+	tests := []struct {
+		name  string
+		book  []models.OrderModel
+		taker models.OrderModel
+		want  []string
+	}{
+		{
+			name:  "buy limit takes asks up to its price in price-time order",
+			taker: newTestOrder("taker-buy", buy, 101, 3, now),
+			want:  []string{"ask-100", "ask-101-old", "ask-101-new"},
+		},
+		{
+			name:  "sell limit takes bids down to its price",
+			taker: newTestOrder("taker-sell", sell, 99, 3, now),
+			want:  []string{"bid-99"},
+		},
+		{
+			name:  "market buy takes every ask",
+			taker: newTestOrder("taker-market", buy, 0, 3, now),
+			want:  []string{"ask-100", "ask-101-old", "ask-101-new", "ask-103"},
+		},
+		{
+			name:  "hidden orders queue behind displayed ones of their own price level only",
+			book:  tiered,
+			taker: newTestOrder("taker-buy", buy, 100.002, 5, now),
+			want:  []string{"ask-100", "hidden-100-old", "ask-100.001", "hidden-100.001", "ask-100.002"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestRedisClient(t)
+			o := NewOrdersStorage(client)
+			book := tt.book
+			if book == nil {
+				book = append(asks, bids...)
+			}
+			bookTestOrders(t, o, book...)
+
+			if err := o.AddOrderToStorage(context.Background(), tt.taker); err != nil {
+				t.Fatalf("AddOrderToStorage() error = %v", err)
+			}
+
+			got, err := o.GetOrdersForMatch(context.Background(), tt.taker.OrderId)
+
+			if err != nil {
+				t.Fatalf("GetOrdersForMatch() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetOrdersForMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sortHiddenLastInLevel(t *testing.T) {
+	tests := []struct {
+		name   string
+		ids    []string
+		prices []float64
+		hidden []bool
+		want   []string
+	}{
+		{
+			name:   "displayed orders keep their order",
+			ids:    []string{"ask-1", "ask-2"},
+			prices: []float64{100, 100},
+			hidden: []bool{false, false},
+			want:   []string{"ask-1", "ask-2"},
+		},
+		{
+			name:   "hidden order queues behind displayed ones of its level",
+			ids:    []string{"hidden", "ask-100", "ask-101"},
+			prices: []float64{100, 100, 101},
+			hidden: []bool{true, false, false},
+			want:   []string{"ask-100", "hidden", "ask-101"},
+		},
+		{
+			name:   "hidden order never crosses into the next level",
+			ids:    []string{"ask-100", "hidden", "ask-100.001"},
+			prices: []float64{100, 100, 100.001},
+			hidden: []bool{false, true, false},
+			want:   []string{"ask-100", "hidden", "ask-100.001"},
+		},
+		{
+			name:   "hidden orders keep their time priority",
+			ids:    []string{"hidden-old", "hidden-new", "ask"},
+			prices: []float64{100, 100, 100},
+			hidden: []bool{true, true, false},
+			want:   []string{"ask", "hidden-old", "hidden-new"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sortHiddenLastInLevel(tt.ids, tt.prices, tt.hidden); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortHiddenLastInLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrdersStorage_writeOrders(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newTestRedisClient(t)
+	o := NewOrdersStorage(client)
+	orderInfo := newTestOrder("order", ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL, 100, 1, time.Now().UnixMilli())
+
+	if err := o.AddOrderToStorage(ctx, orderInfo); err != nil {
+		t.Fatalf("AddOrderToStorage() error = %v", err)
+	}
+
+	concurrent := orderInfo
+	concurrent.State = int(ops.OpsOrderState_OPS_ORDER_STATE_APPROVED)
+	seen := make([]int, 0)
+
+	err := o.writeOrders(ctx, []string{orderInfo.OrderId}, func(tx *TxContainer, stored []*models.OrderModel) error {
+		seen = append(seen, stored[0].State)
+
+		if len(seen) == 1 {
+			if err := o.UpdateOrderInfo(ctx, concurrent); err != nil {
+				t.Fatalf("UpdateOrderInfo() error = %v", err)
+			}
+		}
+
+		tx.addInHash(ctx, "test:write", orderInfo.OrderId, len(seen))
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("writeOrders() error = %v", err)
+	}
+
+	want := []int{orderInfo.State, concurrent.State}
+
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("writeOrders() read stored states %v, want %v after the concurrent write", seen, want)
+	}
+}
+
+func TestOrdersStorage_CloseBookedOrder(t *testing.T) {
+	ctx := context.Background()
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	client, _ := newTestRedisClient(t)
+	o := NewOrdersStorage(client)
+	orderInfo := newTestOrder("order", sell, 100, 1, time.Now().UnixMilli())
+	bookTestOrders(t, o, orderInfo)
+
+	if err := o.CloseBookedOrder(ctx, models.OrderLogCancelled, newTestOrder("unknown", sell, 100, 1, 0)); err == nil {
+		t.Errorf("CloseBookedOrder() of an unknown order returned no error")
+	}
+
+	orderInfo.State = int(ops.OpsOrderState_OPS_ORDER_STATE_DONE)
+
+	if err := o.CloseBookedOrder(ctx, models.OrderLogCancelled, orderInfo); err != nil {
+		t.Fatalf("CloseBookedOrder() error = %v", err)
+	}
+
+	if booked, _ := o.IsInStockBook(ctx, orderInfo); booked {
+		t.Errorf("closed order is still booked")
+	}
+
+	if volume, _ := o.GetStockBookLevel(ctx, testPair, int(sell), 100); volume != 0 {
+		t.Errorf("GetStockBookLevel() = %v, want the level emptied", volume)
+	}
+
+	if stored, err := o.GetOrderFromStorage(ctx, orderInfo.OrderId); err != nil || stored.State != orderInfo.State {
+		t.Errorf("GetOrderFromStorage() = %+v, %v, want the closed order", stored, err)
+	}
+
+	events, err := o.ReadOrderEvents(ctx, "0", 10)
+
+	if err != nil {
+		t.Fatalf("ReadOrderEvents() error = %v", err)
+	}
+
+	got := make([]string, 0, len(events))
+
+	for _, event := range events {
+		got = append(got, event.EventType)
+	}
+
+	want := []string{models.OrderLogCreated, models.OrderLogBooked, models.OrderLogUnbooked, models.OrderLogCancelled}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("order events = %v, want %v", got, want)
+	}
+}
+
+func TestOrdersStorage_GetStockBookLevels(t *testing.T) {
+	now := time.Now().UnixMilli()
+	sell := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_SELL
+	buy := ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY
+	hiddenAsk := newTestOrder("hidden-99.5", sell, 99.5, 1, now)
+	hiddenAsk.Hidden = true
+	tests := []struct {
+		name      string
+		direction ops.OpsOrderDirection
+		count     int64
+		want      []models.PriceLevel
+	}{
+		{name: "best asks first", direction: sell, count: 2, want: []models.PriceLevel{{Price: 100, Volume: 2}, {Price: 101, Volume: 1}}},
+		{name: "best bids first", direction: buy, count: 1, want: []models.PriceLevel{{Price: 99, Volume: 1}}},
+		{name: "every level", direction: sell, want: []models.PriceLevel{{Price: 100, Volume: 2}, {Price: 101, Volume: 1}, {Price: 103, Volume: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestRedisClient(t)
+			o := NewOrdersStorage(client)
+			bookTestOrders(t, o,
+				newTestOrder("ask-103", sell, 103, 1, now),
+				newTestOrder("ask-100", sell, 100, 1.5, now),
+				newTestOrder("ask-100-2", sell, 100, 0.5, now),
+				newTestOrder("ask-101", sell, 101, 1, now),
+				hiddenAsk,
+				newTestOrder("bid-98", buy, 98, 1, now),
+				newTestOrder("bid-99", buy, 99, 1, now),
+			)
+
+			got, err := o.GetStockBookLevels(context.Background(), testPair, int(tt.direction), tt.count)
+
+			if err != nil {
+				t.Fatalf("GetStockBookLevels() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetStockBookLevels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	client, _ := newTestRedisClient(t)
+
+	if _, err := NewOrdersStorage(client).GetStockBookLevels(context.Background(), testPair, int(sell), 0); !errors.Is(err, staticerr.ErrorStockBookIsEmpty) {
+		t.Errorf("GetStockBookLevels() of an empty book error = %v, want %v", err, staticerr.ErrorStockBookIsEmpty)
+	}
+}
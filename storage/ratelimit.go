@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const rateLimitKey = "ratelimit:account:"
+
+// tokenBucketScript refills the bucket by elapsed time and takes one token, returning 1 when allowed.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return allowed
+`)
+
+type RateLimitStorage struct {
+	client *RedisClient
+}
+
+func NewRateLimitStorage(client *RedisClient) *RateLimitStorage {
+	return &RateLimitStorage{client: client}
+}
+
+// TakeToken takes a token from the bucket of the account, the bucket is shared by every pair the account trades.
+func (r *RateLimitStorage) TakeToken(ctx context.Context, accountId string, ratePerSec float64, burst float64) (bool, error) {
+	allowed, err := tokenBucketScript.Run(ctx, r.client.cli, []string{rateLimitKey + accountId}, ratePerSec, burst, time.Now().UTC().UnixMilli()).Int()
+
+	if err != nil {
+		return false, err
+	}
+
+	return allowed == 1, nil
+}
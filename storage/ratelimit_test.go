@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimitStorage_TakeToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		elapsedMs int64
+		want      bool
+	}{
+		{name: "empty bucket stays empty", elapsedMs: 0, want: false},
+		{name: "half a token is not enough", elapsedMs: 500, want: false},
+		{name: "bucket refills with elapsed time", elapsedMs: 1500, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client, server := newTestRedisClient(t)
+			r := NewRateLimitStorage(client)
+			key := rateLimitKey + "account"
+
+			if allowed, err := r.TakeToken(ctx, "account", 1, 1); err != nil || !allowed {
+				t.Fatalf("TakeToken() = %v, %v, want the burst token", allowed, err)
+			}
+
+			client.cli.HSet(ctx, key, "ts", time.Now().UTC().UnixMilli()-tt.elapsedMs)
+
+			got, err := r.TakeToken(ctx, "account", 1, 1)
+
+			if err != nil {
+				t.Fatalf("TakeToken() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("TakeToken() = %v, want %v", got, tt.want)
+			}
+
+			if ttl := server.TTL(key); ttl <= 0 {
+				t.Errorf("bucket TTL = %v, want it to expire", ttl)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+
+	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/utils"
+)
+
+const (
+	riskAccountKey = "risk:account:"
+)
+
+func buildRiskKey(accountId string) string {
+	return riskAccountKey + accountId
+}
+
+func buildRiskOrdersField(currencyPair string) string {
+	return currencyPair + ":orders"
+}
+
+func buildRiskNotionalField(currencyPair string) string {
+	return currencyPair + ":notional"
+}
+
+// isOrderOpen reports whether the order still counts towards the account exposure, from acceptance
+// until it is filled, cancelled or rejected.
+func isOrderOpen(orderInfo models.OrderModel) bool {
+	switch orderInfo.State {
+	case int(ops.OpsOrderState_OPS_ORDER_STATE_FILLED), int(ops.OpsOrderState_OPS_ORDER_STATE_DONE), int(ops.OpsOrderState_OPS_ORDER_STATE_REJECTED):
+		return false
+	default:
+		return true
+	}
+}
+
+func getOrderExposure(orderInfo *models.OrderModel) models.AccountExposure {
+	if orderInfo == nil || !isOrderOpen(*orderInfo) {
+		return models.AccountExposure{}
+	}
+
+	return models.AccountExposure{OpenOrders: 1, OpenNotional: utils.GetRemainingVolume(*orderInfo) * orderInfo.LimitPrice}
+}
+
+// changeExposureTx moves the account exposure from the stored version of the order to the written one,
+// nil stands for no order. Exposure is reserved on acceptance and released by fills and closing states.
+func changeExposureTx(ctx context.Context, tx *TxContainer, stored *models.OrderModel, written *models.OrderModel) {
+	orderInfo := written
+
+	if orderInfo == nil {
+		orderInfo = stored
+	}
+
+	if orderInfo == nil {
+		return
+	}
+
+	before, after := getOrderExposure(stored), getOrderExposure(written)
+
+	if orders := after.OpenOrders - before.OpenOrders; orders != 0 {
+		tx.incrementHashInt(ctx, buildRiskKey(orderInfo.AccountId), buildRiskOrdersField(orderInfo.CurrencyPair), orders)
+	}
+
+	if notional := after.OpenNotional - before.OpenNotional; notional != 0 {
+		tx.incrementHash(ctx, buildRiskKey(orderInfo.AccountId), buildRiskNotionalField(orderInfo.CurrencyPair), notional)
+	}
+}
+
+type RiskStorage struct {
+	client *RedisClient
+}
+
+func NewRiskStorage(client *RedisClient) *RiskStorage {
+	return &RiskStorage{client: client}
+}
+
+func (r *RiskStorage) GetAccountExposure(ctx context.Context, accountId string, currencyPair string) (*models.AccountExposure, error) {
+	values, err := r.client.getAllFromHash(ctx, buildRiskKey(accountId))
+
+	if err != nil {
+		return nil, err
+	}
+
+	exposure := models.AccountExposure{}
+
+	if value, ok := values[buildRiskOrdersField(currencyPair)]; ok {
+		if exposure.OpenOrders, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	if value, ok := values[buildRiskNotionalField(currencyPair)]; ok {
+		if exposure.OpenNotional, err = strconv.ParseFloat(value, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	return &exposure, nil
+}
@@ -0,0 +1,313 @@
+package storage
+
+import (
+	"math"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Stock book snapshots are protobuf encoded by hand, trade-protos has no message for them yet:
+//
+//	message StockBookSnapshot { int32 version = 1; int64 created_date = 2; repeated PairBook books = 3; }
+//	message PairBook { string currency_pair = 1; repeated Order orders = 2; repeated PriceLevel bids = 3; repeated PriceLevel asks = 4; }
+//	message PriceLevel { double price = 1; double volume = 2; }
+//	message Order { fields 1-24 follow models.OrderModel declaration order }
+
+type wireValue struct {
+	varint uint64
+	bytes  []byte
+}
+
+func (v wireValue) double() float64 {
+	return math.Float64frombits(v.varint)
+}
+
+func marshalSnapshot(snapshot models.StockBookSnapshot) []byte {
+	b := appendVarint(nil, 1, uint64(snapshot.Version))
+	b = appendVarint(b, 2, uint64(snapshot.CreatedDate))
+
+	for _, book := range snapshot.Books {
+		b = appendMessage(b, 3, marshalPairBook(book))
+	}
+
+	return b
+}
+
+func unmarshalSnapshot(b []byte) (*models.StockBookSnapshot, error) {
+	snapshot := models.StockBookSnapshot{}
+
+	err := consumeFields(b, func(num protowire.Number, v wireValue) error {
+		switch num {
+		case 1:
+			snapshot.Version = int(v.varint)
+		case 2:
+			snapshot.CreatedDate = int64(v.varint)
+		case 3:
+			book, err := unmarshalPairBook(v.bytes)
+
+			if err != nil {
+				return err
+			}
+
+			snapshot.Books = append(snapshot.Books, *book)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+func marshalPairBook(book models.PairBookSnapshot) []byte {
+	b := appendString(nil, 1, book.CurrencyPair)
+
+	for _, order := range book.Orders {
+		b = appendMessage(b, 2, marshalOrder(order))
+	}
+
+	for _, level := range book.Bids {
+		b = appendMessage(b, 3, marshalPriceLevel(level))
+	}
+
+	for _, level := range book.Asks {
+		b = appendMessage(b, 4, marshalPriceLevel(level))
+	}
+
+	return b
+}
+
+func unmarshalPairBook(b []byte) (*models.PairBookSnapshot, error) {
+	book := models.PairBookSnapshot{}
+
+	err := consumeFields(b, func(num protowire.Number, v wireValue) error {
+		switch num {
+		case 1:
+			book.CurrencyPair = string(v.bytes)
+		case 2:
+			order, err := unmarshalOrder(v.bytes)
+
+			if err != nil {
+				return err
+			}
+
+			book.Orders = append(book.Orders, *order)
+		case 3, 4:
+			level, err := unmarshalPriceLevel(v.bytes)
+
+			if err != nil {
+				return err
+			}
+
+			if num == 3 {
+				book.Bids = append(book.Bids, *level)
+			} else {
+				book.Asks = append(book.Asks, *level)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &book, nil
+}
+
+func marshalPriceLevel(level models.PriceLevel) []byte {
+	b := appendDouble(nil, 1, level.Price)
+	return appendDouble(b, 2, level.Volume)
+}
+
+func unmarshalPriceLevel(b []byte) (*models.PriceLevel, error) {
+	level := models.PriceLevel{}
+
+	err := consumeFields(b, func(num protowire.Number, v wireValue) error {
+		switch num {
+		case 1:
+			level.Price = v.double()
+		case 2:
+			level.Volume = v.double()
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &level, nil
+}
+
+func marshalOrder(order models.OrderModel) []byte {
+	b := appendString(nil, 1, order.OrderId)
+	b = appendString(b, 2, order.AccountId)
+	b = appendString(b, 3, order.AssetId)
+	b = appendString(b, 4, order.CurrencyPair)
+	b = appendVarint(b, 5, uint64(order.Direction))
+	b = appendDouble(b, 6, order.LimitPrice)
+	b = appendDouble(b, 7, order.AskVolume)
+	b = appendDouble(b, 8, order.FilledVolume)
+	b = appendVarint(b, 9, uint64(order.Type))
+	b = appendDouble(b, 10, order.FilledPrice)
+	b = appendVarint(b, 11, uint64(order.CreationDate))
+	b = appendVarint(b, 12, uint64(order.UpdatedDate))
+	b = appendVarint(b, 13, uint64(order.ExpirationDate))
+	b = appendVarint(b, 14, uint64(order.MatchingDate))
+	b = appendString(b, 15, order.TransferId)
+	b = appendVarint(b, 16, uint64(order.State))
+	b = appendString(b, 17, order.ParentId)
+	b = appendString(b, 18, order.ExchangeId)
+	b = appendDouble(b, 19, order.MaxSlippageBps)
+	b = appendDouble(b, 20, order.LockedAmount)
+	b = appendDouble(b, 21, order.SpentAmount)
+	b = appendDouble(b, 22, order.FilledNotional)
+
+	for _, tradeId := range order.TradeIds {
+		b = appendString(b, 23, tradeId)
+	}
+
+	if order.Hidden {
+		b = appendVarint(b, 24, 1)
+	}
+
+	return b
+}
+
+func unmarshalOrder(b []byte) (*models.OrderModel, error) {
+	order := models.OrderModel{}
+
+	err := consumeFields(b, func(num protowire.Number, v wireValue) error {
+		switch num {
+		case 1:
+			order.OrderId = string(v.bytes)
+		case 2:
+			order.AccountId = string(v.bytes)
+		case 3:
+			order.AssetId = string(v.bytes)
+		case 4:
+			order.CurrencyPair = string(v.bytes)
+		case 5:
+			order.Direction = int(v.varint)
+		case 6:
+			order.LimitPrice = v.double()
+		case 7:
+			order.AskVolume = v.double()
+		case 8:
+			order.FilledVolume = v.double()
+		case 9:
+			order.Type = int(v.varint)
+		case 10:
+			order.FilledPrice = v.double()
+		case 11:
+			order.CreationDate = int64(v.varint)
+		case 12:
+			order.UpdatedDate = int64(v.varint)
+		case 13:
+			order.ExpirationDate = int64(v.varint)
+		case 14:
+			order.MatchingDate = int64(v.varint)
+		case 15:
+			order.TransferId = string(v.bytes)
+		case 16:
+			order.State = int(v.varint)
+		case 17:
+			order.ParentId = string(v.bytes)
+		case 18:
+			order.ExchangeId = string(v.bytes)
+		case 19:
+			order.MaxSlippageBps = v.double()
+		case 20:
+			order.LockedAmount = v.double()
+		case 21:
+			order.SpentAmount = v.double()
+		case 22:
+			order.FilledNotional = v.double()
+		case 23:
+			order.TradeIds = append(order.TradeIds, string(v.bytes))
+		case 24:
+			order.Hidden = v.varint != 0
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+func appendVarint(b []byte, num protowire.Number, value uint64) []byte {
+	if value == 0 {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, value)
+}
+
+func appendDouble(b []byte, num protowire.Number, value float64) []byte {
+	if value == 0 {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(value))
+}
+
+func appendString(b []byte, num protowire.Number, value string) []byte {
+	if value == "" {
+		return b
+	}
+
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func appendMessage(b []byte, num protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
+
+// consumeFields walks the message fields, fixed64 values are passed in varint as raw bits.
+func consumeFields(b []byte, handle func(num protowire.Number, v wireValue) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+
+		if n < 0 {
+			return staticerr.ErrorSnapshotIsCorrupted
+		}
+
+		b = b[n:]
+		value := wireValue{}
+
+		switch typ {
+		case protowire.VarintType:
+			value.varint, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			value.varint, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			value.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+
+		if n < 0 {
+			return staticerr.ErrorSnapshotIsCorrupted
+		}
+
+		b = b[n:]
+
+		if err := handle(num, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
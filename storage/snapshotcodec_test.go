@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+)
+
+func TestSnapshotCodec_order(t *testing.T) {
+	full := models.OrderModel{
+		OrderId:        "order",
+		AccountId:      "account",
+		AssetId:        "asset",
+		CurrencyPair:   testPair,
+		Direction:      1,
+		LimitPrice:     100.5,
+		AskVolume:      2,
+		FilledVolume:   0.5,
+		Type:           2,
+		FilledPrice:    100.25,
+		CreationDate:   1700000000000,
+		UpdatedDate:    1700000000001,
+		ExpirationDate: 1700000000002,
+		MatchingDate:   1700000000003,
+		TransferId:     "transfer",
+		State:          3,
+		ParentId:       "parent",
+		ExchangeId:     "exchange",
+		MaxSlippageBps: 15,
+		LockedAmount:   201,
+		SpentAmount:    50.125,
+		FilledNotional: 50.125,
+		TradeIds:       []string{"trade-1", "trade-2"},
+		Hidden:         true,
+	}
+	negative := models.OrderModel{
+		OrderId:        "negative",
+		Direction:      -1,
+		LimitPrice:     -1.5,
+		FilledPrice:    -0.25,
+		CreationDate:   -1,
+		ExpirationDate: -1700000000000,
+		State:          -2,
+		MaxSlippageBps: -3,
+	}
+
+	for i := 0; i < reflect.TypeOf(full).NumField(); i++ {
+		if reflect.ValueOf(full).Field(i).IsZero() {
+			t.Fatalf("field %v is not covered by the round trip", reflect.TypeOf(full).Field(i).Name)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		order models.OrderModel
+	}{
+		{name: "every field", order: full},
+		{name: "zero order", order: models.OrderModel{}},
+		{name: "negative values", order: negative},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unmarshalOrder(marshalOrder(tt.order))
+
+			if err != nil {
+				t.Fatalf("unmarshalOrder() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(*got, tt.order) {
+				t.Errorf("unmarshalOrder() = %+v, want %+v", *got, tt.order)
+			}
+		})
+	}
+}
+
+func TestSnapshotCodec_truncated(t *testing.T) {
+	snapshot := models.StockBookSnapshot{
+		Version:     models.StockBookSnapshotVersion,
+		CreatedDate: 1700000000000,
+		Books: []models.PairBookSnapshot{{
+			CurrencyPair: testPair,
+			Orders:       []models.OrderModel{{OrderId: "order", LimitPrice: 100, AskVolume: 1, Hidden: true}},
+			Bids:         []models.PriceLevel{{Price: 100, Volume: 1}},
+		}},
+	}
+	data := marshalSnapshot(snapshot)
+
+	got, err := unmarshalSnapshot(data)
+
+	if err != nil || !reflect.DeepEqual(*got, snapshot) {
+		t.Fatalf("unmarshalSnapshot() = %+v, %v, want %+v", got, err, snapshot)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "cut inside the books", data: data[:len(data)-1]},
+		{name: "cut inside a tag", data: append(append([]byte{}, data...), 0x80)},
+		{name: "cut inside the created date", data: data[:3]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := unmarshalSnapshot(tt.data); !errors.Is(err, staticerr.ErrorSnapshotIsCorrupted) {
+				t.Errorf("unmarshalSnapshot() error = %v, want %v", err, staticerr.ErrorSnapshotIsCorrupted)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+)
+
+const snapshotFileName = "stock_book_%d.v%d.pb"
+
+type SnapshotStorage struct {
+	dir string
+}
+
+func NewSnapshotStorage(dir string) *SnapshotStorage {
+	return &SnapshotStorage{dir: dir}
+}
+
+// SaveSnapshot writes the snapshot next to a temporary file and renames it, so a crash never leaves a partial snapshot.
+func (s *SnapshotStorage) SaveSnapshot(snapshot models.StockBookSnapshot) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(s.dir, fmt.Sprintf(snapshotFileName, snapshot.CreatedDate, snapshot.Version))
+	tmpPath := path + ".tmp"
+
+	if err := os.WriteFile(tmpPath, marshalSnapshot(snapshot), 0o644); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// LoadSnapshot reads a snapshot by its file name or by the path SaveSnapshot returned,
+// paths outside the snapshot dir are refused.
+func (s *SnapshotStorage) LoadSnapshot(path string) (*models.StockBookSnapshot, error) {
+	path, err := s.resolvePath(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := unmarshalSnapshot(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshot.Version < 1 || snapshot.Version > models.StockBookSnapshotVersion {
+		return nil, staticerr.ErrorSnapshotVersionUnsupported
+	}
+
+	return snapshot, nil
+}
+
+func (s *SnapshotStorage) resolvePath(path string) (string, error) {
+	dir, err := filepath.Abs(s.dir)
+
+	if err != nil {
+		return "", err
+	}
+
+	if filepath.Base(path) == path {
+		path = filepath.Join(dir, path)
+	}
+
+	path, err = filepath.Abs(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	if filepath.Dir(path) != dir {
+		return "", staticerr.ErrorSnapshotPathOutsideDir
+	}
+
+	return path, nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+)
+
+func TestSnapshotStorage_LoadSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	s := NewSnapshotStorage(dir)
+	path, err := s.SaveSnapshot(models.StockBookSnapshot{Version: models.StockBookSnapshotVersion, CreatedDate: 1})
+
+	if err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr error
+	}{
+		{name: "saved path", path: path},
+		{name: "file name", path: filepath.Base(path)},
+		{name: "path outside the dir", path: filepath.Join(dir, "..", filepath.Base(path)), wantErr: staticerr.ErrorSnapshotPathOutsideDir},
+		{name: "relative escape", path: "../" + filepath.Base(path), wantErr: staticerr.ErrorSnapshotPathOutsideDir},
+		{name: "absolute path elsewhere", path: "/etc/passwd", wantErr: staticerr.ErrorSnapshotPathOutsideDir},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.LoadSnapshot(tt.path)
+
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("LoadSnapshot() error = %v", err)
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoadSnapshot() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
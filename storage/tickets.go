@@ -2,11 +2,14 @@ package storage
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
+	"time"
+	"trade-order-processing-service/external/bps"
 	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
 
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
@@ -26,13 +29,7 @@ func NewTicketStorage(client *RedisClient) *TicketStorage {
 func (t *TicketStorage) AddNewTicket(ctx context.Context, operationType ops.OpsTicketOperation, ticketData protoreflect.ProtoMessage) error {
 	ticketId := uuid.NewString()
 
-	bytes, err := proto.Marshal(ticketData)
-
-	if err != nil {
-		return err
-	}
-
-	data, err := base64.StdEncoding.DecodeString(string(bytes))
+	data, err := proto.Marshal(ticketData)
 
 	if err != nil {
 		return err
@@ -51,8 +48,38 @@ func (t *TicketStorage) AddNewTicket(ctx context.Context, operationType ops.OpsT
 		return err
 	}
 
-	return t.client.addInList(ctx, ticketsListKey, jsonData)
+	tx := t.client.performTx(ctx)
+	tx.addInList(ctx, ticketsListKey, jsonData)
 
+	if correlationId := getTicketCorrelationId(ticketData); correlationId != "" {
+		details, _ := protojson.Marshal(ticketData)
+
+		tx.appendAudit(ctx, correlationId, models.AuditEntryModel{
+			Timestamp:     time.Now().UTC().UnixMilli(),
+			Source:        models.AuditSourceTicket,
+			Type:          operationType.String(),
+			CorrelationId: ticketId,
+			Details:       details,
+		})
+	}
+
+	return tx.execTx(ctx)
+}
+
+// getTicketCorrelationId returns the order or transfer the ticket belongs to.
+func getTicketCorrelationId(ticketData protoreflect.ProtoMessage) string {
+	switch data := ticketData.(type) {
+	case *ops.OpsOrderInfo:
+		return data.OrderId
+	case *bps.BpsLockBalanceRequest:
+		return data.Id
+	case *bps.BpsRefundBalanceRequest:
+		return data.Id
+	case *bps.BpsCreateTransferRequest:
+		return data.Id
+	default:
+		return ""
+	}
 }
 
 func (t *TicketStorage) GetTicketFromStorage(ctx context.Context) (*ops.Ticket, error) {
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"trade-order-processing-service/external/ops"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTicketStorage_AddNewTicket(t *testing.T) {
+	tests := []struct {
+		name string
+		data *ops.OpsOrderInfo
+	}{
+		{name: "order notification", data: &ops.OpsOrderInfo{OrderId: "order-1", AccountId: "account-1", CurrencyPair: testPair, LimitPrice: 100, AskVolume: 2}},
+		{name: "empty message", data: &ops.OpsOrderInfo{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestRedisClient(t)
+			s := NewTicketStorage(client)
+
+			if err := s.AddNewTicket(context.Background(), ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION, tt.data); err != nil {
+				t.Fatalf("AddNewTicket() error = %v", err)
+			}
+
+			ticket, err := s.GetTicketFromStorage(context.Background())
+
+			if err != nil {
+				t.Fatalf("GetTicketFromStorage() error = %v", err)
+			}
+
+			var got ops.OpsOrderInfo
+
+			if err = proto.Unmarshal(ticket.Data, &got); err != nil {
+				t.Fatalf("proto.Unmarshal() error = %v", err)
+			}
+
+			if !proto.Equal(&got, tt.data) || ticket.OperationType != ops.OpsTicketOperation_OPS_TICKET_OPERATION_ORDER_NOTIFICATION {
+				t.Errorf("GetTicketFromStorage() = %v, %v, want %v", ticket.OperationType, &got, tt.data)
+			}
+		})
+	}
+}
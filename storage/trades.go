@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Trades are kept tradesRetention after their execution. Every trade write drops up to tradesPruneBatch
+// expired trades found through the date index, trims the indexes it writes to and extends their expiry,
+// so the index of an order or an account that stopped trading expires with its last trade.
+const (
+	tradesHashKey        = "trades"
+	tradesByDateIndex    = "trades:date"
+	tradesByOrderIndex   = "trades:order:"
+	tradesByAccountIndex = "trades:account:"
+	tradesRetention      = ordersEventsRetention
+	tradesPruneBatch     = 100
+)
+
+type TradeStorage struct {
+	client *RedisClient
+}
+
+func NewTradeStorage(client *RedisClient) *TradeStorage {
+	return &TradeStorage{client: client}
+}
+
+func (t *TradeStorage) AddTradeToStorage(ctx context.Context, tradeInfo models.TradeModel) error {
+	jsonData, err := json.Marshal(tradeInfo)
+
+	if err != nil {
+		return err
+	}
+
+	cutoff := getTradesCutoff()
+	expired, err := t.client.cli.ZRangeByScore(ctx, tradesByDateIndex, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   "(" + strconv.FormatInt(cutoff, 10),
+		Count: tradesPruneBatch,
+	}).Result()
+
+	if err != nil {
+		return err
+	}
+
+	tx := t.client.performTx(ctx)
+	tx.
+		removeTrades(ctx, expired).
+		addInHash(ctx, tradesHashKey, tradeInfo.TradeId, jsonData).
+		addInZSet(ctx, tradesByDateIndex, tradeInfo.TradeId, float64(tradeInfo.TradeDate)).
+		addTradeInIndex(ctx, tradesByOrderIndex+tradeInfo.MakerOrderId, tradeInfo, cutoff).
+		addTradeInIndex(ctx, tradesByOrderIndex+tradeInfo.TakerOrderId, tradeInfo, cutoff).
+		addTradeInIndex(ctx, tradesByAccountIndex+tradeInfo.MakerAccountId, tradeInfo, cutoff).
+		addTradeInIndex(ctx, tradesByAccountIndex+tradeInfo.TakerAccountId, tradeInfo, cutoff)
+
+	return tx.execTx(ctx)
+}
+
+func (x *TxContainer) addTradeInIndex(ctx context.Context, key string, tradeInfo models.TradeModel, cutoff int64) *TxContainer {
+	return x.
+		addInZSet(ctx, key, tradeInfo.TradeId, float64(tradeInfo.TradeDate)).
+		removeFromZSetByScore(ctx, key, "-inf", "("+strconv.FormatInt(cutoff, 10)).
+		expireKey(ctx, key, tradesRetention)
+}
+
+func (x *TxContainer) removeTrades(ctx context.Context, ids []string) *TxContainer {
+	if len(ids) == 0 {
+		return x
+	}
+
+	x.tx.HDel(ctx, tradesHashKey, ids...)
+	x.tx.ZRem(ctx, tradesByDateIndex, ids)
+
+	return x
+}
+
+func (t *TradeStorage) GetTradeFromStorage(ctx context.Context, id string) (*models.TradeModel, error) {
+	jsonData, err := t.client.getFromHash(ctx, tradesHashKey, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var tradeInfo models.TradeModel
+
+	if err = json.Unmarshal([]byte(*jsonData), &tradeInfo); err != nil {
+		return nil, err
+	}
+
+	return &tradeInfo, nil
+}
+
+func (t *TradeStorage) GetTradesByOrder(ctx context.Context, orderId string) ([]models.TradeModel, error) {
+	entries, err := t.getIndexPage(ctx, tradesByOrderIndex+orderId, 0, 0, -1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		ids = append(ids, entry.TradeId)
+	}
+
+	return t.GetTradesFromStorage(ctx, ids)
+}
+
+// GetAccountTradeIndex returns a page of the account trades from fromDate on in execution order,
+// trades at the same date come in the order of their ids.
+func (t *TradeStorage) GetAccountTradeIndex(ctx context.Context, accountId string, fromDate, offset, count int64) ([]models.TradeIndexEntry, error) {
+	return t.getIndexPage(ctx, tradesByAccountIndex+accountId, fromDate, offset, count)
+}
+
+// getIndexPage leaves out the trades older than tradesRetention, they may be pruned before the index is trimmed.
+func (t *TradeStorage) getIndexPage(ctx context.Context, key string, fromDate, offset, count int64) ([]models.TradeIndexEntry, error) {
+	values, err := t.client.cli.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min:    strconv.FormatInt(max(fromDate, getTradesCutoff()), 10),
+		Max:    "+inf",
+		Offset: offset,
+		Count:  count,
+	}).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.TradeIndexEntry, 0, len(values))
+
+	for _, value := range values {
+		id, _ := value.Member.(string)
+		entries = append(entries, models.TradeIndexEntry{TradeId: id, Date: int64(value.Score)})
+	}
+
+	return entries, nil
+}
+
+// GetTradesFromStorage returns the trades in the order of the ids.
+func (t *TradeStorage) GetTradesFromStorage(ctx context.Context, ids []string) ([]models.TradeModel, error) {
+	if len(ids) == 0 {
+		return []models.TradeModel{}, nil
+	}
+
+	values, err := t.client.getFieldsFromHash(ctx, tradesHashKey, ids...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	trades := make([]models.TradeModel, 0, len(values))
+
+	for i, jsonData := range values {
+		if jsonData == nil {
+			return nil, fmt.Errorf("%w: %s", staticerr.ErrorTradeIsMissing, ids[i])
+		}
+
+		var tradeInfo models.TradeModel
+
+		if err = json.Unmarshal([]byte(*jsonData), &tradeInfo); err != nil {
+			return nil, err
+		}
+
+		trades = append(trades, tradeInfo)
+	}
+
+	return trades, nil
+}
+
+func getTradesCutoff() int64 {
+	return time.Now().UTC().UnixMilli() - tradesRetention.Milliseconds()
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
+)
+
+// getAccountTrades reads the whole account index the way a single page would.
+func getAccountTrades(ctx context.Context, s *TradeStorage, accountId string) ([]models.TradeModel, error) {
+	entries, err := s.GetAccountTradeIndex(ctx, accountId, 0, 0, -1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		ids = append(ids, entry.TradeId)
+	}
+
+	return s.GetTradesFromStorage(ctx, ids)
+}
+
+func TestTradeStorage_GetTrades(t *testing.T) {
+	now := time.Now().UnixMilli()
+	trades := []models.TradeModel{
+		{TradeId: "trade-2", MakerOrderId: "maker", TakerOrderId: "taker-2", MakerAccountId: "alice", TakerAccountId: "bob", TradeDate: now - 2000},
+		{TradeId: "trade-1", MakerOrderId: "maker", TakerOrderId: "taker-1", MakerAccountId: "alice", TakerAccountId: "carol", TradeDate: now - 3000},
+		{TradeId: "trade-3", MakerOrderId: "other", TakerOrderId: "taker-2", MakerAccountId: "dave", TakerAccountId: "bob", TradeDate: now - 1000},
+	}
+	tests := []struct {
+		name      string
+		orderId   string
+		accountId string
+		deleted   string
+		want      []string
+		wantErr   error
+	}{
+		{
+			name:    "maker order in execution order",
+			orderId: "maker",
+			want:    []string{"trade-1", "trade-2"},
+		},
+		{
+			name:    "taker order",
+			orderId: "taker-2",
+			want:    []string{"trade-2", "trade-3"},
+		},
+		{
+			name:      "account on both sides",
+			accountId: "bob",
+			want:      []string{"trade-2", "trade-3"},
+		},
+		{
+			name:    "unknown order",
+			orderId: "unknown",
+			want:    []string{},
+		},
+		{
+			name:      "indexed trade is missing",
+			accountId: "alice",
+			deleted:   "trade-1",
+			wantErr:   staticerr.ErrorTradeIsMissing,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newTestRedisClient(t)
+			s := NewTradeStorage(client)
+
+			for _, tradeInfo := range trades {
+				if err := s.AddTradeToStorage(context.Background(), tradeInfo); err != nil {
+					t.Fatalf("AddTradeToStorage() error = %v", err)
+				}
+			}
+
+			if tt.deleted != "" {
+				server.HDel(tradesHashKey, tt.deleted)
+			}
+
+			got, err := getAccountTrades(context.Background(), s, tt.accountId)
+
+			if tt.orderId != "" {
+				got, err = s.GetTradesByOrder(context.Background(), tt.orderId)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("query error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			ids := make([]string, 0, len(got))
+			for _, tradeInfo := range got {
+				ids = append(ids, tradeInfo.TradeId)
+			}
+
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("query = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestTradeStorage_retention(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UnixMilli()
+	expired := now - tradesRetention.Milliseconds() - time.Hour.Milliseconds()
+	client, server := newTestRedisClient(t)
+	s := NewTradeStorage(client)
+
+	if err := s.AddTradeToStorage(ctx, models.TradeModel{TradeId: "old", MakerOrderId: "maker", TakerOrderId: "taker-1", MakerAccountId: "alice", TakerAccountId: "bob", TradeDate: expired}); err != nil {
+		t.Fatalf("AddTradeToStorage() error = %v", err)
+	}
+
+	if got, err := getAccountTrades(ctx, s, "alice"); err != nil || len(got) != 0 {
+		t.Errorf("account trades = %v, %v, want the expired trade left out", got, err)
+	}
+
+	if err := s.AddTradeToStorage(ctx, models.TradeModel{TradeId: "new", MakerOrderId: "maker", TakerOrderId: "taker-2", MakerAccountId: "alice", TakerAccountId: "carol", TradeDate: now}); err != nil {
+		t.Fatalf("AddTradeToStorage() error = %v", err)
+	}
+
+	if server.HGet(tradesHashKey, "old") != "" {
+		t.Errorf("expired trade is kept in %v", tradesHashKey)
+	}
+
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{key: tradesByDateIndex, want: []string{"new"}},
+		{key: tradesByOrderIndex + "maker", want: []string{"new"}},
+		{key: tradesByAccountIndex + "alice", want: []string{"new"}},
+		{key: tradesByAccountIndex + "bob", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got, _ := client.cli.ZRange(ctx, tt.key, 0, -1).Result(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%v = %v, want %v", tt.key, got, tt.want)
+			}
+
+			if tt.key != tradesByDateIndex && len(tt.want) > 0 && server.TTL(tt.key) != tradesRetention {
+				t.Errorf("%v expires in %v, want %v", tt.key, server.TTL(tt.key), tradesRetention)
+			}
+		})
+	}
+}
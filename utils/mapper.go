@@ -1,11 +1,13 @@
 package utils
 
 import (
+	"errors"
 	"time"
 
 	"trade-order-processing-service/external/bps"
 	"trade-order-processing-service/external/ops"
 	"trade-order-processing-service/models"
+	"trade-order-processing-service/staticerr"
 
 	"github.com/google/uuid"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -23,7 +25,7 @@ func MapOrderInfoToProto(model models.OrderModel) *ops.OpsOrderInfo {
 		AskVolume:      model.AskVolume,
 		FilledVolume:   model.FilledVolume,
 		Type:           ops.OpsOrderType(model.Type),
-		FillPrice:      model.FilledVolume,
+		FillPrice:      model.FilledPrice,
 		CreationDate:   timestamppb.New(time.UnixMilli(model.CreationDate)),
 		UpdatedDate:    timestamppb.New(time.UnixMilli(model.UpdatedDate)),
 		ExpirationDate: timestamppb.New(time.UnixMilli(model.ExpirationDate)),
@@ -57,6 +59,63 @@ func MapProtoOrderInfoToModel(protoModel *ops.OpsOrderInfo) models.OrderModel {
 	}
 }
 
+func MapOrderFillToExecutionReport(orderInfo models.OrderModel, tradeInfo models.TradeModel) models.ExecutionReportModel {
+	liquidity := models.LiquidityTaker
+
+	switch {
+	case tradeInfo.Auction:
+		liquidity = models.LiquidityAuction
+	case tradeInfo.MakerOrderId == orderInfo.OrderId:
+		liquidity = models.LiquidityMaker
+	}
+
+	return models.ExecutionReportModel{
+		ReportId:         uuid.NewString(),
+		OrderId:          orderInfo.OrderId,
+		AccountId:        orderInfo.AccountId,
+		TradeId:          tradeInfo.TradeId,
+		CurrencyPair:     orderInfo.CurrencyPair,
+		Direction:        orderInfo.Direction,
+		LastVolume:       tradeInfo.Volume,
+		LastPrice:        tradeInfo.Price,
+		CumulativeVolume: orderInfo.FilledVolume,
+		AveragePrice:     orderInfo.FilledPrice,
+		LeavesVolume:     GetRemainingVolume(orderInfo),
+		Liquidity:        liquidity,
+		State:            orderInfo.State,
+		ReportDate:       tradeInfo.TradeDate,
+	}
+}
+
+func MapErrorToOpsError(err error) *ops.OpsError {
+	switch {
+	case errors.Is(err, staticerr.ErrorStockBookIsEmpty):
+		return &ops.OpsError{Message: err.Error(), ErrorCode: ops.OpsErrorCode_OPS_ERROR_CODE_STOCK_BOOK_IS_EMPTY}
+	case errors.Is(err, staticerr.ErrorOpenOrdersLimitExceeded):
+		return &ops.OpsError{Message: err.Error(), ErrorCode: staticerr.OpsErrorCodeOpenOrdersLimitExceeded}
+	case errors.Is(err, staticerr.ErrorOpenNotionalLimitExceeded):
+		return &ops.OpsError{Message: err.Error(), ErrorCode: staticerr.OpsErrorCodeOpenNotionalLimitExceeded}
+	case errors.Is(err, staticerr.ErrorOrderNotionalLimitExceeded):
+		return &ops.OpsError{Message: err.Error(), ErrorCode: staticerr.OpsErrorCodeOrderNotionalLimitExceeded}
+	case errors.Is(err, staticerr.ErrorPriceOutOfBand):
+		return &ops.OpsError{Message: err.Error(), ErrorCode: staticerr.OpsErrorCodePriceOutOfBand}
+	case errors.Is(err, staticerr.ErrorTradingHalted):
+		return &ops.OpsError{Message: err.Error(), ErrorCode: staticerr.OpsErrorCodeTradingHalted}
+	case errors.Is(err, staticerr.ErrorPairIsNotOpen):
+		return &ops.OpsError{Message: err.Error(), ErrorCode: staticerr.OpsErrorCodePairIsNotOpen}
+	case errors.Is(err, staticerr.ErrorOrderIsNotCancellable):
+		return &ops.OpsError{Message: err.Error(), ErrorCode: staticerr.OpsErrorCodeOrderIsNotCancellable}
+	case errors.Is(err, staticerr.ErrorOrderNotBelongsToAccount):
+		return &ops.OpsError{Message: err.Error(), ErrorCode: staticerr.OpsErrorCodeOrderNotBelongsToAccount}
+	case errors.Is(err, staticerr.ErrorAccountIsBlocked):
+		return &ops.OpsError{Message: err.Error(), ErrorCode: staticerr.OpsErrorCodeAccountIsBlocked}
+	case errors.Is(err, staticerr.ErrorRateLimitExceeded):
+		return &ops.OpsError{Message: err.Error(), ErrorCode: staticerr.OpsErrorCodeRateLimitExceeded}
+	default:
+		return &ops.OpsError{Message: err.Error(), ErrorCode: ops.OpsErrorCode_OPS_ERROR_CODE_INTERNAL}
+	}
+}
+
 func MapBpsErrorToOpsError(err *bps.BpsError) *ops.OpsError {
 	if err == nil {
 		return nil
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"trade-order-processing-service/models"
+)
+
+func TestMapOrderFillToExecutionReport(t *testing.T) {
+	tests := []struct {
+		name  string
+		order models.OrderModel
+		trade models.TradeModel
+		want  models.ExecutionReportModel
+	}{
+		{
+			name:  "first fill of a taker",
+			order: models.OrderModel{OrderId: "taker", AskVolume: 6, FilledVolume: 1, FilledNotional: 100, FilledPrice: 100, State: 3},
+			trade: models.TradeModel{TradeId: "trade-1", Price: 100, Volume: 1, MakerOrderId: "maker", TakerOrderId: "taker"},
+			want:  models.ExecutionReportModel{LastVolume: 1, LastPrice: 100, CumulativeVolume: 1, AveragePrice: 100, LeavesVolume: 5, Liquidity: models.LiquidityTaker, State: 3},
+		},
+		{
+			name:  "third fill averages every fill, not the last one",
+			order: models.OrderModel{OrderId: "taker", AskVolume: 6, FilledVolume: 6, FilledNotional: 614, FilledPrice: 614.0 / 6, State: 4},
+			trade: models.TradeModel{TradeId: "trade-3", Price: 104, Volume: 3, MakerOrderId: "maker", TakerOrderId: "taker"},
+			want:  models.ExecutionReportModel{LastVolume: 3, LastPrice: 104, CumulativeVolume: 6, AveragePrice: 614.0 / 6, LeavesVolume: 0, Liquidity: models.LiquidityTaker, State: 4},
+		},
+		{
+			name:  "maker side",
+			order: models.OrderModel{OrderId: "maker", AskVolume: 10, FilledVolume: 4, FilledNotional: 402, FilledPrice: 100.5, State: 3},
+			trade: models.TradeModel{TradeId: "trade-2", Price: 101, Volume: 2, MakerOrderId: "maker", TakerOrderId: "taker"},
+			want:  models.ExecutionReportModel{LastVolume: 2, LastPrice: 101, CumulativeVolume: 4, AveragePrice: 100.5, LeavesVolume: 6, Liquidity: models.LiquidityMaker, State: 3},
+		},
+		{
+			name:  "auction fill of the taker side",
+			order: models.OrderModel{OrderId: "bid", AskVolume: 2, FilledVolume: 2, FilledNotional: 200, FilledPrice: 100, State: 4},
+			trade: models.TradeModel{TradeId: "trade-4", Price: 100, Volume: 2, MakerOrderId: "ask", TakerOrderId: "bid", Auction: true},
+			want:  models.ExecutionReportModel{LastVolume: 2, LastPrice: 100, CumulativeVolume: 2, AveragePrice: 100, LeavesVolume: 0, Liquidity: models.LiquidityAuction, State: 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MapOrderFillToExecutionReport(tt.order, tt.trade)
+
+			if got.OrderId != tt.order.OrderId || got.TradeId != tt.trade.TradeId || got.ReportId == "" {
+				t.Errorf("MapOrderFillToExecutionReport() ids = %v, %v, %v", got.ReportId, got.OrderId, got.TradeId)
+			}
+
+			if math.Abs(got.AveragePrice-tt.want.AveragePrice) > 1e-9 {
+				t.Errorf("MapOrderFillToExecutionReport() AveragePrice = %v, want %v", got.AveragePrice, tt.want.AveragePrice)
+			}
+
+			got.ReportId, got.OrderId, got.TradeId, got.AveragePrice = "", "", "", tt.want.AveragePrice
+
+			if got != tt.want {
+				t.Errorf("MapOrderFillToExecutionReport() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
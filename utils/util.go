@@ -3,6 +3,12 @@ package utils
 import (
 	"strings"
 	"trade-order-processing-service/external/ops"
+	"trade-order-processing-service/models"
+)
+
+const (
+	BpsDenominator = 10000
+	VolumeEpsilon  = 1e-9
 )
 
 func GetOfferCurrencyCode(currencyPair string, direction int) string {
@@ -31,3 +37,20 @@ func Min(a float64, b float64) float64 {
 	return b
 
 }
+
+func GetRemainingVolume(orderInfo models.OrderModel) float64 {
+	remaining := orderInfo.AskVolume - orderInfo.FilledVolume
+
+	if remaining < VolumeEpsilon {
+		return 0
+	}
+
+	return remaining
+}
+
+func GetProtectionPrice(bestPrice float64, slippageBps float64, direction int) float64 {
+	if direction == int(ops.OpsOrderDirection_OPS_ORDER_DIRECTION_BUY) {
+		return bestPrice * (1 + slippageBps/BpsDenominator)
+	}
+	return bestPrice * (1 - slippageBps/BpsDenominator)
+}